@@ -30,6 +30,15 @@ var Scopes = []string{
 	// offline_access is automatically requested by MSAL
 }
 
+// MailboxSettingsScope is needed for the master category list. It is only
+// requested by the commands that use it, after 'auth login --mailbox-settings'.
+const MailboxSettingsScope = "https://graph.microsoft.com/MailboxSettings.ReadWrite"
+
+// withScopes returns Scopes plus the given additional scopes
+func withScopes(extra []string) []string {
+	return append(append([]string{}, Scopes...), extra...)
+}
+
 // OAuthClient manages OAuth2 authentication
 type OAuthClient struct {
 	clientID   string
@@ -76,14 +85,15 @@ func (c *OAuthClient) SetEmail(email string) {
 
 // GetAccessToken retrieves a valid access token for a specific account
 // Tries cache first, then refresh, then requires new login
-func (c *OAuthClient) GetAccessToken(ctx context.Context, email string) (string, error) {
+// extraScopes must have been granted at login in addition to Scopes
+func (c *OAuthClient) GetAccessToken(ctx context.Context, email string, extraScopes ...string) (string, error) {
 	// Try to get a token from cache first
 	accounts, err := c.app.Accounts(ctx)
 	if err == nil && len(accounts) > 0 {
 		// Search for specific account
 		for _, account := range accounts {
 			if account.PreferredUsername == email {
-				result, err := c.app.AcquireTokenSilent(ctx, Scopes,
+				result, err := c.app.AcquireTokenSilent(ctx, withScopes(extraScopes),
 					public.WithSilentAccount(account),
 				)
 				if err == nil {
@@ -115,11 +125,12 @@ func (c *OAuthClient) ListAccounts(ctx context.Context) ([]string, error) {
 
 // StartDeviceCodeFlow initiates the Device Code Flow
 // Returns the device code that the user must enter in the browser
-func (c *OAuthClient) StartDeviceCodeFlow(ctx context.Context) (*DeviceCodeResult, <-chan AuthResult, error) {
+// extraScopes are requested in addition to Scopes
+func (c *OAuthClient) StartDeviceCodeFlow(ctx context.Context, extraScopes ...string) (*DeviceCodeResult, <-chan AuthResult, error) {
 	resultChan := make(chan AuthResult, 1)
 
 	// Start device code flow - returns the code immediately
-	deviceCode, err := c.app.AcquireTokenByDeviceCode(ctx, Scopes)
+	deviceCode, err := c.app.AcquireTokenByDeviceCode(ctx, withScopes(extraScopes))
 	if err != nil {
 		close(resultChan)
 		return nil, nil, fmt.Errorf("failed to start device code flow: %w", err)
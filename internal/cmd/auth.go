@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os/exec"
 	"runtime"
@@ -13,7 +12,10 @@ import (
 	"github.com/yourname/o365-mail-cli/internal/profile"
 )
 
-var logoutAll bool
+var (
+	logoutAll            bool
+	loginMailboxSettings bool
+)
 
 var authCmd = &cobra.Command{
 	Use:   "auth",
@@ -27,7 +29,15 @@ var loginCmd = &cobra.Command{
 	Long: `Starts the OAuth2 Device Code Flow.
 
 You will receive a code to enter in your browser at microsoft.com/devicelogin.
-After successful authentication, a token is stored locally.`,
+After successful authentication, a token is stored locally.
+
+--mailbox-settings also requests the MailboxSettings.ReadWrite permission
+needed by the categories commands. Run it once for an account that is
+already logged in to grant the permission.
+
+Examples:
+  o365-mail-cli auth login
+  o365-mail-cli auth login --mailbox-settings`,
 	Annotations: map[string]string{profile.AnnotationKey: "auth"},
 	RunE:        runLogin,
 }
@@ -97,6 +107,7 @@ Examples:
 }
 
 func init() {
+	loginCmd.Flags().BoolVar(&loginMailboxSettings, "mailbox-settings", false, "Also grant access to mailbox settings (needed for 'categories')")
 	logoutCmd.Flags().BoolVar(&logoutAll, "all", false, "Logout all accounts")
 
 	authCmd.AddCommand(loginCmd)
@@ -108,7 +119,7 @@ func init() {
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Create OAuth client
 	oauthClient, err := auth.NewOAuthClient(cfg.ClientID, cfg.CacheDir)
@@ -119,7 +130,12 @@ func runLogin(cmd *cobra.Command, args []string) error {
 	// Start device code flow
 	printInfo("Starting login...")
 
-	deviceCode, resultChan, err := oauthClient.StartDeviceCodeFlow(ctx)
+	var extraScopes []string
+	if loginMailboxSettings {
+		extraScopes = append(extraScopes, auth.MailboxSettingsScope)
+	}
+
+	deviceCode, resultChan, err := oauthClient.StartDeviceCodeFlow(ctx, extraScopes...)
 	if err != nil {
 		return fmt.Errorf("failed to start device code flow: %w", err)
 	}
@@ -173,7 +189,7 @@ func runLogin(cmd *cobra.Command, args []string) error {
 }
 
 func runLogout(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	oauthClient, err := auth.NewOAuthClient(cfg.ClientID, cfg.CacheDir)
 	if err != nil {
@@ -244,7 +260,7 @@ func runLogout(cmd *cobra.Command, args []string) error {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	oauthClient, err := auth.NewOAuthClient(cfg.ClientID, cfg.CacheDir)
 	if err != nil {
@@ -354,7 +370,7 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 }
 
 func runDebug(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Determine email: argument > active account
 	var email string
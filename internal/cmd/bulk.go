@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+// messageSelection selects the messages a bulk command acts on, either
+// by explicit message IDs or by a search like `mail query` / `mail search`
+type messageSelection struct {
+	query   string
+	from    string
+	subject string
+	since   string
+	limit   int
+	dryRun  bool
+	yes     bool
+}
+
+var (
+	markReadSelection   messageSelection
+	markUnreadSelection messageSelection
+	moveSelection       messageSelection
+	trashSelection      messageSelection
+)
+
+// addFlags registers the selection flags on a bulk command
+func (s *messageSelection) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.query, "query", "", "Select emails by KQL query (like 'mail query')")
+	cmd.Flags().StringVar(&s.from, "from", "", "Select emails by sender")
+	cmd.Flags().StringVar(&s.subject, "subject", "", "Select emails by subject")
+	cmd.Flags().StringVar(&s.since, "since", "", "Select emails since (e.g., 24h, 7d, 30d)")
+	cmd.Flags().IntVar(&s.limit, "limit", 0, "Maximum number of selected emails (0 = all matches)")
+	cmd.Flags().BoolVar(&s.dryRun, "dry-run", false, "Show the selected emails without changing them")
+	cmd.Flags().BoolVarP(&s.yes, "yes", "y", false, "Don't ask for confirmation")
+}
+
+// hasFilter reports whether any search filter was given
+func (s *messageSelection) hasFilter() bool {
+	return s.query != "" || s.from != "" || s.subject != "" || s.since != ""
+}
+
+// resolve returns the IDs of the messages to act on. For search filters it
+// lists the matches and asks for confirmation. prompt is a format string
+// such as "Move %d email(s) to '%s'?" that gets the match count followed by
+// promptArgs. A nil result without error means there is nothing to do.
+func (s *messageSelection) resolve(ctx context.Context, client *mail.GraphClient, folderID string, args []string, prompt string, promptArgs ...interface{}) ([]string, error) {
+	if len(args) > 0 && s.hasFilter() {
+		return nil, fmt.Errorf("specify either message IDs or a search filter, not both")
+	}
+	if len(args) == 0 && !s.hasFilter() {
+		return nil, fmt.Errorf("message ID or search filter (--query, --from, --subject, --since) required")
+	}
+	if s.query != "" && (s.from != "" || s.subject != "" || s.since != "") {
+		return nil, fmt.Errorf("--query cannot be combined with --from, --subject or --since")
+	}
+
+	if len(args) > 0 {
+		if s.dryRun {
+			fmt.Printf("Dry run - %d email(s) selected:\n", len(args))
+			for _, id := range args {
+				fmt.Printf("  • %s\n", id)
+			}
+			return nil, nil
+		}
+		return args, nil
+	}
+
+	emails, err := s.search(ctx, client, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) == 0 {
+		printInfo("No emails found matching criteria.")
+		return nil, nil
+	}
+
+	fmt.Printf("Found %d email(s):\n", len(emails))
+	printEmailBullets(emails)
+
+	if s.dryRun {
+		fmt.Println("\nDry run - no changes made.")
+		return nil, nil
+	}
+
+	if !s.yes {
+		fmt.Printf("\n%s [y/N]: ", fmt.Sprintf(prompt, append([]interface{}{len(emails)}, promptArgs...)...))
+		var response string
+		fmt.Scanln(&response)
+
+		if response != "y" && response != "Y" {
+			printInfo("Cancelled.")
+			return nil, nil
+		}
+	}
+
+	ids := make([]string, len(emails))
+	for i, email := range emails {
+		ids[i] = email.MessageID
+	}
+	return ids, nil
+}
+
+// search runs the KQL query or the field filters in folderID ("" = all folders)
+func (s *messageSelection) search(ctx context.Context, client *mail.GraphClient, folderID string) ([]mail.Email, error) {
+	if s.query != "" {
+		debugLog("Selecting emails via KQL: %s", s.query)
+		return client.SearchEmailsKQL(ctx, folderID, s.query, s.limit)
+	}
+
+	var since time.Time
+	if s.since != "" {
+		duration, err := parseDuration(s.since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since value: %w", err)
+		}
+		since = time.Now().Add(-duration)
+	}
+
+	debugLog("Selecting emails via Graph API filter")
+	return client.SearchEmails(ctx, folderID, s.from, s.subject, since, s.limit)
+}
+
+// resolveSourceFolder returns the folder ID for --folder, or "" for "all"
+func resolveSourceFolder(ctx context.Context, client *mail.GraphClient, name string) (string, error) {
+	if strings.ToLower(name) == "all" {
+		return "", nil
+	}
+	return client.GetFolderByName(ctx, name)
+}
+
+// printEmailBullets prints one line per email for previews and confirmations
+func printEmailBullets(emails []mail.Email) {
+	for _, email := range emails {
+		date := email.Date.Local().Format("2006-01-02")
+		fmt.Printf("  • [%s] %s - %s\n", date, truncate(email.From, 30), truncate(email.Subject, 40))
+	}
+}
+
+// reportBulkResults prints the outcome of a bulk operation. Failed messages are
+// listed individually and the first failure is returned so the exit code reflects it.
+func reportBulkResults(results []mail.BulkResult, err error, action string) error {
+	done := 0
+	var firstErr error
+	for _, result := range results {
+		switch {
+		case result.Err == nil:
+			done++
+		case result.Err != err:
+			fmt.Printf("✗ %s: %v\n", result.MessageID, result.Err)
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+		}
+	}
+
+	if err != nil {
+		if done > 0 {
+			printInfo("Stopped after %d of %d email(s) %s", done, len(results), action)
+		}
+		return err
+	}
+	if firstErr != nil {
+		printInfo("%d of %d email(s) %s", done, len(results), action)
+		return fmt.Errorf("%d email(s) failed: %w", len(results)-done, firstErr)
+	}
+
+	printSuccess("%d email(s) %s", done, action)
+	return nil
+}
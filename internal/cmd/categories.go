@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/auth"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+var categoriesCmd = &cobra.Command{
+	Use:   "categories",
+	Short: "Manage categories",
+	Long: `Commands for the Outlook master category list.
+
+They need the MailboxSettings.ReadWrite permission, which is only requested
+by 'auth login --mailbox-settings'.
+
+Use 'mail categorize' to apply categories to emails.`,
+}
+
+var categoriesListJSON bool
+
+var categoriesListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List categories",
+	Annotations: map[string]string{profile.AnnotationKey: "categories.read"},
+	RunE:        runCategoriesList,
+}
+
+var categoriesCreateColor string
+
+var categoriesCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create category",
+	Long: `Adds a category to the master list.
+
+Colors: ` + strings.Join(mail.CategoryColors, ", ") + ` or none.
+
+Examples:
+  o365-mail-cli categories create "Project Alpha" --color blue
+  o365-mail-cli categories create Finance --color darkgreen`,
+	Annotations: map[string]string{profile.AnnotationKey: "categories.manage"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCategoriesCreate,
+}
+
+var categoriesUpdateColor string
+
+var categoriesUpdateCmd = &cobra.Command{
+	Use:   "update [name]",
+	Short: "Change the color of a category",
+	Long: `Changes the color of a category. Outlook does not allow renaming categories.
+
+Examples:
+  o365-mail-cli categories update "Project Alpha" --color purple`,
+	Annotations: map[string]string{profile.AnnotationKey: "categories.manage"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCategoriesUpdate,
+}
+
+var categoriesDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete category",
+	Long: `Removes a category from the master list.
+Emails keep the category name but it is shown without a color.
+
+Examples:
+  o365-mail-cli categories delete "Old Project"`,
+	Annotations: map[string]string{profile.AnnotationKey: "categories.manage"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCategoriesDelete,
+}
+
+func init() {
+	categoriesListCmd.Flags().BoolVar(&categoriesListJSON, "json", false, "Output as JSON")
+
+	categoriesCreateCmd.Flags().StringVar(&categoriesCreateColor, "color", "none", "Category color")
+	categoriesUpdateCmd.Flags().StringVar(&categoriesUpdateColor, "color", "", "New category color")
+	categoriesUpdateCmd.MarkFlagRequired("color")
+
+	categoriesCmd.AddCommand(categoriesListCmd)
+	categoriesCmd.AddCommand(categoriesCreateCmd)
+	categoriesCmd.AddCommand(categoriesUpdateCmd)
+	categoriesCmd.AddCommand(categoriesDeleteCmd)
+}
+
+func runCategoriesList(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx, auth.MailboxSettingsScope)
+	if err != nil {
+		return err
+	}
+
+	debugLog("Fetching categories via Graph API")
+
+	categories, err := client.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	if categoriesListJSON {
+		return outputJSON(categories)
+	}
+
+	if len(categories) == 0 {
+		printInfo("No categories found.")
+		return nil
+	}
+
+	fmt.Printf("\n%-30s %s\n", "Name", "Color")
+	fmt.Println(strings.Repeat("─", 45))
+	for _, category := range categories {
+		fmt.Printf("%-30s %s\n", truncate(category.DisplayName, 30), mail.CategoryColorName(category.Color))
+	}
+
+	fmt.Printf("\n%d categories found\n", len(categories))
+
+	return nil
+}
+
+func runCategoriesCreate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	color, err := mail.ParseCategoryColor(categoriesCreateColor)
+	if err != nil {
+		return err
+	}
+
+	client, err := getGraphClient(ctx, auth.MailboxSettingsScope)
+	if err != nil {
+		return err
+	}
+
+	debugLog("Creating category via Graph API")
+
+	if _, err := client.CreateCategory(ctx, mail.Category{DisplayName: args[0], Color: color}); err != nil {
+		return err
+	}
+
+	printSuccess("Category '%s' created", args[0])
+
+	return nil
+}
+
+func runCategoriesUpdate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	color, err := mail.ParseCategoryColor(categoriesUpdateColor)
+	if err != nil {
+		return err
+	}
+
+	client, err := getGraphClient(ctx, auth.MailboxSettingsScope)
+	if err != nil {
+		return err
+	}
+
+	category, err := client.GetCategoryByName(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetCategoryColor(ctx, category.ID, color); err != nil {
+		return err
+	}
+
+	printSuccess("Category '%s' is now %s", category.DisplayName, mail.CategoryColorName(color))
+
+	return nil
+}
+
+func runCategoriesDelete(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx, auth.MailboxSettingsScope)
+	if err != nil {
+		return err
+	}
+
+	category, err := client.GetCategoryByName(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	debugLog("Deleting category via Graph API")
+
+	if err := client.DeleteCategory(ctx, category.ID); err != nil {
+		return err
+	}
+
+	printSuccess("Category '%s' deleted", category.DisplayName)
+
+	return nil
+}
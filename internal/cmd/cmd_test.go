@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+// cliResult holds the captured output of a command run
+type cliResult struct {
+	Stdout string
+	Stderr string
+	Err    error
+}
+
+// newTestServer starts a fake Graph server and points the CLI at it
+// via O365_GRAPH_URL / O365_ACCESS_TOKEN, with HOME in a temp dir.
+func newTestServer(t *testing.T) *graphtest.Server {
+	t.Helper()
+
+	srv := graphtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("O365_GRAPH_URL", srv.BaseURL())
+	t.Setenv("O365_ACCESS_TOKEN", graphtest.Token)
+	t.Setenv("O365_ACCOUNT", "")
+
+	return srv
+}
+
+// runCLI executes rootCmd with args, feeding stdin and capturing stdout/stderr
+func runCLI(t *testing.T, stdin string, args ...string) cliResult {
+	t.Helper()
+
+	resetCommands(rootCmd)
+
+	oldStdout, oldStderr, oldStdin := os.Stdout, os.Stderr, os.Stdin
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	inR, inW, _ := os.Pipe()
+	os.Stdout, os.Stderr, os.Stdin = outW, errW, inR
+	defer func() {
+		os.Stdout, os.Stderr, os.Stdin = oldStdout, oldStderr, oldStdin
+	}()
+
+	go func() {
+		io.WriteString(inW, stdin)
+		inW.Close()
+	}()
+
+	var stdout, stderr bytes.Buffer
+	outDone := make(chan struct{})
+	errDone := make(chan struct{})
+	go func() { io.Copy(&stdout, outR); close(outDone) }()
+	go func() { io.Copy(&stderr, errR); close(errDone) }()
+
+	rootCmd.SetArgs(args)
+	err := Execute()
+
+	outW.Close()
+	errW.Close()
+	<-outDone
+	<-errDone
+	inR.Close()
+
+	return cliResult{Stdout: stdout.String(), Stderr: stderr.String(), Err: err}
+}
+
+// resetCommands restores every flag to its default and drops the context
+// left over from a previous Execute, so state doesn't leak between runs
+func resetCommands(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.SetContext(nil)
+	for _, c := range cmd.Commands() {
+		resetCommands(c)
+	}
+}
+
+func mustSucceed(t *testing.T, res cliResult) {
+	t.Helper()
+	if res.Err != nil {
+		t.Fatalf("command failed: %v\nstdout:\n%s\nstderr:\n%s", res.Err, res.Stdout, res.Stderr)
+	}
+}
+
+func assertContains(t *testing.T, got, want string) {
+	t.Helper()
+	if !strings.Contains(got, want) {
+		t.Errorf("output does not contain %q:\n%s", want, got)
+	}
+}
+
+// writeProfile writes a permission profile into the test HOME
+func writeProfile(t *testing.T, name, content string) {
+	t.Helper()
+	dir := filepath.Join(os.Getenv("HOME"), ".o365-mail-cli", "profiles")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".yaml"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/yourname/o365-mail-cli/internal/config"
+	"github.com/yourname/o365-mail-cli/internal/mail"
 	"github.com/yourname/o365-mail-cli/internal/profile"
 )
 
@@ -29,12 +30,17 @@ var configSetCmd = &cobra.Command{
 Available keys:
   client_id       - Azure App Client ID
   current_account - Active account (email address)
-  imap_server     - IMAP server (default: outlook.office365.com)
-  smtp_server     - SMTP server (default: smtp.office365.com)
+  graph_url       - Microsoft Graph endpoint (default: https://graph.microsoft.com/v1.0)
+  user_agent      - User-Agent header sent to Graph (default: o365-mail-cli)
+  retry_max_attempts - Attempts for throttled requests incl. the first (default: 4)
+  retry_base_delay   - Initial retry backoff, doubled per attempt (default: 1s)
+  retry_max_delay    - Maximum computed backoff; Retry-After is always honored (default: 30s)
+  markdown_css       - CSS file styling --markdown bodies, "none" for unstyled (default: built-in theme)
 
 Examples:
   o365-mail-cli config set client_id "your-client-id"
-  o365-mail-cli config set current_account "user@example.com"`,
+  o365-mail-cli config set current_account "user@example.com"
+  o365-mail-cli config set graph_url "https://graph.microsoft.us/v1.0"`,
 	Annotations: map[string]string{profile.AnnotationKey: "config.write"},
 	Args:        cobra.ExactArgs(2),
 	RunE:        runConfigSet,
@@ -70,6 +76,10 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Active Account:  %s\n", valueOrNone(getActiveAccount()))
 	fmt.Printf("Cache Dir:       %s\n", cfg.CacheDir)
 	fmt.Printf("Debug:           %v\n", cfg.Debug)
+	fmt.Printf("Graph URL:       %s\n", valueOrDefault(cfg.GraphURL, mail.GraphAPIBaseURL))
+	fmt.Printf("User Agent:      %s\n", valueOrDefault(cfg.UserAgent, mail.DefaultUserAgent))
+	fmt.Printf("Retry:           %s\n", formatRetryPolicy())
+	fmt.Printf("Markdown CSS:    %s\n", valueOrDefault(cfg.MarkdownCSS, "built-in"))
 
 	fmt.Printf("\nConfig file: %s/config.yaml\n", config.GetConfigDir())
 
@@ -106,6 +116,18 @@ func runConfigPath(cmd *cobra.Command, args []string) {
 
 // Helper
 
+func formatRetryPolicy() string {
+	retry := retryPolicy()
+	return fmt.Sprintf("%d attempts, backoff %s-%s", retry.MaxAttempts, retry.BaseDelay, retry.MaxDelay)
+}
+
+func valueOrDefault(s, def string) string {
+	if s == "" {
+		return def + " (default)"
+	}
+	return s
+}
+
 func valueOrNone(s string) string {
 	if s == "" {
 		return "(not set)"
@@ -1,13 +1,15 @@
 package cmd
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/compose"
+	"github.com/yourname/o365-mail-cli/internal/mail"
 	"github.com/yourname/o365-mail-cli/internal/profile"
 )
 
@@ -21,10 +23,15 @@ var draftsCmd = &cobra.Command{
 var (
 	draftTo       []string
 	draftCc       []string
+	draftBcc      []string
 	draftSubject  string
 	draftBody     string
 	draftBodyFile string
 	draftHTML     bool
+	draftAttach   []string
+	draftTextAlt  bool
+	draftMarkdown bool
+	draftEdit     bool
 )
 
 var draftCreateCmd = &cobra.Command{
@@ -34,7 +41,11 @@ var draftCreateCmd = &cobra.Command{
 
 Examples:
   o365-mail-cli mail drafts create --to user@example.com --subject "Test" --body "Hello!"
-  o365-mail-cli mail drafts create --to user@example.com --subject "Report" --body-file draft.txt`,
+  o365-mail-cli mail drafts create --to user@example.com --subject "Report" --body-file draft.txt
+  o365-mail-cli mail drafts create --to user@example.com --subject "Slides" --body "See attached" --attach deck.pptx
+  o365-mail-cli mail drafts create --to user@example.com --subject "Notes" --body-file notes.md --markdown
+  o365-mail-cli mail drafts create --to user@example.com --subject "Urgent" --body "Call me" --importance high --read-receipt
+  o365-mail-cli mail drafts create --edit`,
 	Annotations: map[string]string{profile.AnnotationKey: "drafts.create"},
 	RunE:        runDraftCreate,
 }
@@ -54,14 +65,57 @@ Examples:
 	RunE:        runDraftList,
 }
 
+// Draft edit command
+var (
+	draftEditTo       []string
+	draftEditCc       []string
+	draftEditBcc      []string
+	draftEditSubject  string
+	draftEditBody     string
+	draftEditBodyFile string
+	draftEditHTML     bool
+	draftEditMarkdown bool
+	draftEditAttach   []string
+	draftEditRemove   []string
+	draftEditEditor   bool
+)
+
+var draftEditCmd = &cobra.Command{
+	Use:   "edit [message-id]",
+	Short: "Edit a draft",
+	Long: `Changes the recipients, subject, body or attachments of a draft.
+Only the given fields change; --to, --cc and --bcc replace the current
+recipients of that kind (pass an empty value to remove them all).
+
+--edit opens the draft in $VISUAL or $EDITOR. Files listed on Attach:
+lines are added to the draft, the current attachments are shown below
+the message for reference.
+
+Examples:
+  o365-mail-cli mail drafts edit AAMkAGI2... --subject "Updated plan"
+  o365-mail-cli mail drafts edit AAMkAGI2... --to alice@example.com --cc bob@example.com
+  o365-mail-cli mail drafts edit AAMkAGI2... --body-file reply.md --markdown
+  o365-mail-cli mail drafts edit AAMkAGI2... --attach v2.pdf --remove-attachment v1.pdf
+  o365-mail-cli mail drafts edit AAMkAGI2... --edit`,
+	Annotations: map[string]string{profile.AnnotationKey: "drafts.edit"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runDraftEdit,
+}
+
 // Draft send command
+var draftSendAt string
+
 var draftSendCmd = &cobra.Command{
 	Use:   "send [message-id]",
 	Short: "Send a draft",
 	Long: `Sends a draft email and removes it from the Drafts folder.
 
+--send-at defers delivery like 'mail send --send-at'; the email waits in
+the Outbox until then (see 'drafts scheduled').
+
 Examples:
-  o365-mail-cli mail drafts send AAMkAGI2...`,
+  o365-mail-cli mail drafts send AAMkAGI2...
+  o365-mail-cli mail drafts send AAMkAGI2... --send-at "tomorrow 08:00"`,
 	Annotations: map[string]string{profile.AnnotationKey: "drafts.send"},
 	Args:        cobra.ExactArgs(1),
 	RunE:        runDraftSend,
@@ -84,20 +138,43 @@ func init() {
 	// Draft create flags
 	draftCreateCmd.Flags().StringArrayVar(&draftTo, "to", nil, "Recipients")
 	draftCreateCmd.Flags().StringArrayVar(&draftCc, "cc", nil, "CC recipients")
+	draftCreateCmd.Flags().StringArrayVar(&draftBcc, "bcc", nil, "BCC recipients")
 	draftCreateCmd.Flags().StringVar(&draftSubject, "subject", "", "Subject")
 	draftCreateCmd.Flags().StringVar(&draftBody, "body", "", "Message body")
 	draftCreateCmd.Flags().StringVar(&draftBodyFile, "body-file", "", "Read body from file")
 	draftCreateCmd.Flags().BoolVar(&draftHTML, "html", false, "Body is HTML")
-
-	draftCreateCmd.MarkFlagRequired("to")
-	draftCreateCmd.MarkFlagRequired("subject")
+	draftCreateCmd.Flags().StringArrayVar(&draftAttach, "attach", nil, "Attach a file (can be specified multiple times)")
+	draftCreateCmd.Flags().BoolVar(&draftTextAlt, "text-alternative", false, "Add a plain-text alternative generated from the HTML body")
+	draftCreateCmd.Flags().BoolVar(&draftMarkdown, "markdown", false, "Body is Markdown, saved as HTML")
+	draftCreateCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	draftCreateCmd.Flags().BoolVar(&draftEdit, "edit", false, "Compose the draft in $EDITOR")
+	draftProperties.addFlags(draftCreateCmd)
+
+	// Draft edit flags
+	draftEditCmd.Flags().StringArrayVar(&draftEditTo, "to", nil, "Replace the recipients")
+	draftEditCmd.Flags().StringArrayVar(&draftEditCc, "cc", nil, "Replace the CC recipients")
+	draftEditCmd.Flags().StringArrayVar(&draftEditBcc, "bcc", nil, "Replace the BCC recipients")
+	draftEditCmd.Flags().StringVar(&draftEditSubject, "subject", "", "New subject")
+	draftEditCmd.Flags().StringVar(&draftEditBody, "body", "", "New message body")
+	draftEditCmd.Flags().StringVar(&draftEditBodyFile, "body-file", "", "Read the new body from file")
+	draftEditCmd.Flags().BoolVar(&draftEditHTML, "html", false, "Body is HTML")
+	draftEditCmd.Flags().BoolVar(&draftEditMarkdown, "markdown", false, "Body is Markdown, saved as HTML")
+	draftEditCmd.Flags().StringArrayVar(&draftEditAttach, "attach", nil, "Attach a file (can be specified multiple times)")
+	draftEditCmd.Flags().StringArrayVar(&draftEditRemove, "remove-attachment", nil, "Remove the attachment with this name (can be specified multiple times)")
+	draftEditCmd.Flags().BoolVar(&draftEditEditor, "edit", false, "Edit the draft in $EDITOR")
+	draftEditCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	draftEditCmd.MarkFlagsMutuallyExclusive("body", "body-file")
 
 	// Draft list flags
 	draftListCmd.Flags().BoolVar(&draftListJSON, "json", false, "Output as JSON")
 
+	// Draft send flags
+	draftSendCmd.Flags().StringVar(&draftSendAt, "send-at", "", "Deliver at this time instead of now")
+
 	// Add subcommands
 	draftsCmd.AddCommand(draftCreateCmd)
 	draftsCmd.AddCommand(draftListCmd)
+	draftsCmd.AddCommand(draftEditCmd)
 	draftsCmd.AddCommand(draftSendCmd)
 	draftsCmd.AddCommand(draftDeleteCmd)
 
@@ -106,12 +183,7 @@ func init() {
 }
 
 func runDraftCreate(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-
-	// Validation
-	if len(draftTo) == 0 {
-		return fmt.Errorf("at least one recipient (--to) required")
-	}
+	ctx := cmd.Context()
 
 	// Body from file or direct
 	body := draftBody
@@ -123,28 +195,87 @@ func runDraftCreate(cmd *cobra.Command, args []string) error {
 		body = string(content)
 	}
 
-	if body == "" {
+	msg := compose.Message{To: draftTo, Cc: draftCc, Bcc: draftBcc, Subject: draftSubject, Attach: draftAttach, Body: body}
+	action := editDraft
+	if draftEdit {
+		var err error
+		msg, action, err = editMessage(msg, compose.HeaderFields, "", editorExtension(draftHTML, draftMarkdown),
+			permittedActions([]string{editDraft, editSend}, map[string]string{editSend: "mail.send"})...)
+		if err != nil {
+			return err
+		}
+		if action == editAbort {
+			printInfo("Cancelled.")
+			return nil
+		}
+	}
+
+	// Validation
+	if len(msg.To) == 0 {
+		return fmt.Errorf("at least one recipient (--to) required")
+	}
+	if msg.Subject == "" {
+		return fmt.Errorf("subject required (--subject)")
+	}
+	if msg.Body == "" {
 		return fmt.Errorf("message body required (--body or --body-file)")
 	}
 
+	attachments, err := mail.LoadAttachments(msg.Attach)
+	if err != nil {
+		return err
+	}
+
+	opts := mail.SendOptions{
+		To:          msg.To,
+		Cc:          msg.Cc,
+		Bcc:         msg.Bcc,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		HTML:        draftHTML,
+		Attachments: attachments,
+	}
+	if err := draftProperties.apply(&opts); err != nil {
+		return err
+	}
+	if draftMarkdown {
+		if err := composeMarkdown(&opts); err != nil {
+			return err
+		}
+	}
+	if err := composeHTML(&opts, draftBodyFile, draftTextAlt); err != nil {
+		return err
+	}
+
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
+	if action == editSend {
+		debugLog("Sending email via Microsoft Graph API")
+		if err := client.Send(ctx, opts); err != nil {
+			return fmt.Errorf("send failed: %w", err)
+		}
+		printSuccess("Email sent to %s", strings.Join(opts.To, ", "))
+		printAttachmentNames(opts.Attachments)
+		return nil
+	}
+
 	debugLog("Creating draft via Graph API")
 
-	draftID, err := client.SaveDraft(draftTo, draftCc, draftSubject, body, draftHTML)
+	draftID, err := client.SaveDraft(ctx, opts)
 	if err != nil {
 		return fmt.Errorf("failed to save draft: %w", err)
 	}
 
 	printSuccess("Draft saved (ID: %s)", draftID)
+	printAttachmentNames(opts.Attachments)
 	return nil
 }
 
 func runDraftList(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
@@ -153,7 +284,7 @@ func runDraftList(cmd *cobra.Command, args []string) error {
 
 	debugLog("Fetching drafts via Graph API")
 
-	drafts, err := client.ListDrafts(50)
+	drafts, err := client.ListDrafts(ctx, 50)
 	if err != nil {
 		return err
 	}
@@ -180,7 +311,7 @@ func runDraftList(cmd *cobra.Command, args []string) error {
 		}
 		subject := truncate(draft.Subject, 35)
 		date := draft.Date.Local().Format("2006-01-02 15:04")
-		id := truncate(draft.ID, 48)
+		id := truncate(draft.MessageID, 48)
 
 		fmt.Printf("%-50s %-20s %-25s %s\n", id, date, to, subject)
 	}
@@ -190,18 +321,191 @@ func runDraftList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runDraftEdit(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	draftID := args[0]
+	flags := cmd.Flags()
+
+	var update mail.DraftUpdate
+	if flags.Changed("to") {
+		update.To = nonEmpty(draftEditTo)
+	}
+	if flags.Changed("cc") {
+		update.Cc = nonEmpty(draftEditCc)
+	}
+	if flags.Changed("bcc") {
+		update.Bcc = nonEmpty(draftEditBcc)
+	}
+	if flags.Changed("subject") {
+		update.Subject = &draftEditSubject
+	}
+	if flags.Changed("body") {
+		update.Body = &draftEditBody
+	}
+	if draftEditBodyFile != "" {
+		content, err := os.ReadFile(draftEditBodyFile)
+		if err != nil {
+			return fmt.Errorf("could not read body file: %w", err)
+		}
+		body := string(content)
+		update.Body = &body
+	}
+	update.HTML = draftEditHTML
+	update.RemoveAttachments = draftEditRemove
+	attach := draftEditAttach
+	if !draftEditEditor && update.To == nil && update.Cc == nil && update.Bcc == nil && update.Subject == nil &&
+		update.Body == nil && len(attach) == 0 && len(update.RemoveAttachments) == 0 {
+		return fmt.Errorf("nothing to change (use --to, --cc, --bcc, --subject, --body, --attach, --remove-attachment or --edit)")
+	}
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	action := editDraft
+	if draftEditEditor {
+		draft, err := client.GetMessageDetail(ctx, draftID)
+		if err != nil {
+			return err
+		}
+		msg, html := draftEditorMessage(draft, update)
+		msg.Attach = attach
+		if html && draftEditMarkdown {
+			return fmt.Errorf("--markdown cannot be used with --edit on an HTML draft")
+		}
+
+		msg, action, err = editMessage(msg, compose.HeaderFields, draftAttachmentList(draft), editorExtension(html, draftEditMarkdown),
+			permittedActions([]string{editDraft, editSend}, map[string]string{editSend: "drafts.send"})...)
+		if err != nil {
+			return err
+		}
+		if action == editAbort {
+			printInfo("Cancelled.")
+			return nil
+		}
+		update.To, update.Cc, update.Bcc = nonNilStrings(msg.To), nonNilStrings(msg.Cc), nonNilStrings(msg.Bcc)
+		update.Subject, update.Body, update.HTML = &msg.Subject, &msg.Body, html
+		attach = msg.Attach
+	}
+
+	if update.Body != nil && draftEditMarkdown {
+		rendered, err := renderMarkdown(*update.Body)
+		if err != nil {
+			return err
+		}
+		update.Body, update.HTML = &rendered, true
+	}
+
+	update.AddAttachments, err = mail.LoadAttachments(attach)
+	if err != nil {
+		return err
+	}
+
+	debugLog("Updating draft via Graph API")
+
+	if err := client.UpdateDraft(ctx, draftID, update); err != nil {
+		return fmt.Errorf("failed to update draft: %w", err)
+	}
+
+	if action == editSend {
+		if err := client.SendDraft(ctx, draftID); err != nil {
+			return fmt.Errorf("failed to send draft: %w", err)
+		}
+		printSuccess("Draft updated and sent")
+		return nil
+	}
+
+	printSuccess("Draft updated")
+	printAttachmentNames(update.AddAttachments)
+	return nil
+}
+
+// draftEditorMessage returns the editable fields of a draft with the
+// changes from the command line applied, and whether the body is HTML
+func draftEditorMessage(draft *mail.MessageDetail, update mail.DraftUpdate) (compose.Message, bool) {
+	msg := compose.Message{To: draft.To, Cc: draft.Cc, Bcc: draft.Bcc, Subject: draft.Subject, Body: draft.Body}
+	html := strings.EqualFold(draft.BodyType, "html")
+	if update.To != nil {
+		msg.To = update.To
+	}
+	if update.Cc != nil {
+		msg.Cc = update.Cc
+	}
+	if update.Bcc != nil {
+		msg.Bcc = update.Bcc
+	}
+	if update.Subject != nil {
+		msg.Subject = *update.Subject
+	}
+	if update.Body != nil {
+		msg.Body, html = *update.Body, update.HTML
+	}
+	return msg, html
+}
+
+// draftAttachmentList lists the current attachments of a draft below the
+// editing template
+func draftAttachmentList(draft *mail.MessageDetail) string {
+	if len(draft.Attachments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Current attachments (remove with --remove-attachment):\n")
+	for _, att := range draft.Attachments {
+		fmt.Fprintf(&b, "  %s (%d bytes)\n", att.Name, att.Size)
+	}
+	return b.String()
+}
+
+// nonEmpty drops empty values, so that --to "" clears the recipients
+func nonEmpty(values []string) []string {
+	result := []string{}
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// nonNilStrings returns an empty list for nil, which clears a field in a DraftUpdate
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 func runDraftSend(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
+	var sendTime time.Time
+	if draftSendAt != "" {
+		var err error
+		if sendTime, err = parseSendAt(draftSendAt, time.Now()); err != nil {
+			return fmt.Errorf("invalid --send-at value: %w", err)
+		}
+	}
+
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
+	if !sendTime.IsZero() {
+		debugLog("Scheduling draft via Graph API")
+		if err := client.SendDraftAt(ctx, messageID, sendTime); err != nil {
+			return fmt.Errorf("failed to schedule draft: %w", err)
+		}
+		printSuccess("Draft scheduled for %s", sendTime.Local().Format("Mon, 2 Jan 2006 15:04"))
+		return nil
+	}
+
 	debugLog("Sending draft via Graph API")
 
-	if err := client.SendDraft(messageID); err != nil {
+	if err := client.SendDraft(ctx, messageID); err != nil {
 		return fmt.Errorf("failed to send draft: %w", err)
 	}
 
@@ -210,7 +514,7 @@ func runDraftSend(cmd *cobra.Command, args []string) error {
 }
 
 func runDraftDelete(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -220,7 +524,7 @@ func runDraftDelete(cmd *cobra.Command, args []string) error {
 
 	debugLog("Deleting draft via Graph API")
 
-	if err := client.DeleteDraft(messageID); err != nil {
+	if err := client.DeleteDraft(ctx, messageID); err != nil {
 		return fmt.Errorf("failed to delete draft: %w", err)
 	}
 
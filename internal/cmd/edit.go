@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourname/o365-mail-cli/internal/compose"
+)
+
+// Actions offered after editing a message in $EDITOR
+const (
+	editSend  = "send"
+	editDraft = "draft"
+	editAbort = "abort"
+)
+
+// editMessage opens msg in the editor until it parses and asks what to do
+// with the result. actions lists the offered actions besides editing again
+// and aborting, the first one being the default for an empty answer.
+// Reaching the end of stdin aborts.
+func editMessage(msg compose.Message, fields []string, quoted, ext string, actions ...string) (compose.Message, string, error) {
+	text := compose.Template(msg, fields, quoted)
+	for {
+		edited, err := compose.Edit(text, ext)
+		if err != nil {
+			return compose.Message{}, "", err
+		}
+		text = edited
+
+		parsed, err := compose.Parse(edited)
+		if err == nil && parsed.Body == "" {
+			err = fmt.Errorf("message body is empty")
+		}
+		offered := []string{}
+		if err != nil {
+			printError(err)
+		} else {
+			offered = actions
+		}
+
+		switch action := promptEditAction(offered); action {
+		case "":
+			continue
+		case editAbort:
+			return compose.Message{}, editAbort, nil
+		default:
+			return parsed, action, nil
+		}
+	}
+}
+
+// permittedActions drops the actions whose permission the active profile
+// doesn't grant, so the prompt after editing can't bypass a profile.
+// permissions maps an action to the permission it needs.
+func permittedActions(actions []string, permissions map[string]string) []string {
+	var result []string
+	for _, action := range actions {
+		if permission, ok := permissions[action]; ok && !activeProfile.IsAllowed(permission) {
+			continue
+		}
+		result = append(result, action)
+	}
+	return result
+}
+
+// promptEditAction asks for one of actions, "edit" (returned as "") or abort
+func promptEditAction(actions []string) string {
+	var labels, keys []string
+	for _, action := range actions {
+		if action == editDraft {
+			labels = append(labels, "save as draft")
+		} else {
+			labels = append(labels, action)
+		}
+		keys = append(keys, action[:1])
+	}
+	labels = append(labels, "edit again", "abort")
+	keys = append(keys, "e", "a")
+	keys[0] = strings.ToUpper(keys[0])
+	prompt := strings.Join(labels[:len(labels)-1], ", ") + " or abort? [" + strings.Join(keys, "/") + "]: "
+	prompt = strings.ToUpper(prompt[:1]) + prompt[1:]
+
+	for {
+		fmt.Print(prompt)
+		var response string
+		if _, err := fmt.Scanln(&response); err == io.EOF {
+			fmt.Println()
+			return editAbort
+		}
+		switch response = strings.ToLower(strings.TrimSpace(response)); response {
+		case "":
+			if len(actions) > 0 {
+				return actions[0]
+			}
+			return ""
+		case "e", "edit":
+			return ""
+		case "a", "abort":
+			return editAbort
+		}
+		for _, action := range actions {
+			if response == action[:1] || response == action {
+				return action
+			}
+		}
+	}
+}
+
+// editorExtension picks the temporary file extension for syntax highlighting
+func editorExtension(html, markdown bool) string {
+	switch {
+	case markdown:
+		return ".md"
+	case html:
+		return ".html"
+	}
+	return ".txt"
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Exit codes returned by the CLI
+const (
+	ExitOK         = 0
+	ExitError      = 1
+	ExitAuth       = 2
+	ExitNetwork    = 3
+	ExitConfig     = 4
+	ExitNotFound   = 5
+	ExitThrottled  = 6
+	ExitConflict   = 7
+	ExitPermission = 8
+	ExitTimeout    = 9
+
+	// ExitInterrupted follows the shell convention for SIGINT (128 + 2)
+	ExitInterrupted = 130
+)
+
+// cliError attaches an exit code and error code to an error that
+// does not carry one itself (e.g. missing login, broken config)
+type cliError struct {
+	exitCode int
+	code     string
+	err      error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func withExitCode(exitCode int, code string, err error) error {
+	return &cliError{exitCode: exitCode, code: code, err: err}
+}
+
+// errorEnvelope is printed to stdout instead of the plain error when --json is set
+type errorEnvelope struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	ExitCode  int    `json:"exit_code"`
+	Status    int    `json:"status,omitempty"`
+	GraphCode string `json:"graph_code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ExitCode maps an error returned by Execute to a process exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	exitCode, _ := classifyError(err)
+	return exitCode
+}
+
+// classifyError returns the exit code and a stable error code for scripts
+func classifyError(err error) (int, string) {
+	var ce *cliError
+	if errors.As(err, &ce) {
+		return ce.exitCode, ce.code
+	}
+
+	var denied *profile.PermissionDeniedError
+	if errors.As(err, &denied) {
+		return ExitPermission, "PermissionDenied"
+	}
+
+	switch {
+	case errors.Is(err, mail.ErrUnauthorized):
+		return ExitAuth, "Unauthorized"
+	case errors.Is(err, mail.ErrForbidden):
+		return ExitPermission, "Forbidden"
+	case errors.Is(err, mail.ErrNotFound):
+		return ExitNotFound, "NotFound"
+	case errors.Is(err, mail.ErrThrottled):
+		return ExitThrottled, "Throttled"
+	case errors.Is(err, mail.ErrConflict):
+		return ExitConflict, "Conflict"
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout, "Timeout"
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted, "Interrupted"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ExitNetwork, "NetworkError"
+	}
+
+	return ExitError, "Error"
+}
+
+// reportError prints err either as plain text on stderr or,
+// when the failed command was run with --json, as a JSON envelope on stdout
+func reportError(cmd *cobra.Command, err error) {
+	if !wantsJSON(cmd) {
+		printError(err)
+		return
+	}
+
+	exitCode, code := classifyError(err)
+	detail := errorDetail{
+		Code:     code,
+		Message:  err.Error(),
+		ExitCode: exitCode,
+	}
+
+	var graphErr *mail.GraphError
+	if errors.As(err, &graphErr) {
+		detail.Status = graphErr.StatusCode
+		detail.GraphCode = graphErr.Code
+		detail.RequestID = graphErr.RequestID
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(errorEnvelope{Error: detail}); encErr != nil {
+		printError(fmt.Errorf("%v (failed to encode JSON error: %v)", err, encErr))
+	}
+}
+
+// wantsJSON reports whether the command has a --json flag that is set
+func wantsJSON(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+	flag := cmd.Flags().Lookup("json")
+	return flag != nil && flag.Value.Type() == "bool" && flag.Value.String() == "true"
+}
@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mbox"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Export Command
+var (
+	exportFolder    string
+	exportQuery     string
+	exportSince     string
+	exportUntil     string
+	exportLimit     int
+	exportOutputDir string
+	exportMbox      string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [message-id...]",
+	Short: "Export emails as .eml files or to an mbox file",
+	Long: `Exports the original MIME content of emails, with all headers and
+attachments, as one .eml file per email or appended to an mbox file.
+
+Emails are selected by ID, or from a folder (--folder, "all" for every
+folder) optionally narrowed by a date range (--since/--until) or a KQL
+query (--query). They are exported oldest first.
+
+.eml files are named from date, subject and a short hash of the message ID,
+e.g. 2024-01-02_1504_quarterly-report_3f9a1c2b.eml.
+
+Exports are resumable: exported message IDs are recorded in a progress file
+(.export-progress in the output directory, or <mbox>.progress), and emails
+listed there are skipped when the command is run again. Delete the progress
+file to export everything again.
+
+--since and --until accept a duration (24h, 7d, 30d) or a date (2024-01-31).
+
+Examples:
+  o365-mail-cli mail export AAMkAGI2... --output-dir ./backup
+  o365-mail-cli mail export --folder "Projects/Alpha" --output-dir ./alpha
+  o365-mail-cli mail export --since 2024-01-01 --until 2024-04-01 --mbox q1.mbox
+  o365-mail-cli mail export --folder all --query "from:boss@company.com hasattachment:true" --mbox boss.mbox`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
+	Args:        cobra.ArbitraryArgs,
+	RunE:        runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFolder, "folder", "inbox", "Folder to export from (\"all\" for every folder)")
+	exportCmd.Flags().StringVar(&exportQuery, "query", "", "Select emails by KQL query (like 'mail query')")
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "Export emails received on or after (e.g., 7d, 2024-01-01)")
+	exportCmd.Flags().StringVar(&exportUntil, "until", "", "Export emails received before (e.g., 24h, 2024-02-01)")
+	exportCmd.Flags().IntVar(&exportLimit, "limit", 0, "Maximum number of emails to export (0 = all)")
+	exportCmd.Flags().StringVarP(&exportOutputDir, "output-dir", "o", "", "Directory for .eml files (default: current directory)")
+	exportCmd.Flags().StringVar(&exportMbox, "mbox", "", "Append to this mbox file instead of writing .eml files")
+
+	mailCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if exportMbox != "" && exportOutputDir != "" {
+		return fmt.Errorf("--output-dir and --mbox cannot be combined")
+	}
+	hasFilter := exportQuery != "" || exportSince != "" || exportUntil != ""
+	if len(args) > 0 && hasFilter {
+		return fmt.Errorf("specify either message IDs or a selection (--query, --since, --until), not both")
+	}
+	if exportQuery != "" && (exportSince != "" || exportUntil != "") {
+		return fmt.Errorf("--query cannot be combined with --since or --until (use KQL, e.g. received>=2024-01-01)")
+	}
+
+	since, err := parseTimeBound(exportSince)
+	if err != nil {
+		return fmt.Errorf("invalid --since value: %w", err)
+	}
+	until, err := parseTimeBound(exportUntil)
+	if err != nil {
+		return fmt.Errorf("invalid --until value: %w", err)
+	}
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := args
+	if len(ids) == 0 {
+		folderID, err := resolveSourceFolder(ctx, client, exportFolder)
+		if err != nil {
+			return err
+		}
+
+		var emails []mail.Email
+		if exportQuery != "" {
+			debugLog("Selecting emails via KQL: %s", exportQuery)
+			emails, err = client.SearchEmailsKQL(ctx, folderID, exportQuery, exportLimit)
+		} else {
+			emails, err = client.ListEmailsReceived(ctx, folderID, since, until, exportLimit)
+		}
+		if err != nil {
+			return err
+		}
+
+		sort.SliceStable(emails, func(i, j int) bool {
+			return emails[i].Date.Before(emails[j].Date)
+		})
+		for _, email := range emails {
+			ids = append(ids, email.MessageID)
+		}
+	}
+
+	if len(ids) == 0 {
+		printInfo("No emails found matching criteria.")
+		return nil
+	}
+
+	target, progressPath := exportOutputDir, filepath.Join(exportOutputDir, ".export-progress")
+	if exportMbox != "" {
+		target, progressPath = exportMbox, exportMbox+".progress"
+	} else if target == "" {
+		target = "."
+	}
+	if exportMbox == "" {
+		if err := os.MkdirAll(target, 0700); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	progress, err := openExportProgress(progressPath)
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+
+	var mboxWriter *mbox.Writer
+	if exportMbox != "" {
+		f, err := os.OpenFile(exportMbox, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open mbox file: %w", err)
+		}
+		defer f.Close()
+		mboxWriter = mbox.NewWriter(f)
+	}
+
+	var exported, skipped, failed int
+	var lastErr error
+	for _, id := range ids {
+		if progress.done[id] {
+			skipped++
+			continue
+		}
+
+		data, err := client.GetMIME(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Printf("Stopped after exporting %d of %d email(s). Run the same command again to resume.\n", exported, len(ids)-skipped)
+				return err
+			}
+			fmt.Printf("  ✗ %s: %v\n", id, err)
+			failed++
+			lastErr = err
+			continue
+		}
+
+		info := parseMIMEInfo(data)
+		if mboxWriter != nil {
+			err = mboxWriter.WriteMessage(info.sender, info.date, data)
+			if err == nil {
+				fmt.Printf("  • [%s] %s\n", info.date.Local().Format("2006-01-02"), truncate(info.subject, 60))
+			}
+		} else {
+			path := filepath.Join(target, emlFileName(info, id))
+			err = writeFileAtomic(path, data, 0600)
+			if err == nil {
+				fmt.Printf("  • %s\n", path)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := progress.markDone(id); err != nil {
+			return err
+		}
+		exported++
+	}
+
+	if exported > 0 || failed == 0 {
+		printSuccess("Exported %d email(s) to %s", exported, target)
+	}
+	if skipped > 0 {
+		printInfo("Skipped %d email(s) already exported (see %s)", skipped, progressPath)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d email(s) failed to export: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// parseTimeBound parses a duration before now (7d, 24h) or a local date (2024-01-31)
+func parseTimeBound(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	duration, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a duration (7d) or a date (2024-01-31): %s", s)
+	}
+	return time.Now().Add(-duration), nil
+}
+
+// mimeInfo holds the headers used to name and describe a message file
+type mimeInfo struct {
+	sender  string
+	subject string
+	date    time.Time
+	// seen is the read state from an mbox Status header (nil = unknown)
+	seen *bool
+}
+
+// parseMIMEInfo reads sender, subject and date from the message headers.
+// Missing or malformed headers leave the fields empty.
+func parseMIMEInfo(data []byte) mimeInfo {
+	var info mimeInfo
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return info
+	}
+
+	info.date, _ = msg.Header.Date()
+	info.subject = msg.Header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(info.subject); err == nil {
+		info.subject = decoded
+	}
+	if from, err := netmail.ParseAddress(msg.Header.Get("From")); err == nil {
+		info.sender = from.Address
+	}
+	if status := msg.Header.Get("Status"); status != "" {
+		seen := strings.Contains(status, "R")
+		info.seen = &seen
+	}
+	return info
+}
+
+// emlFileName derives a stable file name from date, subject and message ID
+func emlFileName(info mimeInfo, messageID string) string {
+	date := "0000-00-00_0000"
+	if !info.date.IsZero() {
+		date = info.date.Local().Format("2006-01-02_1504")
+	}
+	sum := sha256.Sum256([]byte(messageID))
+	return fmt.Sprintf("%s_%s_%s.eml", date, slugify(info.subject, 50), hex.EncodeToString(sum[:4]))
+}
+
+// slugify lowercases s and joins its letters and digits with '-'
+func slugify(s string, maxLen int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if b.Len() >= maxLen {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "no-subject"
+	}
+	return b.String()
+}
+
+// writeFileAtomic writes via a temporary file so readers never see partial content
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// exportProgress records exported message IDs, one per line, so an
+// interrupted export can be resumed
+type exportProgress struct {
+	done map[string]bool
+	file *os.File
+}
+
+func openExportProgress(path string) (*exportProgress, error) {
+	p := &exportProgress{done: map[string]bool{}}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export progress: %w", err)
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			p.done[id] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read export progress: %w", err)
+	}
+
+	p.file = f
+	return p, nil
+}
+
+func (p *exportProgress) markDone(id string) error {
+	if _, err := fmt.Fprintln(p.file, id); err != nil {
+		return fmt.Errorf("failed to record export progress: %w", err)
+	}
+	p.done[id] = true
+	return nil
+}
+
+func (p *exportProgress) Close() error {
+	return p.file.Close()
+}
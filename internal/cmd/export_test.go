@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestMailExport_Eml(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 3)
+	dir := filepath.Join(t.TempDir(), "backup")
+
+	res := runCLI(t, "", "mail", "export", "--output-dir", dir)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Exported 3 email(s)")
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 3 {
+		t.Fatalf("exported files = %v, want 3", files)
+	}
+	if !strings.Contains(filepath.Base(files[0]), "_a-example-com-0_") {
+		t.Errorf("file name %s not derived from the subject", files[0])
+	}
+	data, _ := os.ReadFile(files[0])
+	assertContains(t, string(data), "Subject: a@example.com #0\r\n")
+	if runtime.GOOS != "windows" {
+		for _, path := range []string{files[0], filepath.Join(dir, ".export-progress")} {
+			if fi, err := os.Stat(path); err != nil {
+				t.Error(err)
+			} else if fi.Mode().Perm() != 0600 {
+				t.Errorf("%s: mode %v, want 0600", path, fi.Mode().Perm())
+			}
+		}
+	}
+
+	// Running again resumes: everything is already exported
+	res = runCLI(t, "", "mail", "export", "--output-dir", dir)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Skipped 3 email(s) already exported")
+
+	newID := srv.AddMessage(graphtest.Message{Subject: "Later", From: "b@example.com"})
+	res = runCLI(t, "", "mail", "export", "--output-dir", dir, ids[0], newID)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Exported 1 email(s)")
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(files) != 4 {
+		t.Errorf("exported files = %d, want 4", len(files))
+	}
+}
+
+func TestMailExport_MboxDateRange(t *testing.T) {
+	srv := newTestServer(t)
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.Local) }
+	srv.AddMessage(graphtest.Message{Subject: "Too early", From: "a@example.com", Received: day(1)})
+	srv.AddMessage(graphtest.Message{Subject: "Second", From: "a@example.com", Received: day(3), Body: "From the start"})
+	srv.AddMessage(graphtest.Message{Subject: "First", From: "b@example.com", Received: day(2)})
+	srv.AddMessage(graphtest.Message{Subject: "Too late", From: "a@example.com", Received: day(5)})
+	path := filepath.Join(t.TempDir(), "out.mbox")
+
+	res := runCLI(t, "", "mail", "export", "--since", "2024-01-02", "--until", "2024-01-05", "--mbox", path)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Exported 2 email(s)")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Errorf("mbox mode = %v, want 0600", fi.Mode().Perm())
+	}
+	out := string(data)
+	if !strings.HasPrefix(out, "From b@example.com ") {
+		t.Errorf("mbox should start with the oldest email, got %q", out[:40])
+	}
+	if strings.Count(out, "\nFrom a@example.com ") != 1 || strings.Contains(out, "Too") {
+		t.Errorf("unexpected mbox content:\n%s", out)
+	}
+	assertContains(t, out, "\n>From the start\n")
+}
+
+func TestMailExport_Resume(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 3)
+	path := filepath.Join(t.TempDir(), "out.mbox")
+
+	srv.InjectFault(graphtest.Fault{PathPrefix: "/me/messages/" + ids[1] + "/$value", Status: 404, Times: 1})
+	res := runCLI(t, "", "mail", "export", "--mbox", path)
+	if res.Err == nil {
+		t.Fatal("expected an error for the failed email")
+	}
+	assertContains(t, res.Stdout, "Exported 2 email(s)")
+	assertContains(t, res.Err.Error(), "1 email(s) failed to export")
+
+	res = runCLI(t, "", "mail", "export", "--mbox", path)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Exported 1 email(s)")
+	assertContains(t, res.Stdout, "Skipped 2 email(s)")
+
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "From a@example.com "); n != 3 {
+		t.Errorf("mbox has %d messages, want 3", n)
+	}
+}
+
+func TestMailExport_SelectionErrors(t *testing.T) {
+	newTestServer(t)
+
+	for _, args := range [][]string{
+		{"mail", "export", "id1", "--since", "7d"},
+		{"mail", "export", "--query", "x", "--until", "7d"},
+		{"mail", "export", "--mbox", "a.mbox", "--output-dir", "dir"},
+		{"mail", "export", "--since", "yesterday"},
+	} {
+		if res := runCLI(t, "", args...); res.Err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Re: Quarterly Report (Q1)": "re-quarterly-report-q1",
+		"  ":                        "no-subject",
+		"Grüße aus Köln!":           "grüße-aus-köln",
+	}
+	for in, want := range tests {
+		if got := slugify(in, 50); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
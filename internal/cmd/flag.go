@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Flag Command
+var (
+	flagFolder   string
+	flagDue      string
+	flagComplete bool
+)
+
+var flagCmd = &cobra.Command{
+	Use:   "flag [message-id...]",
+	Short: "Flag email(s) for follow-up",
+	Long: `Sets the follow-up flag of one or more emails, optionally with a due date.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+
+--due accepts a date (2024-05-31), "today", "tomorrow" or a number of days
+from now (3d). --complete marks the follow-up as done.
+
+Examples:
+  o365-mail-cli mail flag AAMkAGI2...
+  o365-mail-cli mail flag AAMkAGI2... --due tomorrow
+  o365-mail-cli mail flag --from boss@example.com --since 7d --due 2024-05-31
+  o365-mail-cli mail flag AAMkAGI2... --complete`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.modify"},
+	RunE:        runFlag,
+}
+
+// Unflag Command
+var unflagFolder string
+
+var unflagCmd = &cobra.Command{
+	Use:   "unflag [message-id...]",
+	Short: "Clear the follow-up flag of email(s)",
+	Long: `Clears the follow-up flag of one or more emails.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+
+Examples:
+  o365-mail-cli mail unflag AAMkAGI2...
+  o365-mail-cli mail unflag --query "subject:invoice" --folder all`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.modify"},
+	RunE:        runUnflag,
+}
+
+// Categorize Command
+var (
+	categorizeFolder string
+	categorizeAdd    []string
+	categorizeRemove []string
+	categorizeClear  bool
+)
+
+var categorizeCmd = &cobra.Command{
+	Use:   "categorize [message-id...]",
+	Short: "Add or remove categories of email(s)",
+	Long: `Adds or removes Outlook categories of one or more emails.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+
+Categories are matched by name. Names that are not in the master category
+list (see 'categories list') are applied without a color.
+
+Examples:
+  o365-mail-cli mail categorize AAMkAGI2... --add "Project Alpha"
+  o365-mail-cli mail categorize AAMkAGI2... --add Finance --remove Todo
+  o365-mail-cli mail categorize --from billing@example.com --add Finance --yes
+  o365-mail-cli mail categorize AAMkAGI2... --clear`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.modify"},
+	RunE:        runCategorize,
+}
+
+var (
+	flagSelection       messageSelection
+	unflagSelection     messageSelection
+	categorizeSelection messageSelection
+)
+
+func init() {
+	flagCmd.Flags().StringVar(&flagFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	flagCmd.Flags().StringVar(&flagDue, "due", "", "Due date (YYYY-MM-DD, today, tomorrow or e.g. 3d)")
+	flagCmd.Flags().BoolVar(&flagComplete, "complete", false, "Mark the follow-up as complete")
+	flagCmd.MarkFlagsMutuallyExclusive("due", "complete")
+	flagSelection.addFlags(flagCmd)
+
+	unflagCmd.Flags().StringVar(&unflagFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	unflagSelection.addFlags(unflagCmd)
+
+	categorizeCmd.Flags().StringVar(&categorizeFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	categorizeCmd.Flags().StringArrayVar(&categorizeAdd, "add", nil, "Category to add (can be specified multiple times)")
+	categorizeCmd.Flags().StringArrayVar(&categorizeRemove, "remove", nil, "Category to remove (can be specified multiple times)")
+	categorizeCmd.Flags().BoolVar(&categorizeClear, "clear", false, "Remove all categories")
+	categorizeCmd.MarkFlagsMutuallyExclusive("clear", "remove")
+	categorizeSelection.addFlags(categorizeCmd)
+
+	mailCmd.AddCommand(flagCmd)
+	mailCmd.AddCommand(unflagCmd)
+	mailCmd.AddCommand(categorizeCmd)
+}
+
+func runFlag(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	followUp := mail.FollowUp{Status: mail.FlagStatusFlagged}
+	action := "flagged"
+	if flagComplete {
+		followUp.Status = mail.FlagStatusComplete
+		action = "marked as complete"
+	}
+	if flagDue != "" {
+		due, err := parseDueDate(flagDue, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --due value: %w", err)
+		}
+		followUp.Due = due
+		action = "flagged, due " + due.Format("2006-01-02")
+	}
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	folderID, err := resolveSourceFolder(ctx, client, flagFolder)
+	if err != nil {
+		return err
+	}
+
+	ids, err := flagSelection.resolve(ctx, client, folderID, args, "Flag %d email(s)?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	results, err := client.FlagEmails(ctx, folderID, ids, followUp)
+	return reportBulkResults(results, err, action)
+}
+
+func runUnflag(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	folderID, err := resolveSourceFolder(ctx, client, unflagFolder)
+	if err != nil {
+		return err
+	}
+
+	ids, err := unflagSelection.resolve(ctx, client, folderID, args, "Clear the flag of %d email(s)?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	results, err := client.FlagEmails(ctx, folderID, ids, mail.FollowUp{Status: mail.FlagStatusNotFlagged})
+	return reportBulkResults(results, err, "unflagged")
+}
+
+func runCategorize(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if len(categorizeAdd) == 0 && len(categorizeRemove) == 0 && !categorizeClear {
+		return fmt.Errorf("--add, --remove or --clear required")
+	}
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	folderID, err := resolveSourceFolder(ctx, client, categorizeFolder)
+	if err != nil {
+		return err
+	}
+
+	ids, err := categorizeSelection.resolve(ctx, client, folderID, args, "Change the categories of %d email(s)?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	results, err := client.UpdateCategories(ctx, folderID, ids, func(current []string) []string {
+		return applyCategories(current, categorizeAdd, categorizeRemove, categorizeClear)
+	})
+	return reportBulkResults(results, err, "categorized")
+}
+
+// applyCategories returns current without the removed categories (all of
+// them with clear) and with the added ones, comparing names case-insensitively
+func applyCategories(current, add, remove []string, clear bool) []string {
+	var result []string
+	if !clear {
+		for _, c := range current {
+			if !containsFoldString(remove, c) {
+				result = append(result, c)
+			}
+		}
+	}
+	for _, c := range add {
+		if !containsFoldString(result, c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func containsFoldString(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDueDate parses a follow-up due date relative to now: YYYY-MM-DD,
+// "today", "tomorrow" or a number of days such as "3d". The result is
+// midnight of that day in the local time zone.
+func parseDueDate(s string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s), now.Location()); err == nil {
+		return t, nil
+	}
+
+	var days int
+	if _, err := fmt.Sscanf(s, "%dd", &days); err == nil && strings.HasSuffix(s, "d") {
+		return today.AddDate(0, 0, days), nil
+	}
+	return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, today, tomorrow or a number of days like 3d: %s", s)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
@@ -56,7 +55,7 @@ func init() {
 }
 
 func runFoldersList(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
@@ -65,7 +64,7 @@ func runFoldersList(cmd *cobra.Command, args []string) error {
 
 	debugLog("Fetching folders via Graph API")
 
-	folders, err := client.ListFolders()
+	folders, err := client.ListFolders(ctx)
 	if err != nil {
 		return err
 	}
@@ -117,7 +116,7 @@ func runFoldersList(cmd *cobra.Command, args []string) error {
 }
 
 func runFoldersCreate(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	folderName := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -127,7 +126,7 @@ func runFoldersCreate(cmd *cobra.Command, args []string) error {
 
 	debugLog("Creating folder via Graph API")
 
-	if err := client.CreateFolder(folderName, ""); err != nil {
+	if err := client.CreateFolder(ctx, folderName, ""); err != nil {
 		return err
 	}
 
@@ -137,7 +136,7 @@ func runFoldersCreate(cmd *cobra.Command, args []string) error {
 }
 
 func runFoldersDelete(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	folderName := args[0]
 
 	// Confirmation
@@ -156,14 +155,14 @@ func runFoldersDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get folder ID
-	folderID, err := client.GetFolderByName(folderName)
+	folderID, err := client.GetFolderByName(ctx, folderName)
 	if err != nil {
 		return err
 	}
 
 	debugLog("Deleting folder via Graph API")
 
-	if err := client.DeleteFolder(folderID); err != nil {
+	if err := client.DeleteFolder(ctx, folderID); err != nil {
 		return err
 	}
 
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/maildir"
+	"github.com/yourname/o365-mail-cli/internal/mbox"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Import Command
+var (
+	importFolder string
+	importUnread bool
+	importDryRun bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|dir>",
+	Short: "Import .eml, mbox or Maildir emails into a folder",
+	Long: `Uploads RFC 822 emails into a mailbox folder, e.g. to migrate old archives.
+
+The source is detected automatically:
+  - a single .eml file
+  - an mbox file (starts with a "From " line)
+  - a Maildir (directory with cur/ and new/)
+  - a directory of .eml files (searched recursively, e.g. from 'mail export')
+
+Emails keep their headers and attachments. The received date is taken from
+the Date header (or the mbox/Maildir metadata). The read state comes from the
+Maildir flags or the mbox Status header; emails without one are imported as
+read unless --unread is given.
+
+Limitation: Exchange creates uploaded emails as drafts and does not allow
+clearing that flag afterwards, so Outlook may show imported emails as
+unsent drafts (e.g. with a "This message hasn't been sent" banner).
+
+Examples:
+  o365-mail-cli mail import message.eml
+  o365-mail-cli mail import archive.mbox --folder "Archive/2019"
+  o365-mail-cli mail import ~/Maildir/.Projects --folder Projects
+  o365-mail-cli mail import ./backup --dry-run`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.import"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runImport,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFolder, "folder", "inbox", "Folder to import into")
+	importCmd.Flags().BoolVar(&importUnread, "unread", false, "Import all emails as unread")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "List the emails without importing them")
+
+	mailCmd.AddCommand(importCmd)
+}
+
+// importMessage is one message found in an import source
+type importMessage struct {
+	// Source names the message in output, e.g. "archive.mbox #3"
+	Source  string
+	Content []byte
+	// Date is used when the message has no Date header
+	Date time.Time
+	// Seen is the read state recorded by the source (nil = unknown)
+	Seen *bool
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	source := args[0]
+
+	var client *mail.GraphClient
+	var folderID string
+	if !importDryRun {
+		var err error
+		client, err = getGraphClient(ctx)
+		if err != nil {
+			return err
+		}
+		folderID, err = client.GetFolderByName(ctx, importFolder)
+		if err != nil {
+			return err
+		}
+	}
+
+	var imported, failed int
+	var lastErr error
+	err := readImportSource(source, func(msg importMessage) error {
+		info := parseMIMEInfo(msg.Content)
+		received := info.date
+		if received.IsZero() {
+			received = msg.Date
+		}
+		read := true
+		if msg.Seen != nil {
+			read = *msg.Seen
+		} else if info.seen != nil {
+			read = *info.seen
+		}
+		if importUnread {
+			read = false
+		}
+
+		line := fmt.Sprintf("[%s] %s - %s", formatImportDate(received), truncate(info.sender, 30), truncate(info.subject, 40))
+		if importDryRun {
+			fmt.Printf("  • %s\n", line)
+			imported++
+			return nil
+		}
+
+		_, err := client.ImportMIME(ctx, folderID, crlf(msg.Content), mail.ImportOptions{Received: received, Read: read})
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			fmt.Printf("  ✗ %s: %v\n", msg.Source, err)
+			failed++
+			lastErr = err
+			return nil
+		}
+
+		fmt.Printf("  • %s\n", line)
+		imported++
+		return nil
+	})
+	if err != nil {
+		if imported > 0 {
+			fmt.Printf("Stopped after importing %d email(s)\n", imported)
+		}
+		return err
+	}
+
+	switch {
+	case importDryRun:
+		fmt.Printf("\nDry run - %d email(s) would be imported into '%s'.\n", imported, importFolder)
+	case imported == 0 && failed == 0:
+		printInfo("No emails found in %s", source)
+	case imported > 0:
+		printSuccess("Imported %d email(s) into '%s'", imported, importFolder)
+		printInfo("Note: Exchange keeps imported emails as drafts, so Outlook shows them as unsent.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d email(s) failed to import: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// readImportSource calls fn for every message in an .eml file, mbox file,
+// Maildir or directory of .eml files
+func readImportSource(path string, fn func(importMessage) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import source: %w", err)
+	}
+
+	if info.IsDir() && maildir.IsMaildir(path) {
+		messages, err := maildir.List(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			content, err := os.ReadFile(m.Path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", m.Path, err)
+			}
+			seen := m.Seen()
+			msg := importMessage{Source: m.Path, Content: content, Seen: &seen}
+			if fi, err := os.Stat(m.Path); err == nil {
+				msg.Date = fi.ModTime()
+			}
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if info.IsDir() {
+		return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".eml") {
+				return nil
+			}
+			return readEmlFile(p, fn)
+		})
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import source: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if head, _ := r.Peek(5); string(head) != "From " {
+		return readEmlFile(path, fn)
+	}
+
+	reader := mbox.NewReader(r)
+	for n := 1; ; n++ {
+		m, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := fn(importMessage{Source: fmt.Sprintf("%s #%d", path, n), Content: m.Content, Date: m.Date}); err != nil {
+			return err
+		}
+	}
+}
+
+func readEmlFile(path string, fn func(importMessage) error) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return fn(importMessage{Source: path, Content: content})
+}
+
+// crlf normalizes line endings to CRLF as RFC 5322 requires
+func crlf(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+}
+
+func formatImportDate(t time.Time) string {
+	if t.IsZero() {
+		return "no date"
+	}
+	return t.Local().Format("2006-01-02")
+}
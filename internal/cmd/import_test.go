@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+const importMbox = `From alice@example.com Mon Jan  1 09:00:00 2018
+From: Alice <alice@example.com>
+To: me@example.com
+Subject: Old news
+Date: Mon, 1 Jan 2018 09:00:00 +0000
+Status: RO
+
+>From the archive
+From bob@example.com Tue Jan  2 10:00:00 2018
+From: bob@example.com
+Subject: Still unread
+Status: O
+
+Body
+`
+
+const importMultipart = "From: carol@example.com\r\n" +
+	"Subject: Report\r\n" +
+	"Date: Wed, 3 Jan 2018 11:00:00 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=XYZ\r\n" +
+	"\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"See attached.\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: application/pdf; name=report.pdf\r\n" +
+	"Content-Disposition: attachment; filename=report.pdf\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--XYZ--\r\n"
+
+func TestMailImport_Mbox(t *testing.T) {
+	srv := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "old.mbox")
+	os.WriteFile(path, []byte(importMbox), 0600)
+
+	res := runCLI(t, "", "mail", "import", path, "--folder", "Archive")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Imported 2 email(s) into 'Archive'")
+	assertContains(t, res.Stdout, "Outlook shows them as unsent")
+
+	msgs := map[string]graphtest.Message{}
+	for _, m := range srv.Messages("archive") {
+		msgs[m.Subject] = m
+	}
+	old, unread := msgs["Old news"], msgs["Still unread"]
+	if !old.Received.Equal(time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC)) || !old.IsRead {
+		t.Errorf("Old news: received %v read %t, want 2018-01-01 09:00 and read", old.Received, old.IsRead)
+	}
+	if old.Body != "From the archive\r\n" || old.From != "alice@example.com" {
+		t.Errorf("Old news not imported from MIME: %+v", old)
+	}
+	// No Date header: the mbox separator date is used
+	if !unread.Received.Equal(time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)) || unread.IsRead {
+		t.Errorf("Still unread: received %v read %t, want 2018-01-02 10:00 and unread", unread.Received, unread.IsRead)
+	}
+	// Exchange keeps MIME uploads flagged as drafts; the help text says so
+	if !old.IsDraft || !unread.IsDraft {
+		t.Error("imported emails are expected to keep the draft flag")
+	}
+}
+
+func TestMailImport_Maildir(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		os.MkdirAll(filepath.Join(dir, sub), 0700)
+	}
+	os.WriteFile(filepath.Join(dir, "cur", "1.host:2,S"), []byte(importMultipart), 0600)
+	os.WriteFile(filepath.Join(dir, "new", "2.host"), []byte("From: dave@example.com\nSubject: Fresh\n\nHi\n"), 0600)
+
+	mustSucceed(t, runCLI(t, "", "mail", "import", dir))
+
+	msgs := map[string]graphtest.Message{}
+	for _, m := range srv.Messages("inbox") {
+		msgs[m.Subject] = m
+	}
+	report := msgs["Report"]
+	if !report.IsRead || len(report.Attachment) != 1 || report.Attachment[0].Name != "report.pdf" || string(report.Attachment[0].Content) != "%PDF-" {
+		t.Errorf("Report = %+v, want read with report.pdf attached", report)
+	}
+	if fresh, ok := msgs["Fresh"]; !ok || fresh.IsRead {
+		t.Errorf("Fresh = %+v, want imported as unread", fresh)
+	}
+}
+
+func TestMailImport_ExportRoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	seedMessages(srv, "a@example.com", 2)
+	folderID := srv.AddFolder("", "Restored")
+	dir := t.TempDir()
+
+	mustSucceed(t, runCLI(t, "", "mail", "export", "--output-dir", dir))
+
+	res := runCLI(t, "", "mail", "import", dir, "--folder", "Restored", "--unread")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Imported 2 email(s)")
+
+	restored := srv.Messages(folderID)
+	if len(restored) != 2 || restored[0].IsRead || restored[0].Subject != "a@example.com #1" {
+		t.Errorf("restored = %+v", restored)
+	}
+}
+
+func TestMailImport_DryRun(t *testing.T) {
+	srv := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "message.eml")
+	os.WriteFile(path, []byte(importMultipart), 0600)
+
+	res := runCLI(t, "", "mail", "import", path, "--dry-run")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "[2018-01-03] carol@example.com - Report")
+	assertContains(t, res.Stdout, "1 email(s) would be imported into 'inbox'")
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("dry run sent %d request(s)", n)
+	}
+}
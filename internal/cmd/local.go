@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/syncstate"
+)
+
+// openLocalStore opens the local store of the active account. It never contacts Graph.
+func openLocalStore() *syncstate.Store {
+	return syncstate.OpenStore(cfg.CacheDir, getActiveAccount())
+}
+
+func runMailListLocal() error {
+	messages, err := openLocalStore().Search(syncstate.SearchOptions{
+		Folder:     listFolder,
+		UnreadOnly: listUnreadOnly,
+		Flagged:    listFlagged,
+		Category:   listCategory,
+		Importance: listImportance,
+		Limit:      listLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	emails := storedToEmails(messages)
+	var threads []mail.Thread
+	if listThreads {
+		threads = mail.GroupThreads(emails)
+	}
+	if listJSON {
+		if listThreads {
+			return outputJSON(threads)
+		}
+		return outputJSON(emails)
+	}
+
+	if len(emails) == 0 {
+		printInfo("No emails in the local store for '%s'. Run 'mail sync --folder \"%s\"' first.", listFolder, listFolder)
+		return nil
+	}
+
+	if listThreads {
+		printThreadTable(threads)
+		fmt.Printf("\n%d threads shown (%d emails, local store)\n", len(threads), len(emails))
+		return nil
+	}
+
+	printEmailTable(emails)
+	fmt.Printf("\n%d emails shown (local store)\n", len(emails))
+
+	return nil
+}
+
+func runReadLocal(messageID string) error {
+	msg, err := openLocalStore().Get(messageID)
+	if errors.Is(err, syncstate.ErrNotStored) {
+		return withExitCode(ExitNotFound, "NotFound", fmt.Errorf("email %s is not in the local store (run 'mail sync' first)", messageID))
+	}
+	if err != nil {
+		return err
+	}
+
+	if readHeaders {
+		return showEmail(&msg.Email, &msg.MessageDetail)
+	}
+	if err := showEmail(&msg.Email, nil); err != nil {
+		return err
+	}
+	if len(msg.Attachments) > 0 && !readJSON {
+		fmt.Println()
+		fmt.Println("Attachments:")
+		for _, att := range msg.Attachments {
+			fmt.Printf("  • %s (%s, %d bytes)\n", att.Name, att.ContentType, att.Size)
+		}
+	}
+
+	return nil
+}
+
+func runSearchLocal() error {
+	if searchText == "" && searchFrom == "" && searchSubject == "" && searchSince == "" {
+		return fmt.Errorf("at least one search criterion required (--text, --from, --subject, or --since)")
+	}
+
+	opts := syncstate.SearchOptions{
+		Text:    searchText,
+		From:    searchFrom,
+		Subject: searchSubject,
+		Folder:  searchFolder,
+		Limit:   searchLimit,
+	}
+	if searchSince != "" {
+		duration, err := parseDuration(searchSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since value: %w", err)
+		}
+		opts.Since = time.Now().Add(-duration)
+	}
+
+	debugLog("Searching local store: %+v", opts)
+
+	messages, err := openLocalStore().Search(opts)
+	if err != nil {
+		return err
+	}
+
+	emails := storedToEmails(messages)
+	if searchJSON {
+		return outputJSON(emails)
+	}
+
+	if len(emails) == 0 {
+		printInfo("No emails found in the local store matching criteria.")
+		return nil
+	}
+
+	printEmailTable(emails)
+	fmt.Printf("\n%d emails found (local store)\n", len(emails))
+
+	return nil
+}
+
+// storedToEmails converts stored messages to the list/search output format
+func storedToEmails(messages []*syncstate.StoredMessage) []mail.Email {
+	emails := make([]mail.Email, len(messages))
+	for i, msg := range messages {
+		emails[i] = msg.Email
+		emails[i].Body = ""
+		if emails[i].Preview == "" {
+			emails[i].Preview = truncate(strings.Join(strings.Fields(msg.Body), " "), 255)
+		}
+	}
+	return emails
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yourname/o365-mail-cli/internal/auth"
+	"github.com/yourname/o365-mail-cli/internal/compose"
 	"github.com/yourname/o365-mail-cli/internal/mail"
 	"github.com/yourname/o365-mail-cli/internal/profile"
+	"github.com/yourname/o365-mail-cli/internal/templates"
 )
 
 var mailCmd = &cobra.Command{
@@ -26,6 +31,11 @@ var (
 	listLimit      int
 	listUnreadOnly bool
 	listJSON       bool
+	listLocal      bool
+	listThreads    bool
+	listFlagged    bool
+	listCategory   string
+	listImportance string
 )
 
 var mailListCmd = &cobra.Command{
@@ -33,17 +43,37 @@ var mailListCmd = &cobra.Command{
 	Short: "List emails",
 	Long: `Lists emails from a folder.
 
+--threads groups the listed emails by conversation, showing the latest
+email of each thread and the number of emails in brackets. Use
+'mail thread' with the ID to see the whole conversation.
+
 Examples:
   o365-mail-cli mail list
   o365-mail-cli mail list --folder "Sent Items" --limit 20
   o365-mail-cli mail list --unread
-  o365-mail-cli mail list --json`,
+  o365-mail-cli mail list --flagged
+  o365-mail-cli mail list --category "Project Alpha"
+  o365-mail-cli mail list --importance high
+  o365-mail-cli mail list --json
+  o365-mail-cli mail list --threads
+  o365-mail-cli mail list --local`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
 	RunE:        runMailList,
 }
 
 // Read Command
-var readFolder string
+var (
+	readFolder     string
+	readLocal      bool
+	readRaw        bool
+	readHTML       bool
+	readText       bool
+	readBrowser    bool
+	readShowQuoted bool
+	readWidth      int
+	readHeaders    bool
+	readJSON       bool
+)
 
 var readCmd = &cobra.Command{
 	Use:   "read [message-id]",
@@ -52,9 +82,23 @@ var readCmd = &cobra.Command{
 
 Find the message ID in the output of 'mail list --json'.
 
+HTML bodies are rendered as wrapped text: links become numbered footnotes,
+tables are aligned where they fit and quoted replies are collapsed. Use
+--raw for the body exactly as received, --html for the HTML source or
+--browser to open it in the default browser (inline images are not shown).
+
+--headers adds importance, categories, flag, conversation ID, attachments,
+the SPF/DKIM/DMARC results recorded by the receiving server and all
+Internet message headers.
+
 Examples:
   o365-mail-cli mail read AAMkAGI2...
-  o365-mail-cli mail read AAMkAGI2... --folder "Sent Items"`,
+  o365-mail-cli mail read AAMkAGI2... --folder "Sent Items"
+  o365-mail-cli mail read AAMkAGI2... --local
+  o365-mail-cli mail read AAMkAGI2... --show-quoted --width 100
+  o365-mail-cli mail read AAMkAGI2... --browser
+  o365-mail-cli mail read AAMkAGI2... --headers
+  o365-mail-cli mail read AAMkAGI2... --headers --json`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
 	Args:        cobra.ExactArgs(1),
 	RunE:        runRead,
@@ -69,6 +113,11 @@ var (
 	sendBody     string
 	sendBodyFile string
 	sendHTML     bool
+	sendAttach   []string
+	sendTextAlt  bool
+	sendMarkdown bool
+	sendEdit     bool
+	sendAt       string
 )
 
 var sendCmd = &cobra.Command{
@@ -79,7 +128,37 @@ var sendCmd = &cobra.Command{
 Examples:
   o365-mail-cli mail send --to user@example.com --subject "Test" --body "Hello!"
   o365-mail-cli mail send --to user@example.com --subject "Report" --body-file report.txt
-  o365-mail-cli mail send --to user@example.com --cc boss@example.com --subject "Info" --body "Text"`,
+  o365-mail-cli mail send --to user@example.com --cc boss@example.com --subject "Info" --body "Text"
+  o365-mail-cli mail send --to user@example.com --subject "Docs" --body "Attached" --attach a.pdf --attach b.zip
+  o365-mail-cli mail send --to user@example.com --subject "News" --body-file news.html --html --text-alternative
+  o365-mail-cli mail send --to user@example.com --subject "Notes" --body-file notes.md --markdown
+  o365-mail-cli mail send --to user@example.com --subject "Outage" --body "The VPN is down" --importance high
+  o365-mail-cli mail send --to hr@example.com --subject "Review" --body-file r.txt --sensitivity confidential --read-receipt
+  o365-mail-cli mail send --to list@example.com --subject "Survey" --body "Please reply" --reply-to survey@example.com
+  o365-mail-cli mail send --to team@example.com --subject "Release" --body-file release.md --markdown --send-at "tomorrow 08:00"
+  o365-mail-cli mail send --template escalate --var ticket=4711 --var customer="ACME Corp"
+  o365-mail-cli mail send --edit
+  o365-mail-cli mail send --to user@example.com --subject "Plan" --edit
+
+With --html or --markdown, images referenced as local files are attached
+inline and the references rewritten to cid: URLs. Markdown is sent as
+styled HTML together with the Markdown source as plain-text alternative.
+
+--edit opens $VISUAL or $EDITOR with To/Cc/Bcc/Subject/Attach headers
+prefilled from the flags. After saving, choose to send, save as draft,
+edit again or abort.
+
+--importance, --sensitivity, --read-receipt, --delivery-receipt and
+--reply-to set the corresponding message properties. Recipients'
+mail clients decide whether to honor receipt requests.
+
+--send-at defers delivery: Exchange keeps the email in the Outbox until
+the given time, see 'drafts scheduled'. It accepts "2024-05-31 08:00",
+"08:00" (the next time it is 08:00), "tomorrow 08:00" or a delay like 2h.
+
+--template composes the email from a stored template, see 'templates'.
+Flags given explicitly override the template's subject, body, format and
+recipients.`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.send"},
 	RunE:        runSend,
 }
@@ -88,15 +167,19 @@ Examples:
 var markReadFolder string
 
 var markReadCmd = &cobra.Command{
-	Use:   "mark-read [message-id]",
-	Short: "Mark email as read",
-	Long: `Marks an email as read.
+	Use:   "mark-read [message-id...]",
+	Short: "Mark email(s) as read",
+	Long: `Marks one or more emails as read.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+Multiple emails are updated in batches of 20 per request.
 
 Examples:
   o365-mail-cli mail mark-read AAMkAGI2...
-  o365-mail-cli mail mark-read AAMkAGI2... --folder "Archive"`,
+  o365-mail-cli mail mark-read AAMkAGI2... AAMkAGI3... AAMkAGI4...
+  o365-mail-cli mail mark-read AAMkAGI2... --folder "Archive"
+  o365-mail-cli mail mark-read --from newsletter@example.com --since 30d
+  o365-mail-cli mail mark-read --query "subject:digest" --folder all --yes`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.modify"},
-	Args:        cobra.ExactArgs(1),
 	RunE:        runMarkRead,
 }
 
@@ -104,15 +187,18 @@ Examples:
 var markUnreadFolder string
 
 var markUnreadCmd = &cobra.Command{
-	Use:   "mark-unread [message-id]",
-	Short: "Mark email as unread",
-	Long: `Marks an email as unread.
+	Use:   "mark-unread [message-id...]",
+	Short: "Mark email(s) as unread",
+	Long: `Marks one or more emails as unread.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+Multiple emails are updated in batches of 20 per request.
 
 Examples:
   o365-mail-cli mail mark-unread AAMkAGI2...
-  o365-mail-cli mail mark-unread AAMkAGI2... --folder "Archive"`,
+  o365-mail-cli mail mark-unread AAMkAGI2... AAMkAGI3...
+  o365-mail-cli mail mark-unread AAMkAGI2... --folder "Archive"
+  o365-mail-cli mail mark-unread --subject "invoice" --since 7d`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.modify"},
-	Args:        cobra.ExactArgs(1),
 	RunE:        runMarkUnread,
 }
 
@@ -123,15 +209,19 @@ var (
 )
 
 var moveCmd = &cobra.Command{
-	Use:   "move [message-id]",
-	Short: "Move email to folder",
-	Long: `Moves an email to another folder.
+	Use:   "move [message-id...]",
+	Short: "Move email(s) to folder",
+	Long: `Moves one or more emails to another folder.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+Multiple emails are moved in batches of 20 per request.
 
 Examples:
   o365-mail-cli mail move AAMkAGI2... --to "Archive"
-  o365-mail-cli mail move AAMkAGI2... --folder "Sent Items" --to "Archive"`,
+  o365-mail-cli mail move AAMkAGI2... AAMkAGI3... --to "Projects/Alpha"
+  o365-mail-cli mail move AAMkAGI2... --folder "Sent Items" --to "Archive"
+  o365-mail-cli mail move --from newsletter@example.com --to "Newsletters" --dry-run
+  o365-mail-cli mail move --query "from:jira subject:resolved" --to "Archive" --limit 300`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.move"},
-	Args:        cobra.ExactArgs(1),
 	RunE:        runMove,
 }
 
@@ -139,16 +229,19 @@ Examples:
 var trashFolder string
 
 var trashCmd = &cobra.Command{
-	Use:   "trash [message-id]",
-	Short: "Move email to Trash",
-	Long: `Moves an email to the Deleted Items folder.
-This is a safe delete - the email can be recovered from Trash.
+	Use:   "trash [message-id...]",
+	Short: "Move email(s) to Trash",
+	Long: `Moves one or more emails to the Deleted Items folder.
+This is a safe delete - the emails can be recovered from Trash.
+Emails are given by ID or selected with --query (KQL) or --from/--subject/--since.
+Multiple emails are moved in batches of 20 per request.
 
 Examples:
   o365-mail-cli mail trash AAMkAGI2...
-  o365-mail-cli mail trash AAMkAGI2... --folder "Spam"`,
+  o365-mail-cli mail trash AAMkAGI2... AAMkAGI3...
+  o365-mail-cli mail trash AAMkAGI2... --folder "Spam"
+  o365-mail-cli mail trash --from promo@shop.example --since 90d --yes`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.delete"},
-	Args:        cobra.ExactArgs(1),
 	RunE:        runTrash,
 }
 
@@ -160,6 +253,8 @@ var (
 	searchSince   string
 	searchLimit   int
 	searchJSON    bool
+	searchLocal   bool
+	searchText    string
 )
 
 var searchCmd = &cobra.Command{
@@ -167,11 +262,16 @@ var searchCmd = &cobra.Command{
 	Short: "Search emails",
 	Long: `Searches emails by various criteria.
 
+With --local the search runs offline against the emails saved by 'mail sync'.
+--text then searches subject, sender, recipients, body and attachment names
+for all given words.
+
 Examples:
   o365-mail-cli mail search --from "sender@example.com"
   o365-mail-cli mail search --subject "important"
   o365-mail-cli mail search --since 24h
-  o365-mail-cli mail search --from "boss@company.com" --since 7d --json`,
+  o365-mail-cli mail search --from "boss@company.com" --since 7d --json
+  o365-mail-cli mail search --local --text "budget 2024" --folder all`,
 	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
 	RunE:        runSearch,
 }
@@ -231,6 +331,15 @@ var (
 	replyBody     string
 	replyBodyFile string
 	replyAll      bool
+	replyMarkdown bool
+	replyHTML     bool
+	replyEdit     bool
+	replyDraft    bool
+	replyTo       []string
+	replyCc       []string
+	replyBcc      []string
+	replyRemove   []string
+	replyAttach   []string
 )
 
 var replyCmd = &cobra.Command{
@@ -241,8 +350,29 @@ var replyCmd = &cobra.Command{
 Examples:
   o365-mail-cli mail reply AAMkAGI2... --body "Thank you for your email!"
   o365-mail-cli mail reply AAMkAGI2... --body-file response.txt
-  o365-mail-cli mail reply AAMkAGI2... --body "Thanks!" --reply-all`,
-	Annotations: map[string]string{profile.AnnotationKey: "mail.send"},
+  o365-mail-cli mail reply AAMkAGI2... --body "Thanks!" --reply-all
+  o365-mail-cli mail reply AAMkAGI2... --body "**Approved**, see [the plan](https://example.com)" --markdown
+  o365-mail-cli mail reply AAMkAGI2... --body-file answer.html --html --attach offer.pdf
+  o365-mail-cli mail reply AAMkAGI2... --body "Adding Carol" --reply-all --cc carol@example.com --remove-recipient bob@example.com
+  o365-mail-cli mail reply AAMkAGI2... --template thanks --var days=2
+  o365-mail-cli mail reply AAMkAGI2... --edit
+  o365-mail-cli mail reply AAMkAGI2... --body "Draft answer" --draft
+
+The original is quoted below the body. --to, --cc and --bcc add
+recipients; --remove-recipient drops an address the reply would go to.
+
+--template writes the reply from a stored template, which can use the
+sender name, subject and date of the original, see 'templates'. Its
+recipients are added to the reply. The template's subject is ignored; the
+reply keeps the "RE:" subject Exchange derives from the original.
+
+--edit shows the quoted original for reference; Microsoft Graph adds the
+original to the reply itself.
+
+--draft saves the reply in the Drafts folder instead of sending it. Review
+it with 'drafts edit' and send it with 'drafts send'. With --draft the
+command needs the drafts.create permission instead of mail.send.`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.send", profile.DraftAnnotationKey: "drafts.create"},
 	Args:        cobra.ExactArgs(1),
 	RunE:        runReply,
 }
@@ -253,6 +383,13 @@ var (
 	forwardTo       []string
 	forwardBody     string
 	forwardBodyFile string
+	forwardCc       []string
+	forwardBcc      []string
+	forwardAttach   []string
+	forwardMarkdown bool
+	forwardHTML     bool
+	forwardDraft    bool
+	forwardAsAttach bool
 )
 
 var forwardCmd = &cobra.Command{
@@ -262,8 +399,19 @@ var forwardCmd = &cobra.Command{
 
 Examples:
   o365-mail-cli mail forward AAMkAGI2... --to colleague@example.com
-  o365-mail-cli mail forward AAMkAGI2... --to colleague@example.com --body "FYI - please review"`,
-	Annotations: map[string]string{profile.AnnotationKey: "mail.send"},
+  o365-mail-cli mail forward AAMkAGI2... --to colleague@example.com --body "FYI - please review"
+  o365-mail-cli mail forward AAMkAGI2... --to colleague@example.com --body-file notes.md --markdown
+  o365-mail-cli mail forward AAMkAGI2... --to colleague@example.com --cc boss@example.com --attach notes.pdf
+  o365-mail-cli mail forward AAMkAGI2... --to abuse@example.com --as-attachment
+  o365-mail-cli mail forward AAMkAGI2... --draft
+
+--as-attachment attaches the original as an .eml file, with all its
+headers, instead of quoting it in the body.
+
+--draft saves the forward in the Drafts folder instead of sending it;
+--to is optional then and can be set later with 'drafts edit'. With --draft
+the command needs the drafts.create permission instead of mail.send.`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.send", profile.DraftAnnotationKey: "drafts.create"},
 	Args:        cobra.ExactArgs(1),
 	RunE:        runForward,
 }
@@ -295,9 +443,25 @@ func init() {
 	mailListCmd.Flags().IntVar(&listLimit, "limit", 10, "Maximum number of emails")
 	mailListCmd.Flags().BoolVar(&listUnreadOnly, "unread", false, "Only unread emails")
 	mailListCmd.Flags().BoolVar(&listJSON, "json", false, "Output as JSON")
+	mailListCmd.Flags().BoolVar(&listLocal, "local", false, "List emails from the local store (see 'mail sync')")
+	mailListCmd.Flags().BoolVar(&listThreads, "threads", false, "Group emails by conversation")
+	mailListCmd.Flags().BoolVar(&listFlagged, "flagged", false, "Only flagged emails")
+	mailListCmd.Flags().StringVar(&listCategory, "category", "", "Only emails with this category")
+	mailListCmd.Flags().StringVar(&listImportance, "importance", "", "Only emails with this importance (low/normal/high)")
 
 	// Read flags
 	readCmd.Flags().StringVar(&readFolder, "folder", "inbox", "Folder of the email")
+	readCmd.Flags().BoolVar(&readLocal, "local", false, "Read the email from the local store (see 'mail sync')")
+	readCmd.Flags().BoolVar(&readText, "text", false, "Render the body as text (default)")
+	readCmd.Flags().BoolVar(&readRaw, "raw", false, "Print the body as received")
+	readCmd.Flags().BoolVar(&readHTML, "html", false, "Print the body as HTML")
+	readCmd.Flags().BoolVar(&readBrowser, "browser", false, "Open the body in the default browser")
+	readCmd.Flags().BoolVar(&readShowQuoted, "show-quoted", false, "Don't collapse quoted replies")
+	readCmd.Flags().IntVar(&readWidth, "width", 0, "Wrap text at this width (default: $COLUMNS or 80)")
+	readCmd.Flags().BoolVar(&readHeaders, "headers", false, "Show all headers and message metadata")
+	readCmd.Flags().BoolVar(&readJSON, "json", false, "Output as JSON")
+	readCmd.MarkFlagsMutuallyExclusive("text", "raw", "html", "browser")
+	readCmd.MarkFlagsMutuallyExclusive("json", "browser")
 
 	// Send flags
 	sendCmd.Flags().StringArrayVar(&sendTo, "to", nil, "Recipients (can be specified multiple times)")
@@ -307,23 +471,32 @@ func init() {
 	sendCmd.Flags().StringVar(&sendBody, "body", "", "Message body")
 	sendCmd.Flags().StringVar(&sendBodyFile, "body-file", "", "Read message body from file")
 	sendCmd.Flags().BoolVar(&sendHTML, "html", false, "Send body as HTML")
-
-	sendCmd.MarkFlagRequired("to")
-	sendCmd.MarkFlagRequired("subject")
+	sendCmd.Flags().StringArrayVar(&sendAttach, "attach", nil, "Attach a file (can be specified multiple times)")
+	sendCmd.Flags().BoolVar(&sendTextAlt, "text-alternative", false, "Add a plain-text alternative generated from the HTML body")
+	sendCmd.Flags().BoolVar(&sendMarkdown, "markdown", false, "Body is Markdown, sent as HTML")
+	sendCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	sendCmd.Flags().BoolVar(&sendEdit, "edit", false, "Compose the message in $EDITOR")
+	sendProperties.addFlags(sendCmd)
+	sendTemplate.addFlags(sendCmd)
+	sendCmd.Flags().StringVar(&sendAt, "send-at", "", "Deliver at this time instead of now")
 
 	// Mark-read flags
-	markReadCmd.Flags().StringVar(&markReadFolder, "folder", "inbox", "Folder of the email")
+	markReadCmd.Flags().StringVar(&markReadFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	markReadSelection.addFlags(markReadCmd)
 
 	// Mark-unread flags
-	markUnreadCmd.Flags().StringVar(&markUnreadFolder, "folder", "inbox", "Folder of the email")
+	markUnreadCmd.Flags().StringVar(&markUnreadFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	markUnreadSelection.addFlags(markUnreadCmd)
 
 	// Move flags
-	moveCmd.Flags().StringVar(&moveFromFolder, "folder", "inbox", "Source folder")
+	moveCmd.Flags().StringVar(&moveFromFolder, "folder", "inbox", "Source folder (use \"all\" with search filters)")
 	moveCmd.Flags().StringVar(&moveToFolder, "to", "", "Destination folder")
 	moveCmd.MarkFlagRequired("to")
+	moveSelection.addFlags(moveCmd)
 
 	// Trash flags
-	trashCmd.Flags().StringVar(&trashFolder, "folder", "inbox", "Folder of the email")
+	trashCmd.Flags().StringVar(&trashFolder, "folder", "inbox", "Folder of the email (use \"all\" with search filters)")
+	trashSelection.addFlags(trashCmd)
 
 	// Search flags
 	searchCmd.Flags().StringVar(&searchFolder, "folder", "inbox", "Folder to search")
@@ -332,6 +505,8 @@ func init() {
 	searchCmd.Flags().StringVar(&searchSince, "since", "", "Filter emails since (e.g., 24h, 7d, 30d)")
 	searchCmd.Flags().IntVar(&searchLimit, "limit", 50, "Maximum results")
 	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output as JSON")
+	searchCmd.Flags().BoolVar(&searchLocal, "local", false, "Search the local store offline (see 'mail sync')")
+	searchCmd.Flags().StringVar(&searchText, "text", "", "Full-text search words (requires --local)")
 
 	// Attachments flags
 	attachmentsCmd.Flags().StringVar(&attachFolder, "folder", "inbox", "Folder of the email")
@@ -343,13 +518,32 @@ func init() {
 	replyCmd.Flags().StringVar(&replyBody, "body", "", "Reply message body")
 	replyCmd.Flags().StringVar(&replyBodyFile, "body-file", "", "Read reply body from file")
 	replyCmd.Flags().BoolVar(&replyAll, "reply-all", false, "Reply to all recipients")
+	replyCmd.Flags().BoolVar(&replyHTML, "html", false, "Body is HTML")
+	replyCmd.Flags().BoolVar(&replyMarkdown, "markdown", false, "Body is Markdown, sent as HTML")
+	replyCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	replyCmd.Flags().StringArrayVar(&replyTo, "to", nil, "Additional recipient (can be specified multiple times)")
+	replyCmd.Flags().StringArrayVar(&replyCc, "cc", nil, "Additional CC recipient (can be specified multiple times)")
+	replyCmd.Flags().StringArrayVar(&replyBcc, "bcc", nil, "BCC recipient (can be specified multiple times)")
+	replyCmd.Flags().StringArrayVar(&replyRemove, "remove-recipient", nil, "Address to remove from the recipients (can be specified multiple times)")
+	replyCmd.Flags().StringArrayVar(&replyAttach, "attach", nil, "Attach a file (can be specified multiple times)")
+	replyCmd.Flags().BoolVar(&replyEdit, "edit", false, "Write the reply in $EDITOR with the original quoted for reference")
+	replyCmd.Flags().BoolVar(&replyDraft, "draft", false, "Save the reply as draft instead of sending it")
+	replyTemplate.addFlags(replyCmd)
 
 	// Forward flags
 	forwardCmd.Flags().StringVar(&forwardFolder, "folder", "inbox", "Folder of the email")
 	forwardCmd.Flags().StringArrayVar(&forwardTo, "to", nil, "Recipients (can be specified multiple times)")
 	forwardCmd.Flags().StringVar(&forwardBody, "body", "", "Additional message body")
 	forwardCmd.Flags().StringVar(&forwardBodyFile, "body-file", "", "Read additional body from file")
-	forwardCmd.MarkFlagRequired("to")
+	forwardCmd.Flags().StringArrayVar(&forwardCc, "cc", nil, "CC recipients (can be specified multiple times)")
+	forwardCmd.Flags().StringArrayVar(&forwardBcc, "bcc", nil, "BCC recipients (can be specified multiple times)")
+	forwardCmd.Flags().StringArrayVar(&forwardAttach, "attach", nil, "Attach a file (can be specified multiple times)")
+	forwardCmd.Flags().BoolVar(&forwardHTML, "html", false, "Body is HTML")
+	forwardCmd.Flags().BoolVar(&forwardMarkdown, "markdown", false, "Body is Markdown, sent as HTML")
+	forwardCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	forwardCmd.Flags().BoolVar(&forwardDraft, "draft", false, "Save the forward as draft instead of sending it")
+	forwardCmd.Flags().BoolVar(&forwardAsAttach, "as-attachment", false, "Attach the original as .eml file instead of quoting it")
+	forwardCmd.MarkFlagsMutuallyExclusive("as-attachment", "draft")
 
 	// Query flags
 	queryCmd.Flags().StringVar(&queryFolder, "folder", "inbox", "Folder to search (use \"all\" for all folders)")
@@ -375,46 +569,106 @@ func init() {
 	mailCmd.AddCommand(archiveFromCmd)
 }
 
-// getGraphClient creates a Graph API client with authentication
-func getGraphClient(ctx context.Context) (*mail.GraphClient, error) {
-	account := getActiveAccount()
-	if account == "" {
-		return nil, fmt.Errorf("no account configured. Please run 'auth login'")
-	}
+// getGraphClient creates a Graph API client with authentication.
+// extraScopes are needed in addition to the scopes every login grants.
+func getGraphClient(ctx context.Context, extraScopes ...string) (*mail.GraphClient, error) {
+	accessToken := cfg.AccessToken
+	if accessToken != "" {
+		debugLog("Using access token from O365_ACCESS_TOKEN")
+	} else {
+		account := getActiveAccount()
+		if account == "" {
+			return nil, withExitCode(ExitAuth, "NotAuthenticated", fmt.Errorf("no account configured. Please run 'auth login'"))
+		}
 
-	oauthClient, err := auth.NewOAuthClient(cfg.ClientID, cfg.CacheDir)
-	if err != nil {
-		return nil, err
+		oauthClient, err := auth.NewOAuthClient(cfg.ClientID, cfg.CacheDir)
+		if err != nil {
+			return nil, err
+		}
+
+		accessToken, err = oauthClient.GetAccessToken(ctx, account, extraScopes...)
+		if err != nil && len(extraScopes) > 0 {
+			return nil, withExitCode(ExitAuth, "NotAuthenticated",
+				fmt.Errorf("missing mailbox settings permission (run 'auth login --mailbox-settings'): %w", err))
+		}
+		if err != nil {
+			return nil, withExitCode(ExitAuth, "NotAuthenticated", fmt.Errorf("not logged in: %w", err))
+		}
 	}
 
-	accessToken, err := oauthClient.GetAccessToken(ctx, account)
-	if err != nil {
-		return nil, fmt.Errorf("not logged in: %w", err)
+	retry := retryPolicy()
+	opts := mail.ClientOptions{
+		BaseURL:   cfg.GraphURL,
+		UserAgent: cfg.UserAgent,
+		Retry:     &retry,
+		Logf:      debugLog,
+	}
+	if opts.BaseURL != "" {
+		debugLog("Using Graph endpoint %s", opts.BaseURL)
 	}
 
-	return mail.NewGraphClient(accessToken), nil
+	return mail.NewGraphClientWithOptions(accessToken, opts), nil
+}
+
+// retryPolicy returns the default retry policy with config overrides applied
+func retryPolicy() mail.RetryPolicy {
+	retry := mail.DefaultRetryPolicy()
+	if cfg.RetryMaxAttempts > 0 {
+		retry.MaxAttempts = cfg.RetryMaxAttempts
+	}
+	if cfg.RetryBaseDelay > 0 {
+		retry.BaseDelay = cfg.RetryBaseDelay
+	}
+	if cfg.RetryMaxDelay > 0 {
+		retry.MaxDelay = cfg.RetryMaxDelay
+	}
+	return retry
 }
 
 func runMailList(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	importance, err := parseImportanceFilter(listImportance)
+	if err != nil {
+		return err
+	}
+
+	if listLocal {
+		return runMailListLocal()
+	}
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(listFolder)
+	folderID, err := client.GetFolderByName(ctx, listFolder)
 	if err != nil {
 		return err
 	}
 
 	debugLog("Fetching emails from folder %s via Graph API", listFolder)
 
-	emails, err := client.ListEmails(folderID, listLimit, listUnreadOnly)
+	filter := mail.ListFilter{UnreadOnly: listUnreadOnly, Flagged: listFlagged, Category: listCategory, Importance: importance}
+	emails, err := client.ListEmailsFiltered(ctx, folderID, listLimit, filter)
 	if err != nil {
 		return err
 	}
 
+	if listThreads {
+		threads := mail.GroupThreads(emails)
+		if listJSON {
+			return outputJSON(threads)
+		}
+		if len(threads) == 0 {
+			printInfo("No emails found.")
+			return nil
+		}
+		printThreadTable(threads)
+		fmt.Printf("\n%d threads shown (%d emails)\n", len(threads), len(emails))
+		return nil
+	}
+
 	if listJSON {
 		return outputJSON(emails)
 	}
@@ -424,22 +678,7 @@ func runMailList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("\n%-40s %-20s %-25s %s\n", "ID", "Date", "From", "Subject")
-	fmt.Println(strings.Repeat("─", 110))
-
-	for _, email := range emails {
-		unreadMarker := " "
-		if email.Unread {
-			unreadMarker = "●"
-		}
-
-		id := truncate(email.MessageID, 38)
-		from := truncate(email.From, 23)
-		subject := truncate(email.Subject, 30)
-		date := email.Date.Local().Format("2006-01-02 15:04")
-
-		fmt.Printf("%s %-39s %-20s %-25s %s\n", unreadMarker, id, date, from, subject)
-	}
+	printEmailTable(emails)
 
 	fmt.Printf("\n%d emails shown\n", len(emails))
 
@@ -447,42 +686,48 @@ func runMailList(cmd *cobra.Command, args []string) error {
 }
 
 func runRead(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
+	if readLocal {
+		return runReadLocal(messageID)
+	}
+
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(readFolder)
+	if readHeaders {
+		detail, err := client.GetMessageDetail(ctx, messageID)
+		if err != nil {
+			return err
+		}
+		return showEmail(&detail.Email, detail)
+	}
+
+	folderID, err := client.GetFolderByName(ctx, readFolder)
 	if err != nil {
 		return err
 	}
 
-	email, err := client.GetEmail(folderID, messageID)
+	email, err := client.GetEmail(ctx, folderID, messageID)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println()
-	fmt.Println("═══════════════════════════════════════════════════════════════")
-	fmt.Printf("From:    %s\n", email.From)
-	fmt.Printf("To:      %s\n", strings.Join(email.To, ", "))
-	fmt.Printf("Subject: %s\n", email.Subject)
-	fmt.Printf("Date:    %s\n", email.Date.Local().Format(time.RFC1123))
-	fmt.Println("═══════════════════════════════════════════════════════════════")
-	fmt.Println()
-	fmt.Println(email.Body)
-
-	return nil
+	return showEmail(email, nil)
 }
 
 func runSend(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
-	if len(sendTo) == 0 {
-		return fmt.Errorf("at least one recipient (--to) required")
+	var sendTime time.Time
+	if sendAt != "" {
+		var err error
+		if sendTime, err = parseSendAt(sendAt, time.Now()); err != nil {
+			return fmt.Errorf("invalid --send-at value: %w", err)
+		}
 	}
 
 	body := sendBody
@@ -494,53 +739,120 @@ func runSend(cmd *cobra.Command, args []string) error {
 		body = string(content)
 	}
 
-	if body == "" {
+	msg := compose.Message{To: sendTo, Cc: sendCc, Bcc: sendBcc, Subject: sendSubject, Attach: sendAttach, Body: body}
+	if err := sendTemplate.apply(cmd, nil, &msg, &sendHTML, &sendMarkdown); err != nil {
+		return err
+	}
+	action := editSend
+	if sendEdit {
+		var err error
+		msg, action, err = editMessage(msg, compose.HeaderFields, "", editorExtension(sendHTML, sendMarkdown),
+			permittedActions([]string{editSend, editDraft}, map[string]string{editDraft: "drafts.create"})...)
+		if err != nil {
+			return err
+		}
+		if action == editAbort {
+			printInfo("Cancelled.")
+			return nil
+		}
+	}
+
+	if len(msg.To) == 0 {
+		return fmt.Errorf("at least one recipient (--to) required")
+	}
+	if msg.Subject == "" {
+		return fmt.Errorf("subject required (--subject)")
+	}
+	if msg.Body == "" {
 		return fmt.Errorf("message body required (--body or --body-file)")
 	}
 
-	client, err := getGraphClient(ctx)
+	attachments, err := mail.LoadAttachments(msg.Attach)
 	if err != nil {
 		return err
 	}
 
-	debugLog("Sending email via Microsoft Graph API")
-
 	opts := mail.SendOptions{
-		To:      sendTo,
-		Cc:      sendCc,
-		Bcc:     sendBcc,
-		Subject: sendSubject,
-		Body:    body,
-		HTML:    sendHTML,
+		To:          msg.To,
+		Cc:          msg.Cc,
+		Bcc:         msg.Bcc,
+		Subject:     msg.Subject,
+		Body:        msg.Body,
+		HTML:        sendHTML,
+		Attachments: attachments,
+	}
+	if err := sendProperties.apply(&opts); err != nil {
+		return err
+	}
+	opts.SendAt = sendTime
+	if sendMarkdown {
+		if err := composeMarkdown(&opts); err != nil {
+			return err
+		}
+	}
+	if err := composeHTML(&opts, sendBodyFile, sendTextAlt); err != nil {
+		return err
+	}
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
 	}
 
-	if err := client.Send(opts); err != nil {
+	if action == editDraft {
+		debugLog("Creating draft via Graph API")
+		draftID, err := client.SaveDraft(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to save draft: %w", err)
+		}
+		printSuccess("Draft saved (ID: %s)", draftID)
+		printAttachmentNames(opts.Attachments)
+		return nil
+	}
+
+	debugLog("Sending email via Microsoft Graph API")
+
+	if err := client.Send(ctx, opts); err != nil {
 		return fmt.Errorf("send failed: %w", err)
 	}
 
-	printSuccess("Email sent to %s", strings.Join(sendTo, ", "))
-	if len(sendCc) > 0 {
-		printInfo("CC: %s", strings.Join(sendCc, ", "))
+	if sendTime.IsZero() {
+		printSuccess("Email sent to %s", strings.Join(opts.To, ", "))
+	} else {
+		printSuccess("Email to %s scheduled for %s", strings.Join(opts.To, ", "), sendTime.Local().Format("Mon, 2 Jan 2006 15:04"))
 	}
+	if len(opts.Cc) > 0 {
+		printInfo("CC: %s", strings.Join(opts.Cc, ", "))
+	}
+	printAttachmentNames(opts.Attachments)
 
 	return nil
 }
 
 func runMarkRead(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-	messageID := args[0]
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(markReadFolder)
+	folderID, err := resolveSourceFolder(ctx, client, markReadFolder)
 	if err != nil {
 		return err
 	}
 
-	if err := client.MarkAsRead(folderID, messageID); err != nil {
+	ids, err := markReadSelection.resolve(ctx, client, folderID, args, "Mark %d email(s) as read?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	if len(ids) > 1 {
+		results, err := client.MarkEmailsRead(ctx, folderID, ids, true)
+		return reportBulkResults(results, err, "marked as read")
+	}
+
+	if err := client.MarkAsRead(ctx, folderID, ids[0]); err != nil {
 		return err
 	}
 
@@ -549,20 +861,29 @@ func runMarkRead(cmd *cobra.Command, args []string) error {
 }
 
 func runMarkUnread(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-	messageID := args[0]
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(markUnreadFolder)
+	folderID, err := resolveSourceFolder(ctx, client, markUnreadFolder)
 	if err != nil {
 		return err
 	}
 
-	if err := client.MarkAsUnread(folderID, messageID); err != nil {
+	ids, err := markUnreadSelection.resolve(ctx, client, folderID, args, "Mark %d email(s) as unread?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	if len(ids) > 1 {
+		results, err := client.MarkEmailsRead(ctx, folderID, ids, false)
+		return reportBulkResults(results, err, "marked as unread")
+	}
+
+	if err := client.MarkAsUnread(ctx, folderID, ids[0]); err != nil {
 		return err
 	}
 
@@ -571,25 +892,34 @@ func runMarkUnread(cmd *cobra.Command, args []string) error {
 }
 
 func runMove(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-	messageID := args[0]
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	srcFolderID, err := client.GetFolderByName(moveFromFolder)
+	srcFolderID, err := resolveSourceFolder(ctx, client, moveFromFolder)
 	if err != nil {
 		return err
 	}
 
-	dstFolderID, err := client.GetFolderByName(moveToFolder)
+	dstFolderID, err := client.GetFolderByName(ctx, moveToFolder)
 	if err != nil {
 		return err
 	}
 
-	if err := client.MoveEmail(srcFolderID, messageID, dstFolderID); err != nil {
+	ids, err := moveSelection.resolve(ctx, client, srcFolderID, args, "Move %d email(s) to '%s'?", moveToFolder)
+	if err != nil || ids == nil {
+		return err
+	}
+
+	if len(ids) > 1 {
+		results, err := client.MoveEmails(ctx, srcFolderID, ids, dstFolderID)
+		return reportBulkResults(results, err, fmt.Sprintf("moved from '%s' to '%s'", moveFromFolder, moveToFolder))
+	}
+
+	if err := client.MoveEmail(ctx, srcFolderID, ids[0], dstFolderID); err != nil {
 		return err
 	}
 
@@ -598,20 +928,29 @@ func runMove(cmd *cobra.Command, args []string) error {
 }
 
 func runTrash(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-	messageID := args[0]
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(trashFolder)
+	folderID, err := resolveSourceFolder(ctx, client, trashFolder)
 	if err != nil {
 		return err
 	}
 
-	if err := client.TrashEmail(folderID, messageID); err != nil {
+	ids, err := trashSelection.resolve(ctx, client, folderID, args, "Move %d email(s) to Trash?")
+	if err != nil || ids == nil {
+		return err
+	}
+
+	if len(ids) > 1 {
+		results, err := client.TrashEmails(ctx, folderID, ids)
+		return reportBulkResults(results, err, "moved to Trash")
+	}
+
+	if err := client.TrashEmail(ctx, folderID, ids[0]); err != nil {
 		return err
 	}
 
@@ -620,7 +959,14 @@ func runTrash(cmd *cobra.Command, args []string) error {
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	if searchLocal {
+		return runSearchLocal()
+	}
+	if searchText != "" {
+		return fmt.Errorf("--text requires --local (use 'mail query' for full-text search on the server)")
+	}
 
 	if searchFrom == "" && searchSubject == "" && searchSince == "" {
 		return fmt.Errorf("at least one search criterion required (--from, --subject, or --since)")
@@ -631,7 +977,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(searchFolder)
+	folderID, err := client.GetFolderByName(ctx, searchFolder)
 	if err != nil {
 		return err
 	}
@@ -647,7 +993,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	debugLog("Searching emails via Graph API")
 
-	emails, err := client.SearchEmails(folderID, searchFrom, searchSubject, since, searchLimit)
+	emails, err := client.SearchEmails(ctx, folderID, searchFrom, searchSubject, since, searchLimit)
 	if err != nil {
 		return err
 	}
@@ -661,22 +1007,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("\n%-40s %-20s %-25s %s\n", "ID", "Date", "From", "Subject")
-	fmt.Println(strings.Repeat("─", 110))
-
-	for _, email := range emails {
-		unreadMarker := " "
-		if email.Unread {
-			unreadMarker = "●"
-		}
-
-		id := truncate(email.MessageID, 38)
-		from := truncate(email.From, 23)
-		subject := truncate(email.Subject, 30)
-		date := email.Date.Local().Format("2006-01-02 15:04")
-
-		fmt.Printf("%s %-39s %-20s %-25s %s\n", unreadMarker, id, date, from, subject)
-	}
+	printEmailTable(emails)
 
 	fmt.Printf("\n%d emails found\n", len(emails))
 
@@ -684,7 +1015,7 @@ func runSearch(cmd *cobra.Command, args []string) error {
 }
 
 func runQuery(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	query := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -694,7 +1025,7 @@ func runQuery(cmd *cobra.Command, args []string) error {
 
 	var folderID string
 	if strings.ToLower(queryFolder) != "all" {
-		folderID, err = client.GetFolderByName(queryFolder)
+		folderID, err = client.GetFolderByName(ctx, queryFolder)
 		if err != nil {
 			return err
 		}
@@ -702,7 +1033,7 @@ func runQuery(cmd *cobra.Command, args []string) error {
 
 	debugLog("Searching emails via KQL: %s", query)
 
-	emails, err := client.SearchEmailsKQL(folderID, query, queryLimit)
+	emails, err := client.SearchEmailsKQL(ctx, folderID, query, queryLimit)
 	if err != nil {
 		return err
 	}
@@ -716,22 +1047,7 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Printf("\n%-40s %-20s %-25s %s\n", "ID", "Date", "From", "Subject")
-	fmt.Println(strings.Repeat("─", 110))
-
-	for _, email := range emails {
-		unreadMarker := " "
-		if email.Unread {
-			unreadMarker = "●"
-		}
-
-		id := truncate(email.MessageID, 38)
-		from := truncate(email.From, 23)
-		subject := truncate(email.Subject, 30)
-		date := email.Date.Local().Format("2006-01-02 15:04")
-
-		fmt.Printf("%s %-39s %-20s %-25s %s\n", unreadMarker, id, date, from, subject)
-	}
+	printEmailTable(emails)
 
 	fmt.Printf("\n%d emails found\n", len(emails))
 
@@ -739,7 +1055,7 @@ func runQuery(cmd *cobra.Command, args []string) error {
 }
 
 func runAttachments(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -747,12 +1063,12 @@ func runAttachments(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	folderID, err := client.GetFolderByName(attachFolder)
+	folderID, err := client.GetFolderByName(ctx, attachFolder)
 	if err != nil {
 		return err
 	}
 
-	attachments, err := client.GetAttachments(folderID, messageID, attachSaveTo)
+	attachments, err := client.GetAttachments(ctx, folderID, messageID, attachSaveTo)
 	if err != nil {
 		return err
 	}
@@ -771,7 +1087,7 @@ func runAttachments(cmd *cobra.Command, args []string) error {
 }
 
 func runReply(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
 	comment := replyBody
@@ -788,9 +1104,78 @@ func runReply(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	var original *mail.Email
+	if replyEdit || replyTemplate.name != "" {
+		folderID, err := client.GetFolderByName(ctx, replyFolder)
+		if err != nil {
+			return err
+		}
+		if original, err = client.GetEmail(ctx, folderID, messageID); err != nil {
+			return err
+		}
+	}
+
+	// Template recipients are added to the ones of the reply
+	var fields *templates.Original
+	if original != nil {
+		fields = templates.NewOriginal(original)
+	}
+	reply := compose.Message{To: replyTo, Cc: replyCc, Bcc: replyBcc, Body: comment}
+	if err := replyTemplate.apply(cmd, fields, &reply, &replyHTML, &replyMarkdown); err != nil {
+		return err
+	}
+	comment, replyTo, replyCc, replyBcc = reply.Body, reply.To, reply.Cc, reply.Bcc
+
+	if replyEdit {
+		quoted := original.Body
+		if strings.EqualFold(original.BodyType, "html") {
+			quoted = compose.HTMLToText(quoted)
+		}
+
+		actions := []string{editSend, editDraft}
+		if replyDraft {
+			actions = []string{editDraft, editSend}
+		}
+		actions = permittedActions(actions, map[string]string{editSend: "mail.send", editDraft: "drafts.create"})
+		msg, action, err := editMessage(compose.Message{Body: comment}, nil,
+			compose.Quote(original.From, original.Date, quoted), editorExtension(false, replyMarkdown), actions...)
+		if err != nil {
+			return err
+		}
+		if action == editAbort {
+			printInfo("Cancelled.")
+			return nil
+		}
+		replyDraft = action == editDraft
+		if len(msg.To)+len(msg.Cc)+len(msg.Bcc)+len(msg.Attach) > 0 || msg.Subject != "" {
+			return fmt.Errorf("recipients, subject and attachments of a reply cannot be edited")
+		}
+		comment = msg.Body
+	}
+
+	opts, err := responseOptions(comment, replyHTML, replyMarkdown, replyAttach)
+	if err != nil {
+		return err
+	}
+	opts.To = replyTo
+	opts.Cc = replyCc
+	opts.Bcc = replyBcc
+	opts.Remove = replyRemove
+
+	if replyDraft {
+		debugLog("Creating reply draft via Microsoft Graph API")
+		draftID, err := client.CreateReplyDraft(ctx, messageID, opts, replyAll)
+		if err != nil {
+			return fmt.Errorf("failed to save reply draft: %w", err)
+		}
+		printSuccess("Reply saved as draft (ID: %s)", draftID)
+		printAttachmentNames(opts.Attachments)
+		return nil
+	}
+
 	debugLog("Sending reply via Microsoft Graph API")
 
-	if err := client.Reply(messageID, comment, replyAll); err != nil {
+	if err := client.Reply(ctx, messageID, opts, replyAll); err != nil {
 		return fmt.Errorf("reply failed: %w", err)
 	}
 
@@ -799,15 +1184,16 @@ func runReply(cmd *cobra.Command, args []string) error {
 	} else {
 		printSuccess("Reply sent")
 	}
+	printAttachmentNames(opts.Attachments)
 
 	return nil
 }
 
 func runForward(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	messageID := args[0]
 
-	if len(forwardTo) == 0 {
+	if len(forwardTo) == 0 && !forwardDraft {
 		return fmt.Errorf("at least one recipient (--to) required")
 	}
 
@@ -820,24 +1206,344 @@ func runForward(cmd *cobra.Command, args []string) error {
 		comment = string(content)
 	}
 
+	opts, err := responseOptions(comment, forwardHTML, forwardMarkdown, forwardAttach)
+	if err != nil {
+		return err
+	}
+	opts.To = forwardTo
+	opts.Cc = forwardCc
+	opts.Bcc = forwardBcc
+
 	client, err := getGraphClient(ctx)
 	if err != nil {
 		return err
 	}
 
+	if forwardDraft {
+		debugLog("Creating forward draft via Microsoft Graph API")
+		draftID, err := client.CreateForwardDraft(ctx, messageID, opts)
+		if err != nil {
+			return fmt.Errorf("failed to save forward draft: %w", err)
+		}
+		printSuccess("Forward saved as draft (ID: %s)", draftID)
+		printAttachmentNames(opts.Attachments)
+		return nil
+	}
+
 	debugLog("Forwarding email via Microsoft Graph API")
 
-	if err := client.Forward(messageID, forwardTo, comment); err != nil {
+	if forwardAsAttach {
+		err = client.ForwardAsAttachment(ctx, messageID, opts)
+	} else {
+		err = client.Forward(ctx, messageID, opts)
+	}
+	if err != nil {
 		return fmt.Errorf("forward failed: %w", err)
 	}
 
 	printSuccess("Email forwarded to %s", strings.Join(forwardTo, ", "))
+	if len(forwardCc) > 0 {
+		printInfo("CC: %s", strings.Join(forwardCc, ", "))
+	}
+	printAttachmentNames(opts.Attachments)
 
 	return nil
 }
 
+// responseOptions loads the attachments of a reply or forward and renders a
+// Markdown body as HTML
+func responseOptions(body string, isHTML, markdown bool, attach []string) (mail.ResponseOptions, error) {
+	attachments, err := mail.LoadAttachments(attach)
+	if err != nil {
+		return mail.ResponseOptions{}, err
+	}
+	if markdown && body != "" {
+		rendered, err := renderMarkdown(body)
+		if err != nil {
+			return mail.ResponseOptions{}, err
+		}
+		body, isHTML = rendered, true
+	}
+	return mail.ResponseOptions{Body: body, HTML: isHTML, Attachments: attachments}, nil
+}
+
 // Helper functions
 
+// printEmailTable prints emails as a table with one row per email
+func printEmailTable(emails []mail.Email) {
+	fmt.Printf("\n%-40s %-20s %-25s %s\n", "ID", "Date", "From", "Subject")
+	fmt.Println(strings.Repeat("─", 110))
+
+	for _, email := range emails {
+		unreadMarker := " "
+		if email.Unread {
+			unreadMarker = "●"
+		}
+
+		id := truncate(email.MessageID, 38)
+		from := truncate(email.From, 23)
+		subject := truncate(email.Subject, 30)
+		if email.Flagged {
+			subject = "⚑ " + subject
+		}
+		if email.Importance == "high" {
+			subject = "! " + subject
+		}
+		if len(email.Categories) > 0 {
+			subject += " [" + strings.Join(email.Categories, ", ") + "]"
+		}
+		date := email.Date.Local().Format("2006-01-02 15:04")
+
+		fmt.Printf("%s %-39s %-20s %-25s %s\n", unreadMarker, id, date, from, subject)
+	}
+}
+
+// printEmail prints the headers and body of a single email, with the
+// metadata of detail if it is not nil
+func printEmail(email *mail.Email, detail *mail.MessageDetail) {
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Printf("From:    %s\n", email.From)
+	fmt.Printf("To:      %s\n", strings.Join(email.To, ", "))
+	if len(email.Cc) > 0 {
+		fmt.Printf("Cc:      %s\n", strings.Join(email.Cc, ", "))
+	}
+	fmt.Printf("Subject: %s\n", email.Subject)
+	fmt.Printf("Date:    %s\n", email.Date.Local().Format(time.RFC1123))
+	if detail != nil {
+		printMessageDetail(detail)
+	} else {
+		if email.Importance != "" && email.Importance != "normal" {
+			fmt.Printf("Importance: %s\n", email.Importance)
+		}
+		if email.Flagged {
+			fmt.Printf("Flag:    %s\n", flagDescription(email))
+		}
+		if len(email.Categories) > 0 {
+			fmt.Printf("Categories: %s\n", strings.Join(email.Categories, ", "))
+		}
+	}
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Println()
+	fmt.Println(strings.TrimRight(formatBody(email), "\n"))
+}
+
+// printMessageDetail prints the metadata shown by 'mail read --headers'
+func printMessageDetail(detail *mail.MessageDetail) {
+	fmt.Println("───────────────────────────────────────────────────────────────")
+	fmt.Printf("Importance:   %s\n", valueOrNone(detail.Importance))
+	fmt.Printf("Categories:   %s\n", valueOrNone(strings.Join(detail.Categories, ", ")))
+	flag := detail.FlagStatus
+	if detail.Flagged {
+		flag = flagDescription(&detail.Email)
+	}
+	fmt.Printf("Flag:         %s\n", valueOrNone(flag))
+	fmt.Printf("Conversation: %s\n", valueOrNone(detail.ConversationID))
+	fmt.Printf("Message-ID:   %s\n", valueOrNone(detail.InternetMessageID))
+
+	if results := mail.AuthenticationResults(detail.Headers); len(results) > 0 {
+		fmt.Println("Authentication:")
+		for _, r := range results {
+			fmt.Printf("  %-9s %-9s %s\n", r.Method, r.Result, r.Properties)
+		}
+	}
+
+	if len(detail.Attachments) > 0 {
+		fmt.Println("Attachments:")
+		for _, att := range detail.Attachments {
+			inline := ""
+			if att.IsInline {
+				inline = ", inline"
+			}
+			fmt.Printf("  • %s (%s, %d bytes%s)\n", att.Name, att.ContentType, att.Size, inline)
+		}
+	}
+
+	if len(detail.Headers) > 0 {
+		fmt.Println("Headers:")
+		for _, h := range detail.Headers {
+			fmt.Printf("  %s: %s\n", h.Name, h.Value)
+		}
+	}
+}
+
+// parseImportanceFilter validates the --importance filter of the list
+// commands; an empty value matches all emails
+func parseImportanceFilter(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	importance, err := mail.ParseImportance(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid --importance value: %w", err)
+	}
+	return importance, nil
+}
+
+// flagDescription describes the follow-up flag of a flagged email
+func flagDescription(email *mail.Email) string {
+	if email.FlagDue == nil {
+		return "flagged"
+	}
+	return "flagged, due " + email.FlagDue.Format("Mon, 2 Jan 2006")
+}
+
+// readDetailJSON is the output of 'mail read --headers --json'
+type readDetailJSON struct {
+	*mail.MessageDetail
+	Authentication []mail.AuthResult `json:"authentication,omitempty"`
+}
+
+// showEmail prints an email as text or JSON, or opens its body in the
+// browser (--browser). detail is only set for --headers.
+func showEmail(email *mail.Email, detail *mail.MessageDetail) error {
+	if readJSON {
+		if detail != nil {
+			return outputJSON(readDetailJSON{MessageDetail: detail, Authentication: mail.AuthenticationResults(detail.Headers)})
+		}
+		return outputJSON(email)
+	}
+	if !readBrowser {
+		printEmail(email, detail)
+		return nil
+	}
+
+	f, err := os.CreateTemp("", "o365-mail-*.html")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	_, err = f.WriteString(htmlDocument(email))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+
+	if !openBrowser("file://" + filepath.ToSlash(f.Name())) {
+		printInfo("Could not open a browser, the message is saved as %s", f.Name())
+		return nil
+	}
+	// The browser loads the file after we exit, so it stays in the
+	// temporary directory
+	printSuccess("Opened %q in the browser (saved as %s)", email.Subject, f.Name())
+	return nil
+}
+
+// formatBody renders the body as selected with --text, --raw or --html
+func formatBody(email *mail.Email) string {
+	switch {
+	case readRaw:
+		return email.Body
+	case readHTML:
+		return htmlBody(email)
+	}
+
+	opts := compose.RenderOptions{Width: readWidth, ShowQuoted: readShowQuoted}
+	if opts.Width == 0 {
+		opts.Width = terminalWidth()
+	}
+	if strings.EqualFold(email.BodyType, "html") {
+		return compose.RenderHTML(email.Body, opts)
+	}
+	return compose.RenderText(email.Body, opts)
+}
+
+// htmlBody returns the HTML body, converting text bodies to preformatted HTML
+func htmlBody(email *mail.Email) string {
+	if strings.EqualFold(email.BodyType, "html") {
+		return email.Body
+	}
+	return "<pre>" + html.EscapeString(email.Body) + "</pre>"
+}
+
+// htmlDocument wraps the HTML body into a page for the browser
+func htmlDocument(email *mail.Email) string {
+	body := htmlBody(email)
+	if strings.Contains(strings.ToLower(body), "<html") {
+		return body
+	}
+	return "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(email.Subject) +
+		"</title></head><body>\n" + body + "\n</body></html>\n"
+}
+
+// terminalWidth returns $COLUMNS, or 80 if it is not set
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// composeHTML prepares an HTML body for sending: local images are attached
+// inline, relative to the body file if there is one, and a plain-text
+// alternative is added if requested.
+func composeHTML(opts *mail.SendOptions, bodyFile string, textAlternative bool) error {
+	if !opts.HTML {
+		if textAlternative {
+			return fmt.Errorf("--text-alternative requires --html")
+		}
+		return nil
+	}
+
+	baseDir := "."
+	if bodyFile != "" {
+		baseDir = filepath.Dir(bodyFile)
+	}
+	body, images, err := compose.InlineImages(opts.Body, baseDir)
+	if err != nil {
+		return err
+	}
+	if len(images) > 0 {
+		debugLog("Inlined %d image(s) from %s", len(images), baseDir)
+	}
+	opts.Body = body
+	opts.Attachments = append(images, opts.Attachments...)
+
+	if textAlternative && opts.TextBody == "" {
+		opts.TextBody = compose.HTMLToText(body)
+	}
+	return nil
+}
+
+// composeMarkdown renders a Markdown body as HTML and keeps the Markdown
+// source as its plain-text alternative
+func composeMarkdown(opts *mail.SendOptions) error {
+	rendered, err := renderMarkdown(opts.Body)
+	if err != nil {
+		return err
+	}
+	opts.TextBody = opts.Body
+	opts.Body = rendered
+	opts.HTML = true
+	return nil
+}
+
+// renderMarkdown renders Markdown as HTML styled with the configured theme
+func renderMarkdown(body string) (string, error) {
+	var cssPath string
+	if cfg != nil {
+		cssPath = cfg.MarkdownCSS
+	}
+	theme, err := compose.LoadTheme(cssPath)
+	if err != nil {
+		return "", err
+	}
+	return compose.Markdown(body, theme)
+}
+
+// printAttachmentNames lists the files attached to a sent or saved message
+func printAttachmentNames(attachments []mail.FileAttachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	names := make([]string, len(attachments))
+	for i, att := range attachments {
+		names[i] = att.Name
+	}
+	printInfo("Attachments: %s", strings.Join(names, ", "))
+}
+
 func parseDuration(s string) (time.Duration, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -870,7 +1576,7 @@ func truncate(s string, maxLen int) string {
 }
 
 func runArchiveFrom(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	senderAddresses := args
 
 	client, err := getGraphClient(ctx)
@@ -878,7 +1584,7 @@ func runArchiveFrom(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	srcFolderID, err := client.GetFolderByName(archiveFromFolder)
+	srcFolderID, err := client.GetFolderByName(ctx, archiveFromFolder)
 	if err != nil {
 		return err
 	}
@@ -886,7 +1592,7 @@ func runArchiveFrom(cmd *cobra.Command, args []string) error {
 	debugLog("Searching for emails from: %s", strings.Join(senderAddresses, ", "))
 
 	// Find all emails from the specified senders (no limit - get all)
-	emails, err := client.ListEmailsFromSenders(srcFolderID, senderAddresses, 0)
+	emails, err := client.ListEmailsFromSenders(ctx, srcFolderID, senderAddresses, 0)
 	if err != nil {
 		return fmt.Errorf("failed to list emails: %w", err)
 	}
@@ -900,27 +1606,35 @@ func runArchiveFrom(cmd *cobra.Command, args []string) error {
 
 	if archiveFromDryRun {
 		fmt.Println("\nDry run - would archive:")
-		for _, email := range emails {
-			date := email.Date.Local().Format("2006-01-02")
-			fmt.Printf("  • [%s] %s - %s\n", date, truncate(email.From, 30), truncate(email.Subject, 40))
-		}
+		printEmailBullets(emails)
 		return nil
 	}
 
 	// Get archive folder ID
-	archiveFolderID, err := client.GetFolderByName("Archive")
+	archiveFolderID, err := client.GetFolderByName(ctx, "Archive")
 	if err != nil {
 		return fmt.Errorf("failed to get Archive folder: %w", err)
 	}
 
-	// Move each email to archive
+	// Move the emails to archive in batches
+	ids := make([]string, len(emails))
+	for i, email := range emails {
+		ids[i] = email.MessageID
+	}
+	results, err := client.MoveEmails(ctx, srcFolderID, ids, archiveFolderID)
+
 	archived := 0
-	for _, email := range emails {
-		if err := client.MoveEmail(srcFolderID, email.MessageID, archiveFolderID); err != nil {
-			fmt.Printf("✗ Failed to archive: %s\n", truncate(email.Subject, 50))
-			continue
+	for i, result := range results {
+		if result.Err == nil {
+			archived++
+		} else if result.Err != err {
+			fmt.Printf("✗ Failed to archive: %s\n", truncate(emails[i].Subject, 50))
+			debugLog("Move failed for %s: %v", result.MessageID, result.Err)
 		}
-		archived++
+	}
+	if err != nil {
+		printInfo("Stopped after archiving %d of %d email(s)", archived, len(emails))
+		return err
 	}
 
 	printSuccess("Archived %d email(s) from %s", archived, strings.Join(senderAddresses, ", "))
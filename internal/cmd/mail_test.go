@@ -0,0 +1,1106 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func seedMessages(srv *graphtest.Server, from string, n int) []string {
+	base := time.Now().Add(-time.Hour).UTC()
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		ids[i] = srv.AddMessage(graphtest.Message{
+			Subject:  fmt.Sprintf("%s #%d", from, i),
+			Body:     "Hello",
+			From:     from,
+			To:       []string{"me@example.com"},
+			Received: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return ids
+}
+
+func TestMailList_JSON(t *testing.T) {
+	srv := newTestServer(t)
+	srv.PageSize = 4
+	seedMessages(srv, "a@example.com", 10)
+
+	res := runCLI(t, "", "mail", "list", "--limit", "6", "--json")
+	mustSucceed(t, res)
+
+	var emails []mail.Email
+	if err := json.Unmarshal([]byte(res.Stdout), &emails); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, res.Stdout)
+	}
+	if len(emails) != 6 {
+		t.Errorf("got %d emails, want 6", len(emails))
+	}
+}
+
+func TestMailList_Table(t *testing.T) {
+	srv := newTestServer(t)
+	seedMessages(srv, "a@example.com", 2)
+
+	res := runCLI(t, "", "mail", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "2 emails shown")
+	assertContains(t, res.Stdout, "a@example.com #1")
+}
+
+func TestMailRead(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Status", Body: "All good", From: "Bob <bob@example.com>"})
+
+	res := runCLI(t, "", "mail", "read", id)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Subject: Status")
+	assertContains(t, res.Stdout, "Bob <bob@example.com>")
+	assertContains(t, res.Stdout, "All good")
+}
+
+func TestMailRead_RenderModes(t *testing.T) {
+	srv := newTestServer(t)
+	body := `<p>See <a href="https://example.com/report">the report</a>.</p><blockquote>Earlier mail</blockquote>`
+	id := srv.AddMessage(graphtest.Message{Subject: "Status", Body: body, BodyType: "html", From: "bob@example.com"})
+
+	res := runCLI(t, "", "mail", "read", id)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "See the report[1].")
+	assertContains(t, res.Stdout, "[quoted text hidden]")
+	assertContains(t, res.Stdout, "[1] https://example.com/report")
+
+	res = runCLI(t, "", "mail", "read", id, "--show-quoted")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "> Earlier mail")
+
+	res = runCLI(t, "", "mail", "read", id, "--html")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, body)
+
+	if res := runCLI(t, "", "mail", "read", id, "--raw", "--html"); res.Err == nil {
+		t.Error("expected error for --raw with --html")
+	}
+}
+
+func TestMailRead_Headers(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{
+		Subject:    "Invoice",
+		Body:       "Please pay",
+		From:       "billing@example.com",
+		Cc:         []string{"accounts@example.com"},
+		Importance: "high",
+		Categories: []string{"Finance"},
+		Headers: []mail.MessageHeader{
+			{Name: "Authentication-Results", Value: "spf=pass smtp.mailfrom=example.com; dkim=fail header.d=example.com; dmarc=fail action=quarantine header.from=example.com"},
+			{Name: "X-Mailer", Value: "Billing 2.0"},
+		},
+	})
+
+	res := runCLI(t, "", "mail", "read", id, "--headers")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Cc:      accounts@example.com")
+	assertContains(t, res.Stdout, "Importance:   high")
+	assertContains(t, res.Stdout, "Categories:   Finance")
+	assertContains(t, res.Stdout, "Conversation: conv-"+id)
+	assertContains(t, res.Stdout, "dkim      fail      header.d=example.com")
+	assertContains(t, res.Stdout, "X-Mailer: Billing 2.0")
+	assertContains(t, res.Stdout, "Please pay")
+
+	res = runCLI(t, "", "mail", "read", id, "--headers", "--json")
+	mustSucceed(t, res)
+	var out struct {
+		Importance     string            `json:"importance"`
+		ConversationID string            `json:"conversation_id"`
+		Authentication []mail.AuthResult `json:"authentication"`
+	}
+	if err := json.Unmarshal([]byte(res.Stdout), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, res.Stdout)
+	}
+	if out.Importance != "high" || out.ConversationID != "conv-"+id || len(out.Authentication) != 3 {
+		t.Errorf("JSON = %+v", out)
+	}
+}
+
+func TestMailThread(t *testing.T) {
+	srv := newTestServer(t)
+	base := time.Now().Add(-3 * time.Hour).UTC()
+	srv.AddMessage(graphtest.Message{FolderID: "inbox", Subject: "Plan", Body: "Shall we meet on Monday?", From: "bob@example.com", ConversationID: "c1", Received: base, IsRead: true})
+	srv.AddMessage(graphtest.Message{FolderID: "sentitems", Subject: "RE: Plan", Body: "Monday works for me.", From: "me@example.com", ConversationID: "c1", Received: base.Add(time.Hour), IsRead: true})
+	last := srv.AddMessage(graphtest.Message{FolderID: "inbox", Subject: "RE: Plan", Body: "<p>Great, see you then.</p><blockquote>Monday works for me.</blockquote>", BodyType: "html", From: "bob@example.com", ConversationID: "c1", Received: base.Add(2 * time.Hour)})
+	srv.AddMessage(graphtest.Message{FolderID: "inbox", Subject: "Unrelated", Body: "Other", ConversationID: "c2", Received: base})
+
+	res := runCLI(t, "", "mail", "thread", last)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Thread:  Plan")
+	assertContains(t, res.Stdout, "3 messages, 1 unread")
+	assertContains(t, res.Stdout, "[2/3] me@example.com")
+	assertContains(t, res.Stdout, "  Monday works for me.")
+	assertContains(t, res.Stdout, "Great, see you then.")
+	assertContains(t, res.Stdout, "[quoted text hidden]")
+	if strings.Contains(res.Stdout, "Unrelated") {
+		t.Error("thread contains a message of another conversation")
+	}
+
+	res = runCLI(t, "", "mail", "thread", last, "--json")
+	mustSucceed(t, res)
+	var thread mail.Thread
+	if err := json.Unmarshal([]byte(res.Stdout), &thread); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, res.Stdout)
+	}
+	if len(thread.Messages) != 3 || thread.Messages[0].Subject != "Plan" || thread.Messages[2].MessageID != last {
+		t.Errorf("thread = %+v", thread)
+	}
+}
+
+func TestMailList_Threads(t *testing.T) {
+	srv := newTestServer(t)
+	base := time.Now().Add(-3 * time.Hour).UTC()
+	srv.AddMessage(graphtest.Message{Subject: "Plan", From: "bob@example.com", ConversationID: "c1", Received: base})
+	srv.AddMessage(graphtest.Message{Subject: "Unrelated", From: "eve@example.com", ConversationID: "c2", Received: base.Add(time.Hour)})
+	srv.AddMessage(graphtest.Message{Subject: "RE: Plan", From: "bob@example.com", ConversationID: "c1", Received: base.Add(2 * time.Hour)})
+
+	res := runCLI(t, "", "mail", "list", "--threads")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "(2) RE: Plan")
+	assertContains(t, res.Stdout, "2 threads shown (3 emails)")
+
+	res = runCLI(t, "", "mail", "list", "--threads", "--json")
+	mustSucceed(t, res)
+	var threads []mail.Thread
+	if err := json.Unmarshal([]byte(res.Stdout), &threads); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, res.Stdout)
+	}
+	if len(threads) != 2 || threads[0].ConversationID != "c1" || len(threads[0].Messages) != 2 {
+		t.Errorf("threads = %+v", threads)
+	}
+}
+
+func TestMailSend(t *testing.T) {
+	srv := newTestServer(t)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--cc", "y@example.com", "--subject", "Hi", "--body", "Text")
+	mustSucceed(t, res)
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 {
+		t.Fatalf("got %d sent messages, want 1", len(sent))
+	}
+	if sent[0].Subject != "Hi" || sent[0].Cc[0] != "y@example.com" {
+		t.Errorf("sent = %+v", sent[0])
+	}
+}
+
+func TestMailSend_Attach(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	small, large := filepath.Join(dir, "notes.txt"), filepath.Join(dir, "data.bin")
+	os.WriteFile(small, []byte("notes"), 0600)
+	os.WriteFile(large, bytes.Repeat([]byte{0}, 4*1024*1024), 0600)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Files", "--body", "Text", "--attach", small, "--attach", large)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Attachments: notes.txt, data.bin")
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || len(sent[0].Attachment) != 2 {
+		t.Fatalf("sent = %d message(s), want 1 with 2 attachments", len(sent))
+	}
+	if att := sent[0].Attachment[0]; att.Name != "notes.txt" || !strings.HasPrefix(att.ContentType, "text/plain") {
+		t.Errorf("attachment = %s (%s), want notes.txt as text/plain", att.Name, att.ContentType)
+	}
+	if att := sent[0].Attachment[1]; len(att.Content) != 4*1024*1024 {
+		t.Errorf("large attachment has %d bytes", len(att.Content))
+	}
+
+	res = runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Later", "--body", "Text", "--attach", filepath.Join(dir, "missing.pdf"))
+	if res.Err == nil {
+		t.Fatal("expected error for a missing attachment")
+	}
+	if len(srv.Messages("drafts")) != 0 {
+		t.Error("draft created despite a missing attachment")
+	}
+}
+
+func TestMailSend_InlineImages(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("\x89PNG\r\n\x1a\nlogo"), 0600)
+	bodyFile := filepath.Join(dir, "news.html")
+	os.WriteFile(bodyFile, []byte(`<p>Hello <b>team</b></p><img src="logo.png" alt="Logo">`), 0600)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "News", "--body-file", bodyFile, "--html", "--text-alternative")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Attachments: logo.png")
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || len(sent[0].Attachment) != 1 {
+		t.Fatalf("sent = %d message(s), want 1 with the inline image", len(sent))
+	}
+	att := sent[0].Attachment[0]
+	if !att.Inline || att.ContentID == "" || !strings.Contains(sent[0].Body, `src="cid:`+att.ContentID+`"`) {
+		t.Errorf("image %+v not referenced inline from %s", att, sent[0].Body)
+	}
+	assertContains(t, string(sent[0].MIME), "Hello team")
+
+	res = runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Plain", "--body", "Text", "--text-alternative")
+	if res.Err == nil {
+		t.Fatal("expected error for --text-alternative without --html")
+	}
+}
+
+func TestMailSend_RequiresBody(t *testing.T) {
+	newTestServer(t)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Hi")
+	if res.Err == nil {
+		t.Fatal("expected error without body")
+	}
+}
+
+func TestMailSend_Properties(t *testing.T) {
+	srv := newTestServer(t)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Outage", "--body", "The VPN is down",
+		"--importance", "High", "--sensitivity", "private", "--read-receipt", "--reply-to", "ops@example.com")
+	mustSucceed(t, res)
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 {
+		t.Fatalf("got %d sent messages, want 1", len(sent))
+	}
+	m := sent[0]
+	if m.Importance != "high" || m.Sensitivity != "private" || !m.ReadReceipt || m.DeliveryReceipt {
+		t.Errorf("sent = importance %q, sensitivity %q, receipts %v/%v", m.Importance, m.Sensitivity, m.ReadReceipt, m.DeliveryReceipt)
+	}
+	if len(m.ReplyTo) != 1 || m.ReplyTo[0] != "ops@example.com" {
+		t.Errorf("reply-to = %v", m.ReplyTo)
+	}
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Later", "--body", "Text", "--importance", "low", "--delivery-receipt"))
+	if drafts := srv.Messages("drafts"); len(drafts) != 1 || drafts[0].Importance != "low" || !drafts[0].DeliveryReceipt {
+		t.Errorf("drafts = %+v", drafts)
+	}
+
+	res = runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Hi", "--body", "Text", "--sensitivity", "secret")
+	if res.Err == nil {
+		t.Fatal("expected error for an invalid sensitivity")
+	}
+}
+
+func TestMailList_Importance(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Outage", Importance: "high"})
+	srv.AddMessage(graphtest.Message{Subject: "Newsletter", Importance: "low"})
+
+	res := runCLI(t, "", "mail", "list", "--importance", "high")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "! Outage")
+	assertContains(t, res.Stdout, "1 emails shown")
+
+	res = runCLI(t, "", "mail", "read", id)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Importance: high")
+
+	if res := runCLI(t, "", "mail", "list", "--importance", "urgent"); res.Err == nil {
+		t.Error("expected error for an invalid importance")
+	}
+}
+
+func TestMailMoveAndTrash(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 2)
+
+	mustSucceed(t, runCLI(t, "", "mail", "move", ids[0], "--to", "Archive"))
+	mustSucceed(t, runCLI(t, "", "mail", "trash", ids[1]))
+
+	if m, _ := srv.Message(ids[0]); m.FolderID != "archive" {
+		t.Errorf("moved message in %q", m.FolderID)
+	}
+	if m, _ := srv.Message(ids[1]); m.FolderID != "deleteditems" {
+		t.Errorf("trashed message in %q", m.FolderID)
+	}
+}
+
+func TestMailArchiveFrom(t *testing.T) {
+	srv := newTestServer(t)
+	srv.PageSize = 3
+	newsIDs := seedMessages(srv, "news@example.com", 5)
+	otherIDs := seedMessages(srv, "boss@example.com", 2)
+
+	res := runCLI(t, "", "mail", "archive-from", "news@example.com")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Archived 5 email(s)")
+
+	for _, id := range newsIDs {
+		if m, _ := srv.Message(id); m.FolderID != "archive" {
+			t.Errorf("message %s in %q, want archive", id, m.FolderID)
+		}
+	}
+	for _, id := range otherIDs {
+		if m, _ := srv.Message(id); m.FolderID != "inbox" {
+			t.Errorf("message %s from other sender was moved", id)
+		}
+	}
+}
+
+func TestMailArchiveFrom_Batched(t *testing.T) {
+	srv := newTestServer(t)
+	seedMessages(srv, "news@example.com", 45)
+
+	res := runCLI(t, "", "mail", "archive-from", "news@example.com")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Archived 45 email(s)")
+
+	if got := srv.BatchCalls(); fmt.Sprint(got) != "[20 20 5]" {
+		t.Errorf("batch sizes = %v, want [20 20 5]", got)
+	}
+	if n := len(srv.Messages("archive")); n != 45 {
+		t.Errorf("%d messages archived, want 45", n)
+	}
+}
+
+func TestMailBulkActions(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 4)
+
+	mustSucceed(t, runCLI(t, "", "mail", "mark-read", ids[0], ids[1], ids[2]))
+	for i, id := range ids {
+		if m, _ := srv.Message(id); m.IsRead != (i < 3) {
+			t.Errorf("message %d read = %v", i, m.IsRead)
+		}
+	}
+
+	res := runCLI(t, "", "mail", "mark-unread", ids[0], ids[1])
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "2 email(s) marked as unread")
+
+	mustSucceed(t, runCLI(t, "", "mail", "move", ids[0], ids[1], "--to", "Archive"))
+	mustSucceed(t, runCLI(t, "", "mail", "trash", ids[2], ids[3]))
+
+	if n := len(srv.Messages("archive")); n != 2 {
+		t.Errorf("%d messages archived, want 2", n)
+	}
+	if n := len(srv.Messages("deleteditems")); n != 2 {
+		t.Errorf("%d messages trashed, want 2", n)
+	}
+	if n := len(srv.BatchCalls()); n != 4 {
+		t.Errorf("made %d batch calls, want 4", n)
+	}
+}
+
+func TestMailBulkActions_PartialFailure(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 2)
+
+	res := runCLI(t, "", "mail", "trash", ids[0], "missing-id", ids[1])
+	if code := ExitCode(res.Err); code != ExitNotFound {
+		t.Errorf("exit code = %d, want %d (err: %v)", code, ExitNotFound, res.Err)
+	}
+	assertContains(t, res.Stdout, "✗ missing-id")
+	assertContains(t, res.Stdout, "2 of 3 email(s) moved to Trash")
+
+	if n := len(srv.Messages("deleteditems")); n != 2 {
+		t.Errorf("%d messages trashed, want 2", n)
+	}
+}
+
+func TestMailBulkActions_Filters(t *testing.T) {
+	srv := newTestServer(t)
+	newsIDs := seedMessages(srv, "news@example.com", 25)
+	otherIDs := seedMessages(srv, "boss@example.com", 2)
+
+	res := runCLI(t, "", "mail", "move", "--from", "news@example.com", "--to", "Archive", "--dry-run")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Found 25 email(s)")
+	assertContains(t, res.Stdout, "Dry run - no changes made")
+	if n := len(srv.Messages("archive")); n != 0 {
+		t.Fatalf("dry run moved %d messages", n)
+	}
+
+	// Declining the confirmation prompt changes nothing
+	res = runCLI(t, "n\n", "mail", "move", "--from", "news@example.com", "--to", "Archive")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Move 25 email(s) to 'Archive'? [y/N]")
+	assertContains(t, res.Stdout, "Cancelled.")
+	if n := len(srv.Messages("archive")); n != 0 {
+		t.Fatalf("cancelled move changed %d messages", n)
+	}
+
+	res = runCLI(t, "y\n", "mail", "move", "--from", "news@example.com", "--to", "Archive", "--limit", "21")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "21 email(s) moved")
+	if n := len(srv.Messages("archive")); n != 21 {
+		t.Errorf("%d messages archived, want 21", n)
+	}
+
+	mustSucceed(t, runCLI(t, "", "mail", "mark-read", "--from", "news@example.com", "--folder", "all", "--yes"))
+	for _, id := range newsIDs {
+		if m, _ := srv.Message(id); !m.IsRead {
+			t.Errorf("message %s in %s not marked read", id, m.FolderID)
+		}
+	}
+	for _, id := range otherIDs {
+		if m, _ := srv.Message(id); m.IsRead {
+			t.Errorf("message %s from other sender marked read", id)
+		}
+	}
+}
+
+func TestMailMove_PromptWithPercentInFolder(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddFolder("", "100% done")
+	seedMessages(srv, "news@example.com", 2)
+
+	res := runCLI(t, "n\n", "mail", "move", "--from", "news@example.com", "--to", "100% done")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Move 2 email(s) to '100% done'? [y/N]")
+}
+
+func TestMailBulkActions_Query(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddMessage(graphtest.Message{Subject: "Weekly digest", From: "digest@example.com"})
+	srv.AddMessage(graphtest.Message{Subject: "Another digest", From: "digest@example.com"})
+	keep := srv.AddMessage(graphtest.Message{Subject: "Contract", From: "legal@example.com"})
+
+	res := runCLI(t, "y\n", "mail", "trash", "--query", "digest")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Move 2 email(s) to Trash? [y/N]")
+	assertContains(t, res.Stdout, "2 email(s) moved to Trash")
+
+	if n := len(srv.Messages("deleteditems")); n != 2 {
+		t.Errorf("%d messages trashed, want 2", n)
+	}
+	if m, _ := srv.Message(keep); m.FolderID != "inbox" {
+		t.Errorf("unrelated message moved to %q", m.FolderID)
+	}
+}
+
+func TestMailBulkActions_SelectionErrors(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 1)
+
+	for _, args := range [][]string{
+		{"mail", "trash"},
+		{"mail", "trash", ids[0], "--from", "a@example.com"},
+		{"mail", "mark-read", "--query", "x", "--since", "7d"},
+	} {
+		if res := runCLI(t, "", args...); res.Err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+	if m, _ := srv.Message(ids[0]); m.FolderID != "inbox" {
+		t.Errorf("message moved to %q", m.FolderID)
+	}
+}
+
+func TestMailArchiveFrom_DryRun(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "news@example.com", 3)
+
+	res := runCLI(t, "", "mail", "archive-from", "news@example.com", "--dry-run")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Dry run - would archive")
+
+	for _, id := range ids {
+		if m, _ := srv.Message(id); m.FolderID != "inbox" {
+			t.Errorf("dry run moved message %s", id)
+		}
+	}
+	if n := srv.CountRequests("POST", "/v1.0/me/mailFolders/inbox/messages/"); n != 0 {
+		t.Errorf("dry run made %d POST requests", n)
+	}
+
+	// Flags must not leak into the next run
+	res = runCLI(t, "", "mail", "archive-from", "news@example.com")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Archived 3 email(s)")
+}
+
+func TestMailSearchAndQuery(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddMessage(graphtest.Message{Subject: "Invoice 42", From: "billing@example.com"})
+	srv.AddMessage(graphtest.Message{Subject: "Lunch", From: "friend@example.com"})
+
+	res := runCLI(t, "", "mail", "search", "--from", "billing", "--json")
+	mustSucceed(t, res)
+	var emails []mail.Email
+	json.Unmarshal([]byte(res.Stdout), &emails)
+	if len(emails) != 1 || emails[0].Subject != "Invoice 42" {
+		t.Errorf("search returned %+v", emails)
+	}
+
+	res = runCLI(t, "", "mail", "query", "lunch", "--folder", "all")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "1 emails found")
+}
+
+func TestMailAttachments(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{
+		Subject:    "Report",
+		Attachment: []graphtest.Attachment{{Name: "report.txt", ContentType: "text/plain", Content: []byte("data")}},
+	})
+	dir := t.TempDir()
+
+	res := runCLI(t, "", "mail", "attachments", id, "--save-to", dir)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "report.txt")
+	if _, err := os.Stat(filepath.Join(dir, "report.txt")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMailReplyAndForward(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com"})
+
+	mustSucceed(t, runCLI(t, "", "mail", "reply", id, "--body", "Answer", "--reply-all"))
+	mustSucceed(t, runCLI(t, "", "mail", "forward", id, "--to", "c@example.com"))
+
+	actions := srv.Actions()
+	if len(actions) != 2 || actions[0].Kind != "replyAll" || actions[1].Kind != "forward" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	if actions[0].Body["comment"] != "Answer" {
+		t.Errorf("reply comment = %v", actions[0].Body["comment"])
+	}
+}
+
+func TestMailMarkdown(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com"})
+
+	mustSucceed(t, runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Notes", "--body", "# Notes\n\n- **one**\n- two\n", "--markdown"))
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].BodyType != "html" {
+		t.Fatalf("sent = %+v, want 1 HTML message", sent)
+	}
+	assertContains(t, sent[0].Body, "<strong")
+	assertContains(t, sent[0].Body, `<h1 style="`)
+	assertContains(t, string(sent[0].MIME), "- **one**")
+
+	mustSucceed(t, runCLI(t, "", "mail", "reply", id, "--body", "*Done*", "--markdown"))
+	sent = srv.Messages("sentitems")
+	if len(sent) != 2 || sent[0].Subject != "RE: Question" || sent[0].BodyType != "html" {
+		t.Fatalf("sent = %+v, want an HTML reply", sent)
+	}
+	assertContains(t, sent[0].Body, "<em>Done</em>")
+	assertContains(t, sent[0].Body, "From: a@example.com")
+
+	res := runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Both", "--body", "x", "--html", "--markdown")
+	if res.Err == nil {
+		t.Error("expected error for --html with --markdown")
+	}
+}
+
+// fakeEditor installs an $EDITOR that copies the template it opens to the
+// returned path and replaces it with content
+func fakeEditor(t *testing.T, content string) (seen string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("editor script needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	seen = filepath.Join(dir, "seen.txt")
+	edited := filepath.Join(dir, "edited.txt")
+	os.WriteFile(edited, []byte(content), 0600)
+	script := filepath.Join(dir, "editor.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" "+seen+"\ncat "+edited+" > \"$1\"\n"), 0700)
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script)
+	return seen
+}
+
+func TestMailSend_Edit(t *testing.T) {
+	srv := newTestServer(t)
+	seen := fakeEditor(t, "To: x@example.com, y@example.com\nSubject: Edited\n\nWritten in the editor\n")
+
+	res := runCLI(t, "s\n", "mail", "send", "--to", "x@example.com", "--body", "Start", "--edit")
+	mustSucceed(t, res)
+	template, _ := os.ReadFile(seen)
+	assertContains(t, string(template), "To: x@example.com\nCc: \n")
+	assertContains(t, string(template), "\nStart\n")
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].Subject != "Edited" || len(sent[0].To) != 2 || sent[0].Body != "Written in the editor\n" {
+		t.Fatalf("sent = %+v", sent)
+	}
+
+	mustSucceed(t, runCLI(t, "d\n", "mail", "send", "--edit"))
+	if len(srv.Messages("drafts")) != 1 || len(srv.Messages("sentitems")) != 1 {
+		t.Error("save as draft did not create a draft")
+	}
+
+	res = runCLI(t, "a\n", "mail", "drafts", "create", "--edit")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Cancelled")
+	if len(srv.Messages("drafts")) != 1 {
+		t.Error("aborted draft was saved")
+	}
+}
+
+func TestDraftsCreate_EditWithoutSendPermission(t *testing.T) {
+	srv := newTestServer(t)
+	fakeEditor(t, "To: x@example.com\nSubject: Sneaky\n\nBody\n")
+	writeProfile(t, "assistant", "enforce: true\nallow:\n  - drafts.create\n")
+
+	res := runCLI(t, "s\n", "mail", "drafts", "create", "--edit")
+	mustSucceed(t, res)
+	if strings.Contains(res.Stdout, "send") {
+		t.Errorf("send offered without mail.send permission:\n%s", res.Stdout)
+	}
+	if len(srv.Messages("sentitems")) != 0 {
+		t.Error("email sent without mail.send permission")
+	}
+
+	mustSucceed(t, runCLI(t, "d\n", "mail", "drafts", "create", "--edit"))
+	if len(srv.Messages("drafts")) != 1 {
+		t.Error("draft was not saved")
+	}
+}
+
+func TestDraftsEdit_EditWithoutSendPermission(t *testing.T) {
+	srv := newTestServer(t)
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Plan", "--body", "v1"))
+	id := srv.Messages("drafts")[0].ID
+	fakeEditor(t, "To: x@example.com\nSubject: Plan\n\nv2\n")
+	writeProfile(t, "assistant", "enforce: true\nallow:\n  - drafts.edit\n")
+
+	res := runCLI(t, "s\n", "mail", "drafts", "edit", id, "--edit")
+	mustSucceed(t, res)
+	if strings.Contains(res.Stdout, "send") || len(srv.Messages("sentitems")) != 0 {
+		t.Errorf("draft could be sent without drafts.send permission:\n%s", res.Stdout)
+	}
+}
+
+func TestMailReply_Edit(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com", Body: "<p>Can you <b>help</b>?</p>", BodyType: "html"})
+	seen := fakeEditor(t, "Sure, on it.\n")
+
+	mustSucceed(t, runCLI(t, "\n", "mail", "reply", id, "--edit"))
+	template, _ := os.ReadFile(seen)
+	assertContains(t, string(template), "a@example.com wrote:\n> Can you help?\n")
+
+	actions := srv.Actions()
+	if len(actions) != 1 || actions[0].Body["comment"] != "Sure, on it.\n" {
+		t.Errorf("actions = %+v", actions)
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	srv := newTestServer(t)
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--subject", "Later", "--body", "Text"))
+
+	drafts := srv.Messages("drafts")
+	if len(drafts) != 1 {
+		t.Fatalf("got %d drafts, want 1", len(drafts))
+	}
+
+	res := runCLI(t, "", "mail", "drafts", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "1 draft(s) found")
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "send", drafts[0].ID))
+	if len(srv.Messages("sentitems")) != 1 {
+		t.Error("draft was not sent")
+	}
+}
+
+func TestDraftsEdit(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	v1, v2 := filepath.Join(dir, "v1.txt"), filepath.Join(dir, "v2.txt")
+	os.WriteFile(v1, []byte("first"), 0600)
+	os.WriteFile(v2, []byte("second"), 0600)
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "create", "--to", "x@example.com", "--bcc", "hidden@example.com", "--subject", "Plan", "--body", "v1", "--attach", v1))
+	id := srv.Messages("drafts")[0].ID
+
+	res := runCLI(t, "", "mail", "drafts", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, truncate(id, 48))
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "edit", id, "--subject", "Plan v2", "--cc", "y@example.com", "--attach", v2, "--remove-attachment", "v1.txt"))
+	m, _ := srv.Message(id)
+	if m.Subject != "Plan v2" || m.Body != "v1" || len(m.Cc) != 1 || len(m.Bcc) != 1 {
+		t.Errorf("draft = %+v", m)
+	}
+	if len(m.Attachment) != 1 || m.Attachment[0].Name != "v2.txt" {
+		t.Errorf("attachments = %+v", m.Attachment)
+	}
+
+	seen := fakeEditor(t, "To: z@example.com\nSubject: Final\n\nEdited body\n")
+	mustSucceed(t, runCLI(t, "s\n", "mail", "drafts", "edit", id, "--edit"))
+	template, _ := os.ReadFile(seen)
+	assertContains(t, string(template), "Bcc: hidden@example.com\nSubject: Plan v2\n")
+	assertContains(t, string(template), "v2.txt (6 bytes)")
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].Subject != "Final" || sent[0].To[0] != "z@example.com" || len(sent[0].Bcc) != 0 || sent[0].Body != "Edited body\n" {
+		t.Fatalf("sent = %+v", sent)
+	}
+
+	if res := runCLI(t, "", "mail", "drafts", "edit", id); res.Err == nil {
+		t.Error("expected error without changes")
+	}
+}
+
+func TestMailReplyAndForward_Draft(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com"})
+
+	res := runCLI(t, "", "mail", "reply", id, "--body", "Answer", "--draft")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Reply saved as draft")
+	mustSucceed(t, runCLI(t, "", "mail", "forward", id, "--draft"))
+
+	drafts := srv.Messages("drafts")
+	if len(drafts) != 2 || len(srv.Actions()) != 0 {
+		t.Fatalf("drafts = %+v, actions = %+v", drafts, srv.Actions())
+	}
+	if drafts[1].Subject != "RE: Question" || drafts[0].Subject != "FW: Question" || len(drafts[0].To) != 0 {
+		t.Errorf("drafts = %q (%v), %q", drafts[0].Subject, drafts[0].To, drafts[1].Subject)
+	}
+
+	if res := runCLI(t, "", "mail", "forward", id); res.Err == nil {
+		t.Error("expected error for a forward without --to")
+	}
+}
+
+func TestMailReplyAndForward_DraftPermission(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com"})
+	writeProfile(t, "assistant", "enforce: true\nallow:\n  - drafts.create\n")
+
+	mustSucceed(t, runCLI(t, "", "mail", "reply", id, "--body", "Answer", "--draft"))
+	mustSucceed(t, runCLI(t, "", "mail", "forward", id, "--draft"))
+	if len(srv.Messages("drafts")) != 2 {
+		t.Errorf("drafts = %+v", srv.Messages("drafts"))
+	}
+
+	res := runCLI(t, "", "mail", "reply", id, "--body", "Answer")
+	if code := ExitCode(res.Err); code != ExitPermission {
+		t.Errorf("reply without --draft exit code = %d, want %d", code, ExitPermission)
+	}
+
+	fakeEditor(t, "Sure\n")
+	res = runCLI(t, "s\n", "mail", "reply", id, "--draft", "--edit")
+	mustSucceed(t, res)
+	if len(srv.Actions()) != 0 || len(srv.Messages("sentitems")) != 0 {
+		t.Error("reply sent without mail.send permission")
+	}
+}
+
+func TestMailReplyAndForward_Options(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com", Cc: []string{"b@example.com"}})
+	path := filepath.Join(t.TempDir(), "offer.pdf")
+	if err := os.WriteFile(path, []byte("%PDF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runCLI(t, "", "mail", "reply", id, "--body", "<b>Yes</b>", "--html", "--reply-all",
+		"--cc", "c@example.com", "--remove-recipient", "b@example.com", "--attach", path)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Attachments: offer.pdf")
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].BodyType != "html" || len(sent[0].Cc) != 1 || sent[0].Cc[0] != "c@example.com" || len(sent[0].Attachment) != 1 {
+		t.Fatalf("sent = %+v", sent)
+	}
+	assertContains(t, sent[0].Body, "<b>Yes</b>")
+
+	mustSucceed(t, runCLI(t, "", "mail", "forward", id, "--to", "d@example.com", "--as-attachment"))
+	sent = srv.Messages("sentitems")
+	if len(sent) != 2 || sent[0].Subject != "FW: Question" || len(sent[0].Attachment) != 1 || sent[0].Attachment[0].Name != "Question.eml" {
+		t.Fatalf("sent = %+v", sent)
+	}
+
+	if res := runCLI(t, "", "mail", "forward", id, "--as-attachment", "--draft"); res.Err == nil {
+		t.Error("expected error for --as-attachment with --draft")
+	}
+}
+
+func TestDraftsScheduled(t *testing.T) {
+	srv := newTestServer(t)
+
+	res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Announcement", "--body", "Text", "--send-at", "2h")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Email to x@example.com scheduled for")
+	if len(srv.Messages("sentitems")) != 0 {
+		t.Fatal("scheduled email was sent immediately")
+	}
+
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "create", "--to", "y@example.com", "--subject", "Reminder", "--body", "Text"))
+	draft := srv.Messages("drafts")[0]
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "send", draft.ID, "--send-at", "tomorrow 08:00"))
+
+	res = runCLI(t, "", "mail", "drafts", "scheduled")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Announcement")
+	assertContains(t, res.Stdout, "2 scheduled email(s)")
+
+	outbox := srv.Messages("outbox")
+	if len(outbox) != 2 {
+		t.Fatalf("got %d emails in the Outbox, want 2", len(outbox))
+	}
+	mustSucceed(t, runCLI(t, "", "mail", "drafts", "scheduled", "cancel", draft.ID))
+	if m, _ := srv.Message(draft.ID); m.FolderID != "drafts" {
+		t.Errorf("cancelled email in %s, want drafts", m.FolderID)
+	}
+
+	if res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "Hi", "--body", "Text", "--send-at", "2000-01-01 08:00"); res.Err == nil {
+		t.Error("expected error for a send time in the past")
+	}
+}
+
+func TestParseSendAt(t *testing.T) {
+	now := time.Date(2024, 5, 10, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-05-31 08:00", time.Date(2024, 5, 31, 8, 0, 0, 0, time.UTC)},
+		{"2024-05-31T08:00:00Z", time.Date(2024, 5, 31, 8, 0, 0, 0, time.UTC)},
+		{"16:00", time.Date(2024, 5, 10, 16, 0, 0, 0, time.UTC)},
+		{"08:00", time.Date(2024, 5, 11, 8, 0, 0, 0, time.UTC)},
+		{"Tomorrow 08:00", time.Date(2024, 5, 11, 8, 0, 0, 0, time.UTC)},
+		{"2h", now.Add(2 * time.Hour)},
+		{"+1d", now.AddDate(0, 0, 1)},
+	}
+	for _, tt := range tests {
+		got, err := parseSendAt(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseSendAt(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"2024-05-01 08:00", "noon", "-2h", "tomorrow"} {
+		if _, err := parseSendAt(in, now); err == nil {
+			t.Errorf("parseSendAt(%q): expected error", in)
+		}
+	}
+}
+
+func TestFolders(t *testing.T) {
+	srv := newTestServer(t)
+	parent := srv.AddFolder("", "Projects")
+	srv.AddFolder(parent, "Alpha")
+
+	res := runCLI(t, "", "folders", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Alpha")
+
+	mustSucceed(t, runCLI(t, "", "folders", "create", "Receipts"))
+
+	res = runCLI(t, "", "folders", "delete", "Receipts")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Cancelled")
+
+	mustSucceed(t, runCLI(t, "y\n", "folders", "delete", "Receipts"))
+	for _, f := range srv.Folders() {
+		if f.DisplayName == "Receipts" {
+			t.Error("folder was not deleted")
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	srv := newTestServer(t)
+
+	res := runCLI(t, "", "rules", "create", "--name", "Newsletters", "--from-contains", "@news.com", "--move-to", "Archive", "--mark-read")
+	mustSucceed(t, res)
+
+	rules := srv.Rules()
+	if len(rules) != 1 || rules[0].Actions.MoveToFolder != "archive" {
+		t.Fatalf("rules = %+v", rules)
+	}
+
+	res = runCLI(t, "", "rules", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Newsletters")
+
+	mustSucceed(t, runCLI(t, "", "rules", "delete", rules[0].ID))
+	if len(srv.Rules()) != 0 {
+		t.Error("rule was not deleted")
+	}
+}
+
+func TestProfileBlocksCommand(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "x"})
+
+	writeProfile(t, "readonly", "enforce: true\nallow:\n  - mail.read\n")
+
+	res := runCLI(t, "", "mail", "trash", id)
+	if res.Err == nil {
+		t.Fatal("expected permission denied")
+	}
+	if m, _ := srv.Message(id); m.FolderID != "inbox" {
+		t.Error("blocked command still modified the mailbox")
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddFolder("", "Receipts")
+
+	res := runCLI(t, "", "mail", "read", "does-not-exist")
+	if code := ExitCode(res.Err); code != ExitNotFound {
+		t.Errorf("missing message exit code = %d, want %d (err: %v)", code, ExitNotFound, res.Err)
+	}
+	assertContains(t, res.Stderr, "ErrorItemNotFound")
+
+	res = runCLI(t, "", "folders", "create", "Receipts")
+	if code := ExitCode(res.Err); code != ExitConflict {
+		t.Errorf("duplicate folder exit code = %d, want %d", code, ExitConflict)
+	}
+
+	srv.InjectFault(graphtest.Fault{PathPrefix: "/me/mailFolders/inbox/messageRules", Status: 401, Times: 1})
+	res = runCLI(t, "", "rules", "list")
+	if code := ExitCode(res.Err); code != ExitAuth {
+		t.Errorf("unauthorized exit code = %d, want %d", code, ExitAuth)
+	}
+
+	writeProfile(t, "readonly", "enforce: true\nallow:\n  - mail.read\n")
+	res = runCLI(t, "", "rules", "list")
+	if code := ExitCode(res.Err); code != ExitPermission {
+		t.Errorf("permission denied exit code = %d, want %d", code, ExitPermission)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	newTestServer(t)
+
+	res := runCLI(t, "", "mail", "list", "--timeout", "1ns")
+	if code := ExitCode(res.Err); code != ExitTimeout {
+		t.Errorf("timeout exit code = %d, want %d (err: %v)", code, ExitTimeout, res.Err)
+	}
+
+	// The timeout must not carry over to the next invocation
+	mustSucceed(t, runCLI(t, "", "mail", "list"))
+}
+
+func TestJSONErrorEnvelope(t *testing.T) {
+	newTestServer(t)
+
+	res := runCLI(t, "", "mail", "list", "--folder", "Nope", "--json")
+	if res.Err == nil {
+		t.Fatal("expected error")
+	}
+
+	var envelope struct {
+		Error struct {
+			Code     string `json:"code"`
+			Message  string `json:"message"`
+			ExitCode int    `json:"exit_code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(res.Stdout), &envelope); err != nil {
+		t.Fatalf("stdout is not a JSON envelope: %v\n%s", err, res.Stdout)
+	}
+	if envelope.Error.Code != "NotFound" || envelope.Error.ExitCode != ExitNotFound {
+		t.Errorf("envelope = %+v", envelope.Error)
+	}
+	if res.Stderr != "" && strings.Contains(res.Stderr, "Error:") {
+		t.Errorf("plain error printed despite --json: %s", res.Stderr)
+	}
+}
+
+func TestMailFlagAndCategorize(t *testing.T) {
+	srv := newTestServer(t)
+	a := srv.AddMessage(graphtest.Message{Subject: "Invoice", From: "billing@example.com", Categories: []string{"Todo"}})
+	b := srv.AddMessage(graphtest.Message{Subject: "Lunch", From: "bob@example.com"})
+
+	res := runCLI(t, "", "mail", "flag", a, "--due", "2030-01-15")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "1 email(s) flagged, due 2030-01-15")
+
+	res = runCLI(t, "", "mail", "categorize", a, b, "--add", "Finance", "--remove", "todo")
+	mustSucceed(t, res)
+	if m, _ := srv.Message(a); len(m.Categories) != 1 || m.Categories[0] != "Finance" {
+		t.Errorf("categories = %v, want [Finance]", m.Categories)
+	}
+
+	res = runCLI(t, "", "mail", "list", "--flagged")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "⚑ Invoice [Finance]")
+	assertContains(t, res.Stdout, "1 emails shown")
+
+	res = runCLI(t, "", "mail", "list", "--category", "finance")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "2 emails shown")
+
+	res = runCLI(t, "", "mail", "read", a)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Flag:    flagged, due Tue, 15 Jan 2030")
+	assertContains(t, res.Stdout, "Categories: Finance")
+
+	res = runCLI(t, "", "mail", "unflag", a)
+	mustSucceed(t, res)
+	if m, _ := srv.Message(a); m.Flagged {
+		t.Error("message still flagged")
+	}
+
+	if res := runCLI(t, "", "mail", "categorize", a); res.Err == nil {
+		t.Error("expected error without --add, --remove or --clear")
+	}
+}
+
+func TestCategories(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddCategory(mail.Category{DisplayName: "Finance", Color: "preset4"})
+
+	res := runCLI(t, "", "categories", "create", "Project Alpha", "--color", "blue")
+	mustSucceed(t, res)
+
+	res = runCLI(t, "", "categories", "update", "project alpha", "--color", "purple")
+	mustSucceed(t, res)
+
+	res = runCLI(t, "", "categories", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Finance")
+	assertContains(t, res.Stdout, "green")
+	assertContains(t, res.Stdout, "purple")
+
+	res = runCLI(t, "", "categories", "delete", "Finance")
+	mustSucceed(t, res)
+	if got := srv.Categories(); len(got) != 1 || got[0].DisplayName != "Project Alpha" {
+		t.Errorf("categories = %+v", got)
+	}
+
+	if res := runCLI(t, "", "categories", "create", "X", "--color", "mauve"); res.Err == nil {
+		t.Error("expected error for unknown color")
+	}
+}
+
+func TestParseDueDate(t *testing.T) {
+	now := time.Date(2024, 5, 30, 15, 4, 0, 0, time.UTC)
+	for in, want := range map[string]string{
+		"today":      "2024-05-30",
+		"tomorrow":   "2024-05-31",
+		"3d":         "2024-06-02",
+		"2024-07-01": "2024-07-01",
+	} {
+		got, err := parseDueDate(in, now)
+		if err != nil || got.Format("2006-01-02") != want {
+			t.Errorf("parseDueDate(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	if _, err := parseDueDate("next week", now); err == nil {
+		t.Error("expected error for unsupported value")
+	}
+}
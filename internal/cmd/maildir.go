@@ -0,0 +1,571 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/maildir"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+	"github.com/yourname/o365-mail-cli/internal/syncstate"
+)
+
+// Maildir Sync Command
+var syncMaildirPrefer string
+
+var syncMaildirCmd = &cobra.Command{
+	Use:   "maildir <path>",
+	Short: "Synchronize all folders with a local Maildir tree",
+	Long: `Mirrors every mail folder into a Maildir below <path> and keeps both
+sides in sync, so mail clients like mutt or aerc can work on the local copy.
+
+Each folder becomes a Maildir at its folder path, e.g. <path>/Inbox and
+<path>/Inbox/Projects. New emails are downloaded in full MIME format.
+Changes made on either side since the last run are applied to the other:
+  - read state (Maildir flag S) and follow-up flag (F)
+  - moves between folders
+  - trashing: the T flag or deleting a file moves the email to Deleted Items
+    (deleting a file that is already in Deleted Items only forgets it locally)
+
+The sync state is kept in ` + syncstate.MaildirStateFile + ` in <path>.
+If an email was moved to different folders on both sides, --prefer decides
+which side wins. Files added to the Maildir by other programs are left alone.
+
+Examples:
+  o365-mail-cli mail sync maildir ~/Mail/work
+  o365-mail-cli mail sync maildir ~/Mail/work --prefer local`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.sync"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runSyncMaildir,
+}
+
+func init() {
+	syncMaildirCmd.Flags().StringVar(&syncMaildirPrefer, "prefer", "remote", "Side that wins conflicting moves: remote or local")
+
+	syncCmd.AddCommand(syncMaildirCmd)
+}
+
+// maildirSync is one run of `mail sync maildir`
+type maildirSync struct {
+	ctx         context.Context
+	client      *mail.GraphClient
+	state       *syncstate.MaildirState
+	preferLocal bool
+	trashID     string
+
+	// dirs and names map folder IDs to Maildir paths and folder names
+	dirs  map[string]string
+	names map[string]string
+	// local holds the Maildir messages by key
+	local map[string]localMessage
+	// remote holds messages reported by delta queries, removed the IDs
+	// reported as removed or missing from a full sync
+	remote     map[string]*remoteMessage
+	removed    map[string]bool
+	deltaLinks map[string]string
+
+	downloaded, changedLocal, pushed, conflicts int
+}
+
+type localMessage struct {
+	maildir.Message
+	folderID string
+}
+
+type remoteMessage struct {
+	mail.MessageChange
+	folderID string
+	// claimed is set once the message was matched to a local one
+	claimed bool
+}
+
+func runSyncMaildir(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	root := args[0]
+
+	if syncMaildirPrefer != "remote" && syncMaildirPrefer != "local" {
+		return fmt.Errorf("invalid --prefer value %q (use remote or local)", syncMaildirPrefer)
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return fmt.Errorf("failed to create maildir: %w", err)
+	}
+
+	state, err := syncstate.LoadMaildir(root)
+	if err != nil {
+		return err
+	}
+	account := getActiveAccount()
+	if state.Account != "" && state.Account != account {
+		return fmt.Errorf("maildir %s is synced with account %s, not %s", root, state.Account, account)
+	}
+	state.Account = account
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	s := &maildirSync{
+		ctx:         ctx,
+		client:      client,
+		state:       state,
+		preferLocal: syncMaildirPrefer == "local",
+		dirs:        map[string]string{},
+		names:       map[string]string{},
+		local:       map[string]localMessage{},
+		remote:      map[string]*remoteMessage{},
+		removed:     map[string]bool{},
+		deltaLinks:  map[string]string{},
+	}
+
+	if err := s.mapFolders(root); err != nil {
+		return err
+	}
+	if err := s.scanLocal(); err != nil {
+		return err
+	}
+	if err := s.fetchRemote(); err != nil {
+		return err
+	}
+
+	// On failure the finished work is saved, but not the new delta links:
+	// the next run sees the same remote changes again and picks up from there
+	if err := s.reconcile(); err != nil {
+		return errors.Join(err, state.Save())
+	}
+	if err := s.download(); err != nil {
+		return errors.Join(err, state.Save())
+	}
+
+	for id, link := range s.deltaLinks {
+		state.Folders[id].DeltaLink = link
+	}
+	if err := state.Save(); err != nil {
+		return err
+	}
+
+	if s.downloaded+s.changedLocal+s.pushed == 0 {
+		printInfo("Maildir %s is up to date", root)
+		return nil
+	}
+	printSuccess("Synced %s: %d downloaded, %d local change(s), %d change(s) pushed to Graph", root, s.downloaded, s.changedLocal, s.pushed)
+	if s.conflicts > 0 {
+		printInfo("%d conflict(s) resolved in favor of the %s side", s.conflicts, syncMaildirPrefer)
+	}
+	return nil
+}
+
+// mapFolders assigns a Maildir to every mail folder, following folder renames
+// and forgetting folders that no longer exist
+func (s *maildirSync) mapFolders(root string) error {
+	folders, err := s.client.ListFolders(s.ctx)
+	if err != nil {
+		return err
+	}
+	trash, err := s.client.GetFolder(s.ctx, "deleteditems")
+	if err != nil {
+		return err
+	}
+	s.trashID = trash.ID
+
+	for _, f := range folders {
+		path := maildirFolderPath(f.Name)
+		dir := filepath.Join(root, filepath.FromSlash(path))
+
+		st := s.state.Folders[f.ID]
+		if st == nil {
+			st = &syncstate.MaildirFolder{Path: path}
+			s.state.Folders[f.ID] = st
+		}
+		if st.Path != path {
+			old := filepath.Join(root, filepath.FromSlash(st.Path))
+			if _, err := os.Stat(dir); os.IsNotExist(err) {
+				if _, err := os.Stat(old); err == nil {
+					if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+						return fmt.Errorf("failed to rename folder: %w", err)
+					}
+					if err := os.Rename(old, dir); err != nil {
+						return fmt.Errorf("failed to rename folder: %w", err)
+					}
+					fmt.Printf("↓ renamed  %s → %s\n", st.Path, path)
+				}
+			}
+			st.Path = path
+		}
+
+		if err := maildir.Create(dir); err != nil {
+			return err
+		}
+		s.dirs[f.ID] = dir
+		s.names[f.ID] = f.Name
+	}
+
+	for id := range s.state.Folders {
+		if _, ok := s.dirs[id]; !ok {
+			debugLog("Folder %s no longer exists", s.state.Folders[id].Path)
+			delete(s.state.Folders, id)
+		}
+	}
+	for key, msg := range s.state.Messages {
+		if _, ok := s.dirs[msg.FolderID]; !ok {
+			delete(s.state.Messages, key)
+		}
+	}
+	return nil
+}
+
+// scanLocal lists the messages of all mirrored folders
+func (s *maildirSync) scanLocal() error {
+	unknown := 0
+	for id, dir := range s.dirs {
+		messages, err := maildir.List(dir)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			s.local[m.Key] = localMessage{Message: m, folderID: id}
+			if s.state.Messages[m.Key] == nil {
+				unknown++
+			}
+		}
+	}
+	debugLog("Found %d local message(s), %d not known to the sync state", len(s.local), unknown)
+	return nil
+}
+
+// fetchRemote runs a delta query for every folder
+func (s *maildirSync) fetchRemote() error {
+	for _, id := range sortedKeys(s.dirs) {
+		st := s.state.Folders[id]
+
+		full := st.DeltaLink == ""
+		result, err := s.client.MessagesDelta(s.ctx, id, st.DeltaLink)
+		if errors.Is(err, mail.ErrSyncReset) {
+			debugLog("Sync state of %s expired, starting full sync: %v", st.Path, err)
+			full = true
+			result, err = s.client.MessagesDelta(s.ctx, id, "")
+		}
+		if err != nil {
+			return err
+		}
+		s.deltaLinks[id] = result.DeltaLink
+
+		listed := map[string]bool{}
+		for _, c := range result.Changes {
+			if c.Removed {
+				s.removed[c.ID] = true
+				continue
+			}
+			listed[c.ID] = true
+			s.remote[c.ID] = &remoteMessage{MessageChange: c, folderID: id}
+		}
+
+		// A full sync lists every message, so anything known but not listed is gone
+		if full {
+			for _, msg := range s.state.Messages {
+				if msg.FolderID == id && !listed[msg.ID] {
+					s.removed[msg.ID] = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reconcile applies the changes to messages known from the last sync
+func (s *maildirSync) reconcile() error {
+	known := s.state.ByID()
+	moved := map[string][]*remoteMessage{}
+	for id, rem := range s.remote {
+		if _, ok := known[id]; !ok && rem.InternetMessageID != "" {
+			moved[rem.InternetMessageID] = append(moved[rem.InternetMessageID], rem)
+		}
+	}
+
+	for _, key := range sortedKeys(s.state.Messages) {
+		entry := s.state.Messages[key]
+		loc, hasLocal := s.local[key]
+		rem := s.remote[entry.ID]
+
+		// Graph assigns new IDs on moves, so a removal may be a move into another folder
+		if rem == nil && s.removed[entry.ID] {
+			for _, candidate := range moved[entry.InternetMessageID] {
+				if !candidate.claimed {
+					candidate.claimed = true
+					rem = candidate
+					entry.ID = candidate.ID
+					break
+				}
+			}
+		}
+
+		var err error
+		switch {
+		case rem == nil && s.removed[entry.ID]:
+			err = s.removeLocal(key, loc, hasLocal)
+		case !hasLocal:
+			err = s.trashRemote(key, rem)
+		default:
+			err = s.merge(entry, loc, rem)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeLocal drops a message deleted on Graph
+func (s *maildirSync) removeLocal(key string, loc localMessage, hasLocal bool) error {
+	if hasLocal {
+		if err := maildir.Remove(loc.Message); err != nil {
+			return err
+		}
+		fmt.Printf("↓ removed  %s: %s\n", s.names[loc.folderID], s.state.Messages[key].Subject)
+		s.changedLocal++
+	}
+	delete(s.state.Messages, key)
+	return nil
+}
+
+// trashRemote moves a message deleted from the Maildir to Deleted Items
+func (s *maildirSync) trashRemote(key string, rem *remoteMessage) error {
+	entry := s.state.Messages[key]
+	folderID := entry.FolderID
+	if rem != nil {
+		folderID = rem.folderID
+	}
+
+	if folderID != s.trashID {
+		if _, err := s.client.MoveMessage(s.ctx, entry.ID, s.trashID); err != nil && !errors.Is(err, mail.ErrNotFound) {
+			return fmt.Errorf("failed to trash %s: %w", entry.ID, err)
+		}
+		fmt.Printf("↑ trashed  %s: %s\n", s.names[folderID], entry.Subject)
+		s.pushed++
+	}
+	delete(s.state.Messages, key)
+	return nil
+}
+
+// merge reconciles folder and flags of a message present on both sides.
+// A side that changed since the last sync wins over one that did not;
+// conflicting moves are decided by --prefer.
+func (s *maildirSync) merge(entry *syncstate.MaildirMessage, loc localMessage, rem *remoteMessage) error {
+	remoteFolder, remoteFlags := entry.FolderID, entry.Flags
+	if rem != nil {
+		remoteFolder, remoteFlags = rem.folderID, graphMaildirFlags(rem.Email)
+	}
+	localFolder, localFlags := loc.folderID, loc.Flags
+	if strings.ContainsRune(localFlags, 'T') {
+		localFolder, localFlags = s.trashID, strings.ReplaceAll(localFlags, "T", "")
+	}
+	if rem != nil {
+		entry.Subject = rem.Email.Subject
+	}
+
+	target := entry.FolderID
+	localMoved, remoteMoved := localFolder != entry.FolderID, remoteFolder != entry.FolderID
+	switch {
+	case localMoved && remoteMoved && localFolder != remoteFolder:
+		s.conflicts++
+		target = remoteFolder
+		if s.preferLocal {
+			target = localFolder
+		}
+		fmt.Printf("! conflict %s: moved to %s locally and to %s on Graph, keeping %s\n",
+			entry.Subject, s.names[localFolder], s.names[remoteFolder], s.names[target])
+	case localMoved:
+		target = localFolder
+	case remoteMoved:
+		target = remoteFolder
+	}
+
+	if target != remoteFolder {
+		newID, err := s.client.MoveMessage(s.ctx, entry.ID, target)
+		if err != nil {
+			return fmt.Errorf("failed to move %s: %w", entry.ID, err)
+		}
+		entry.ID, entry.FolderID = newID, target
+		fmt.Printf("↑ moved    %s → %s: %s\n", s.names[remoteFolder], s.names[target], entry.Subject)
+		s.pushed++
+	}
+	if target != loc.folderID {
+		m, err := maildir.Move(loc.Message, s.dirs[target])
+		if err != nil {
+			return err
+		}
+		// A local move to Deleted Items via the T flag is not a change from Graph
+		if target != localFolder {
+			fmt.Printf("↓ moved    %s → %s: %s\n", s.names[loc.folderID], s.names[target], entry.Subject)
+			s.changedLocal++
+		}
+		loc.Message, loc.folderID = m, target
+	}
+	entry.FolderID = target
+
+	flags := localFlags
+	flagsChanged := false
+	for _, flag := range "SF" {
+		base := strings.ContainsRune(entry.Flags, flag)
+		local := strings.ContainsRune(localFlags, flag)
+		remote := strings.ContainsRune(remoteFlags, flag)
+
+		want := base
+		if local != base {
+			want = local
+		} else if remote != base {
+			want = remote
+		}
+
+		if want != remote {
+			if err := s.pushFlag(entry.ID, flag, want); err != nil {
+				return err
+			}
+			fmt.Printf("↑ %-8s %s: %s\n", maildirFlagName(flag, want), s.names[target], entry.Subject)
+			s.pushed++
+		}
+		if want != local {
+			flagsChanged = true
+		}
+		flags = strings.ReplaceAll(flags, string(flag), "")
+		if want {
+			flags += string(flag)
+		}
+	}
+
+	if flags != loc.Flags {
+		if _, err := maildir.SetFlags(loc.Message, flags); err != nil {
+			return err
+		}
+	}
+	if flagsChanged {
+		fmt.Printf("↓ flags    %s: %s\n", s.names[target], entry.Subject)
+		s.changedLocal++
+	}
+	entry.Flags = syncedMaildirFlags(flags)
+	return nil
+}
+
+func (s *maildirSync) pushFlag(messageID string, flag rune, set bool) error {
+	var err error
+	switch {
+	case flag == 'S' && set:
+		err = s.client.MarkAsRead(s.ctx, "", messageID)
+	case flag == 'S':
+		err = s.client.MarkAsUnread(s.ctx, "", messageID)
+	default:
+		err = s.client.SetFlag(s.ctx, messageID, set)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", messageID, err)
+	}
+	return nil
+}
+
+// download fetches messages that are new on Graph
+func (s *maildirSync) download() error {
+	known := s.state.ByID()
+	var pending []*remoteMessage
+	for id, rem := range s.remote {
+		if _, ok := known[id]; !ok && !rem.claimed {
+			pending = append(pending, rem)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].folderID != pending[j].folderID {
+			return s.names[pending[i].folderID] < s.names[pending[j].folderID]
+		}
+		return pending[i].Email.Date.Before(pending[j].Email.Date)
+	})
+
+	for _, rem := range pending {
+		content, err := s.client.GetMIME(s.ctx, rem.ID)
+		if errors.Is(err, mail.ErrNotFound) {
+			// Deleted since the delta query ran
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", rem.ID, err)
+		}
+
+		flags := graphMaildirFlags(rem.Email)
+		key := maildir.NewKey()
+		if _, err := maildir.Deliver(s.dirs[rem.folderID], key, flags, content); err != nil {
+			return err
+		}
+		s.state.Messages[key] = &syncstate.MaildirMessage{
+			ID:                rem.ID,
+			FolderID:          rem.folderID,
+			InternetMessageID: rem.InternetMessageID,
+			Subject:           rem.Email.Subject,
+			Flags:             flags,
+		}
+		fmt.Printf("↓ new      %s: %s\n", s.names[rem.folderID], rem.Email.Subject)
+		s.downloaded++
+	}
+	return nil
+}
+
+// maildirFolderPath turns a folder path like "Inbox/Projects" into a relative
+// Maildir path, keeping clear of the cur/new/tmp subdirectories
+func maildirFolderPath(name string) string {
+	segs := strings.Split(name, "/")
+	for i, seg := range segs {
+		seg = strings.ReplaceAll(seg, `\`, "_")
+		if seg == "cur" || seg == "new" || seg == "tmp" || strings.Trim(seg, ".") == "" {
+			seg = "_" + seg
+		}
+		segs[i] = seg
+	}
+	return strings.Join(segs, "/")
+}
+
+// graphMaildirFlags returns the Maildir flags of a Graph message
+func graphMaildirFlags(email *mail.Email) string {
+	flags := ""
+	if email.Flagged {
+		flags += "F"
+	}
+	if !email.Unread {
+		flags += "S"
+	}
+	return flags
+}
+
+// syncedMaildirFlags keeps the flags that are synced with Graph
+func syncedMaildirFlags(flags string) string {
+	synced := ""
+	for _, flag := range "FS" {
+		if strings.ContainsRune(flags, flag) {
+			synced += string(flag)
+		}
+	}
+	return synced
+}
+
+func maildirFlagName(flag rune, set bool) string {
+	switch {
+	case flag == 'S' && set:
+		return "read"
+	case flag == 'S':
+		return "unread"
+	case set:
+		return "flagged"
+	}
+	return "unflagged"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
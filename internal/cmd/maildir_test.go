@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+	"github.com/yourname/o365-mail-cli/internal/maildir"
+)
+
+// listMaildir returns the messages of a folder Maildir by subject
+func listMaildir(t *testing.T, dir string) map[string]maildir.Message {
+	t.Helper()
+	messages, err := maildir.List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bySubject := map[string]maildir.Message{}
+	for _, m := range messages {
+		data, err := os.ReadFile(m.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bySubject[parseMIMEInfo(data).subject] = m
+	}
+	return bySubject
+}
+
+func TestSyncMaildir_Download(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddMessage(graphtest.Message{Subject: "Unread", From: "a@example.com"})
+	srv.AddMessage(graphtest.Message{Subject: "Read and flagged", From: "a@example.com", IsRead: true, Flagged: true})
+	projects := srv.AddFolder("inbox", "Projects")
+	srv.AddMessage(graphtest.Message{Subject: "Alpha", FolderID: projects})
+	root := t.TempDir()
+
+	res := runCLI(t, "", "mail", "sync", "maildir", root)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "↓ new      Inbox: Unread")
+	assertContains(t, res.Stdout, "3 downloaded")
+
+	inbox := listMaildir(t, filepath.Join(root, "Inbox"))
+	if m := inbox["Unread"]; filepath.Base(filepath.Dir(m.Path)) != "new" || m.Flags != "" {
+		t.Errorf("unread message = %+v, want in new/ without flags", m)
+	}
+	if m := inbox["Read and flagged"]; m.Flags != "FS" {
+		t.Errorf("read message flags = %q, want FS", m.Flags)
+	}
+	if len(listMaildir(t, filepath.Join(root, "Inbox", "Projects"))) != 1 {
+		t.Error("subfolder not mirrored below its parent")
+	}
+
+	res = runCLI(t, "", "mail", "sync", "maildir", root)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "is up to date")
+}
+
+func TestSyncMaildir_PushLocalChanges(t *testing.T) {
+	srv := newTestServer(t)
+	srv.NewIDOnMove = true
+	ids := seedMessages(srv, "a@example.com", 4)
+	root := t.TempDir()
+	mustSucceed(t, runCLI(t, "", "mail", "sync", "maildir", root))
+
+	inboxDir, archiveDir := filepath.Join(root, "Inbox"), filepath.Join(root, "Archive")
+	inbox := listMaildir(t, inboxDir)
+	maildir.SetFlags(inbox["a@example.com #0"], "FS")
+	maildir.Move(inbox["a@example.com #1"], archiveDir)
+	maildir.Remove(inbox["a@example.com #2"])
+	maildir.SetFlags(inbox["a@example.com #3"], "T")
+
+	res := runCLI(t, "", "mail", "sync", "maildir", root)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "↑ read     Inbox: a@example.com #0")
+	assertContains(t, res.Stdout, "↑ flagged  Inbox: a@example.com #0")
+	assertContains(t, res.Stdout, "↑ moved    Inbox → Archive: a@example.com #1")
+	assertContains(t, res.Stdout, "↑ trashed  Inbox: a@example.com #2")
+	assertContains(t, res.Stdout, "↑ moved    Inbox → Deleted Items: a@example.com #3")
+
+	if m, _ := srv.Message(ids[0]); !m.IsRead || !m.Flagged {
+		t.Errorf("message #0 = %+v, want read and flagged", m)
+	}
+	folders := map[string]string{}
+	for _, m := range srv.Messages("") {
+		folders[m.Subject] = m.FolderID
+	}
+	want := map[string]string{"a@example.com #1": "archive", "a@example.com #2": "deleteditems", "a@example.com #3": "deleteditems"}
+	for subject, folder := range want {
+		if folders[subject] != folder {
+			t.Errorf("%s is in %q, want %q", subject, folders[subject], folder)
+		}
+	}
+	if m := listMaildir(t, filepath.Join(root, "Deleted Items"))["a@example.com #3"]; m.Flags != "" {
+		t.Errorf("trashed message = %+v, want moved to Deleted Items without the T flag", m)
+	}
+
+	// The moves gave the messages new IDs; the next run must recognize them
+	// instead of downloading copies or removing the local files
+	res = runCLI(t, "", "mail", "sync", "maildir", root)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "↓ new      Deleted Items: a@example.com #2")
+	if len(listMaildir(t, archiveDir)) != 1 || len(listMaildir(t, filepath.Join(root, "Deleted Items"))) != 2 {
+		t.Errorf("unexpected local state after resync:\n%s", res.Stdout)
+	}
+	assertContains(t, runCLI(t, "", "mail", "sync", "maildir", root).Stdout, "is up to date")
+}
+
+func TestSyncMaildir_PullRemoteChanges(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 3)
+	root := t.TempDir()
+	mustSucceed(t, runCLI(t, "", "mail", "sync", "maildir", root))
+
+	mustSucceed(t, runCLI(t, "", "mail", "mark-read", ids[0]))
+	mustSucceed(t, runCLI(t, "", "mail", "move", ids[1], "--to", "archive"))
+	if err := srv.Client().DeleteDraft(context.Background(), ids[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	res := runCLI(t, "", "mail", "sync", "maildir", root)
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "↓ flags    Inbox: a@example.com #0")
+	assertContains(t, res.Stdout, "↓ moved    Inbox → Archive: a@example.com #1")
+	assertContains(t, res.Stdout, "↓ removed  Inbox: a@example.com #2")
+
+	inbox := listMaildir(t, filepath.Join(root, "Inbox"))
+	if len(inbox) != 1 || inbox["a@example.com #0"].Flags != "S" {
+		t.Errorf("inbox = %+v, want only #0 marked seen", inbox)
+	}
+	if _, ok := listMaildir(t, filepath.Join(root, "Archive"))["a@example.com #1"]; !ok {
+		t.Error("#1 not moved to the Archive maildir")
+	}
+}
+
+func TestSyncMaildir_Conflict(t *testing.T) {
+	for _, prefer := range []string{"remote", "local"} {
+		t.Run(prefer, func(t *testing.T) {
+			srv := newTestServer(t)
+			ids := seedMessages(srv, "a@example.com", 1)
+			root := t.TempDir()
+			mustSucceed(t, runCLI(t, "", "mail", "sync", "maildir", root))
+
+			msg := listMaildir(t, filepath.Join(root, "Inbox"))["a@example.com #0"]
+			maildir.Move(msg, filepath.Join(root, "Archive"))
+			mustSucceed(t, runCLI(t, "", "mail", "move", ids[0], "--to", "junkemail"))
+
+			res := runCLI(t, "", "mail", "sync", "maildir", root, "--prefer", prefer)
+			mustSucceed(t, res)
+			assertContains(t, res.Stdout, "! conflict a@example.com #0: moved to Archive locally and to Junk Email on Graph")
+
+			want, wantDir := "junkemail", "Junk Email"
+			if prefer == "local" {
+				want, wantDir = "archive", "Archive"
+			}
+			if m, _ := srv.Message(ids[0]); m.FolderID != want {
+				t.Errorf("message is in %s on Graph, want %s", m.FolderID, want)
+			}
+			if len(listMaildir(t, filepath.Join(root, wantDir))) != 1 {
+				t.Errorf("message not in the %s maildir", wantDir)
+			}
+		})
+	}
+}
+
+func TestSyncMaildir_AccountMismatch(t *testing.T) {
+	newTestServer(t)
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".o365-mail-cli-sync.json"), []byte(`{"account":"other@example.com"}`), 0600)
+
+	res := runCLI(t, "", "mail", "sync", "maildir", root)
+	if res.Err == nil {
+		t.Fatal("expected an error for a maildir synced with another account")
+	}
+	assertContains(t, res.Err.Error(), "synced with account other@example.com")
+}
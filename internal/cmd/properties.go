@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+// messageProperties are the importance, sensitivity, receipt and reply-to
+// options shared by the commands that compose new messages
+type messageProperties struct {
+	importance      string
+	sensitivity     string
+	readReceipt     bool
+	deliveryReceipt bool
+	replyTo         []string
+}
+
+var (
+	sendProperties  messageProperties
+	draftProperties messageProperties
+)
+
+// addFlags registers the property flags on a compose command
+func (p *messageProperties) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&p.importance, "importance", "", "Importance ("+strings.Join(mail.Importances, "/")+")")
+	cmd.Flags().StringVar(&p.sensitivity, "sensitivity", "", "Sensitivity ("+strings.Join(mail.Sensitivities, "/")+")")
+	cmd.Flags().BoolVar(&p.readReceipt, "read-receipt", false, "Request a read receipt")
+	cmd.Flags().BoolVar(&p.deliveryReceipt, "delivery-receipt", false, "Request a delivery receipt")
+	cmd.Flags().StringArrayVar(&p.replyTo, "reply-to", nil, "Address replies should go to (can be specified multiple times)")
+}
+
+// apply validates the properties and sets them on opts
+func (p *messageProperties) apply(opts *mail.SendOptions) error {
+	if p.importance != "" {
+		importance, err := mail.ParseImportance(p.importance)
+		if err != nil {
+			return fmt.Errorf("invalid --importance value: %w", err)
+		}
+		opts.Importance = importance
+	}
+	if p.sensitivity != "" {
+		sensitivity, err := mail.ParseSensitivity(p.sensitivity)
+		if err != nil {
+			return fmt.Errorf("invalid --sensitivity value: %w", err)
+		}
+		opts.Sensitivity = sensitivity
+	}
+	opts.ReadReceipt = p.readReceipt
+	opts.DeliveryReceipt = p.deliveryReceipt
+	opts.ReplyTo = p.replyTo
+	return nil
+}
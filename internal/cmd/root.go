@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/yourname/o365-mail-cli/internal/config"
@@ -15,7 +19,11 @@ var (
 	debug         bool
 	accountFlag   string
 	profileFlag   string
+	timeoutFlag   time.Duration
 	activeProfile *profile.Profile
+
+	// cancelTimeout releases the --timeout context once the command finished
+	cancelTimeout context.CancelFunc
 )
 
 // rootCmd is the base command
@@ -36,12 +44,13 @@ Examples:
 
   # Send email
   o365-mail-cli mail send --to recipient@example.com --subject "Test" --body "Hello!"`,
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
 		var err error
 		cfg, err = config.Load()
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return withExitCode(ExitConfig, "ConfigError", fmt.Errorf("failed to load config: %w", err))
 		}
 
 		if debug {
@@ -51,20 +60,39 @@ Examples:
 		// Resolve and check permission profile
 		activeProfile, err = profile.ResolveProfile(profileFlag)
 		if err != nil {
-			return fmt.Errorf("failed to load profile: %w", err)
+			return withExitCode(ExitConfig, "ConfigError", fmt.Errorf("failed to load profile: %w", err))
 		}
 
 		if err := profile.CheckCommand(activeProfile, cmd); err != nil {
 			return err
 		}
 
+		if timeoutFlag > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeoutFlag)
+			cmd.SetContext(ctx)
+			cancelTimeout = cancel
+		}
+
 		return nil
 	},
 }
 
-// Execute runs the root command
+// Execute runs the root command.
+// Ctrl-C / SIGTERM cancel the command context so in-flight Graph requests abort.
+// Errors are reported here (plain or as JSON envelope); use ExitCode to map them to an exit status.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if cancelTimeout != nil {
+		cancelTimeout()
+		cancelTimeout = nil
+	}
+	if err != nil {
+		reportError(cmd, err)
+	}
+	return err
 }
 
 func init() {
@@ -73,12 +101,15 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output")
 	rootCmd.PersistentFlags().StringVar(&accountFlag, "account", "", "Account to use (email address)")
 	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Permission profile to use")
+	rootCmd.PersistentFlags().DurationVar(&timeoutFlag, "timeout", 0, "Abort the command after this duration (e.g. 30s, 5m; 0 = no limit)")
 
 	// Add subcommands
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(mailCmd)
 	rootCmd.AddCommand(foldersCmd)
 	rootCmd.AddCommand(rulesCmd)
+	rootCmd.AddCommand(categoriesCmd)
+	rootCmd.AddCommand(templatesCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
 }
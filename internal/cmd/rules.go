@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -201,7 +200,7 @@ func init() {
 }
 
 func runRulesList(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
@@ -210,7 +209,7 @@ func runRulesList(cmd *cobra.Command, args []string) error {
 
 	debugLog("Fetching inbox rules via Graph API")
 
-	rules, err := client.ListRules()
+	rules, err := client.ListRules(ctx)
 	if err != nil {
 		return err
 	}
@@ -263,7 +262,7 @@ func runRulesList(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesGet(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ruleID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -271,7 +270,7 @@ func runRulesGet(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	rule, err := client.GetRule(ruleID)
+	rule, err := client.GetRule(ctx, ruleID)
 	if err != nil {
 		return err
 	}
@@ -309,7 +308,7 @@ func runRulesGet(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesCreate(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	client, err := getGraphClient(ctx)
 	if err != nil {
@@ -386,7 +385,7 @@ func runRulesCreate(cmd *cobra.Command, args []string) error {
 
 		if createMoveToFolder != "" {
 			// Resolve folder name to ID
-			folderID, err := client.GetFolderByName(createMoveToFolder)
+			folderID, err := client.GetFolderByName(ctx, createMoveToFolder)
 			if err != nil {
 				return fmt.Errorf("failed to resolve folder '%s': %w", createMoveToFolder, err)
 			}
@@ -394,7 +393,7 @@ func runRulesCreate(cmd *cobra.Command, args []string) error {
 			hasActions = true
 		}
 		if createCopyToFolder != "" {
-			folderID, err := client.GetFolderByName(createCopyToFolder)
+			folderID, err := client.GetFolderByName(ctx, createCopyToFolder)
 			if err != nil {
 				return fmt.Errorf("failed to resolve folder '%s': %w", createCopyToFolder, err)
 			}
@@ -437,7 +436,7 @@ func runRulesCreate(cmd *cobra.Command, args []string) error {
 
 	debugLog("Creating inbox rule via Graph API")
 
-	created, err := client.CreateRule(rule)
+	created, err := client.CreateRule(ctx, rule)
 	if err != nil {
 		return err
 	}
@@ -454,7 +453,7 @@ func runRulesCreate(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesUpdate(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ruleID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -481,7 +480,7 @@ func runRulesUpdate(cmd *cobra.Command, args []string) error {
 
 	debugLog("Updating inbox rule via Graph API")
 
-	updated, err := client.UpdateRule(ruleID, updates)
+	updated, err := client.UpdateRule(ctx, ruleID, updates)
 	if err != nil {
 		return err
 	}
@@ -497,7 +496,7 @@ func runRulesUpdate(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesDelete(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ruleID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -507,7 +506,7 @@ func runRulesDelete(cmd *cobra.Command, args []string) error {
 
 	debugLog("Deleting inbox rule via Graph API")
 
-	if err := client.DeleteRule(ruleID); err != nil {
+	if err := client.DeleteRule(ctx, ruleID); err != nil {
 		return err
 	}
 
@@ -516,7 +515,7 @@ func runRulesDelete(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesEnable(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ruleID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -526,7 +525,7 @@ func runRulesEnable(cmd *cobra.Command, args []string) error {
 
 	debugLog("Enabling inbox rule via Graph API")
 
-	rule, err := client.EnableRule(ruleID)
+	rule, err := client.EnableRule(ctx, ruleID)
 	if err != nil {
 		return err
 	}
@@ -536,7 +535,7 @@ func runRulesEnable(cmd *cobra.Command, args []string) error {
 }
 
 func runRulesDisable(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 	ruleID := args[0]
 
 	client, err := getGraphClient(ctx)
@@ -546,7 +545,7 @@ func runRulesDisable(cmd *cobra.Command, args []string) error {
 
 	debugLog("Disabling inbox rule via Graph API")
 
-	rule, err := client.DisableRule(ruleID)
+	rule, err := client.DisableRule(ctx, ruleID)
 	if err != nil {
 		return err
 	}
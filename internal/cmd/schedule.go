@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Scheduled drafts command
+var draftScheduledJSON bool
+
+var draftScheduledCmd = &cobra.Command{
+	Use:   "scheduled",
+	Short: "List scheduled emails",
+	Long: `Lists emails sent with --send-at that are waiting in the Outbox
+for their send time, the next one first.
+
+Examples:
+  o365-mail-cli mail drafts scheduled
+  o365-mail-cli mail drafts scheduled --json
+  o365-mail-cli mail drafts scheduled cancel AAMkAGI2...`,
+	Annotations: map[string]string{profile.AnnotationKey: "drafts.list"},
+	RunE:        runDraftScheduled,
+}
+
+var draftScheduledCancelCmd = &cobra.Command{
+	Use:   "cancel [message-id...]",
+	Short: "Cancel scheduled emails",
+	Long: `Cancels the delivery of scheduled emails by moving them back to the
+Drafts folder. Send them again with 'drafts send' or remove them with
+'drafts delete'.
+
+Examples:
+  o365-mail-cli mail drafts scheduled cancel AAMkAGI2...`,
+	Annotations: map[string]string{profile.AnnotationKey: "drafts.send"},
+	Args:        cobra.MinimumNArgs(1),
+	RunE:        runDraftScheduledCancel,
+}
+
+func init() {
+	draftScheduledCmd.Flags().BoolVar(&draftScheduledJSON, "json", false, "Output as JSON")
+
+	draftScheduledCmd.AddCommand(draftScheduledCancelCmd)
+	draftsCmd.AddCommand(draftScheduledCmd)
+}
+
+func runDraftScheduled(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	debugLog("Fetching scheduled emails via Graph API")
+
+	scheduled, err := client.ListScheduled(ctx)
+	if err != nil {
+		return err
+	}
+
+	if draftScheduledJSON {
+		return outputJSON(scheduled)
+	}
+
+	if len(scheduled) == 0 {
+		printInfo("No scheduled emails.")
+		return nil
+	}
+
+	fmt.Printf("\n%-50s %-20s %-25s %s\n", "ID", "Send at", "To", "Subject")
+	fmt.Println(strings.Repeat("-", 120))
+
+	for _, email := range scheduled {
+		to := ""
+		if len(email.To) > 0 {
+			to = truncate(email.To[0], 23)
+		}
+		fmt.Printf("%-50s %-20s %-25s %s\n", truncate(email.MessageID, 48), email.SendAt.Local().Format("2006-01-02 15:04"), to, truncate(email.Subject, 35))
+	}
+
+	fmt.Printf("\n%d scheduled email(s)\n", len(scheduled))
+
+	return nil
+}
+
+func runDraftScheduledCancel(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range args {
+		debugLog("Cancelling scheduled email %s via Graph API", id)
+
+		draftID, err := client.CancelScheduled(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to cancel %s: %w", id, err)
+		}
+		printSuccess("Delivery cancelled, email moved to Drafts (ID: %s)", draftID)
+	}
+
+	return nil
+}
+
+// parseSendAt parses a --send-at value relative to now: an RFC 3339 time,
+// "YYYY-MM-DD HH:MM", "HH:MM" (today, or tomorrow if that has passed),
+// "tomorrow HH:MM" or a delay such as 30m, 2h or 1d. The time must be in
+// the future.
+func parseSendAt(s string, now time.Time) (time.Time, error) {
+	value := strings.TrimSpace(s)
+	at, err := parseSendTime(value, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("send time %s is in the past", at.Format("2006-01-02 15:04"))
+	}
+	return at, nil
+}
+
+func parseSendTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	lower := strings.ToLower(value)
+	day := 0
+	if rest, ok := strings.CutPrefix(lower, "tomorrow "); ok {
+		day, lower = 1, strings.TrimSpace(rest)
+	}
+	if clock, err := time.Parse("15:04", lower); err == nil {
+		at := time.Date(now.Year(), now.Month(), now.Day()+day, clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if day == 0 && !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	if day == 0 {
+		if d, err := parseDuration(strings.TrimPrefix(lower, "+")); err == nil {
+			return now.Add(d), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("expected a time like 2024-05-31 08:00, 08:00, tomorrow 08:00 or a delay like 2h: %s", value)
+}
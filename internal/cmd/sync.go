@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+	"github.com/yourname/o365-mail-cli/internal/syncstate"
+)
+
+// Sync Command
+var (
+	syncFolder  string
+	syncJSON    bool
+	syncReset   bool
+	syncNoStore bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Show changes since the last sync",
+	Long: `Shows emails that were added, updated or removed in a folder since the
+last sync, using Microsoft Graph delta queries.
+
+The sync state is stored per account and folder in the cache directory.
+Added and updated emails are also saved with body, headers and attachment
+metadata to a local store for offline use ('mail list/read/search --local'),
+unless --no-store is given.
+The first sync (or a sync after --reset) reports every email as added.
+If Graph expires the stored state, a full sync is done and compared
+against the emails known from the last sync.
+
+Output is one line per change, or NDJSON (one JSON object per line) with --json:
+  + added    ~ updated    - removed
+
+Examples:
+  o365-mail-cli mail sync
+  o365-mail-cli mail sync --folder "Projects/Alpha"
+  o365-mail-cli mail sync --json | jq -r 'select(.change == "added") | .message.subject'
+  o365-mail-cli mail sync --reset`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
+	Args:        cobra.NoArgs,
+	RunE:        runSync,
+}
+
+// syncChange is a single NDJSON line of `mail sync --json`
+type syncChange struct {
+	Change  string      `json:"change"`
+	ID      string      `json:"id"`
+	Folder  string      `json:"folder"`
+	Message *mail.Email `json:"message,omitempty"`
+}
+
+func init() {
+	syncCmd.Flags().StringVar(&syncFolder, "folder", "inbox", "Folder to sync")
+	syncCmd.Flags().BoolVar(&syncJSON, "json", false, "Output changes as NDJSON")
+	syncCmd.Flags().BoolVar(&syncReset, "reset", false, "Discard the stored sync state and start over")
+	syncCmd.Flags().BoolVar(&syncNoStore, "no-store", false, "Don't save synced emails to the local store")
+
+	mailCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	folderID, err := client.GetFolderByName(ctx, syncFolder)
+	if err != nil {
+		return err
+	}
+
+	account := getActiveAccount()
+	if syncReset {
+		if err := syncstate.Remove(cfg.CacheDir, account, folderID); err != nil {
+			return err
+		}
+	}
+
+	state, err := syncstate.Load(cfg.CacheDir, account, folderID)
+	if err != nil {
+		return err
+	}
+	state.Folder = syncFolder
+
+	debugLog("Syncing folder %s (delta link: %t)", folderID, state.DeltaLink != "")
+
+	fullSync := state.DeltaLink == ""
+	result, err := client.MessagesDelta(ctx, folderID, state.DeltaLink)
+	if errors.Is(err, mail.ErrSyncReset) {
+		debugLog("Sync state expired, starting full sync: %v", err)
+		fullSync = true
+		result, err = client.MessagesDelta(ctx, folderID, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	known := state.Known()
+	var changes []syncChange
+	seen := map[string]bool{}
+	for _, c := range result.Changes {
+		seen[c.ID] = true
+		switch {
+		case c.Removed:
+			if known[c.ID] {
+				changes = append(changes, syncChange{Change: "removed", ID: c.ID})
+			}
+			delete(known, c.ID)
+		case known[c.ID]:
+			changes = append(changes, syncChange{Change: "updated", ID: c.ID, Message: c.Email})
+		default:
+			changes = append(changes, syncChange{Change: "added", ID: c.ID, Message: c.Email})
+			known[c.ID] = true
+		}
+	}
+
+	// A full sync lists every email, so anything known but not listed is gone
+	if fullSync {
+		for id := range known {
+			if !seen[id] {
+				changes = append(changes, syncChange{Change: "removed", ID: id})
+				delete(known, id)
+			}
+		}
+	}
+
+	if !syncNoStore {
+		skipped, err := storeSyncChanges(ctx, client, account, folderID, changes)
+		if err != nil {
+			return err
+		}
+		// Emails deleted before they could be fetched are neither reported as
+		// added nor remembered; updated ones stay known so that the next sync
+		// reports their removal
+		kept := changes[:0]
+		for _, c := range changes {
+			if c.Change == "added" && skipped[c.ID] {
+				delete(known, c.ID)
+				continue
+			}
+			kept = append(kept, c)
+		}
+		changes = kept
+	}
+
+	if err := printSyncChanges(changes); err != nil {
+		return err
+	}
+
+	// Only persist the new state once the changes were emitted
+	state.DeltaLink = result.DeltaLink
+	state.SyncedAt = time.Now().UTC()
+	state.SetKnown(known)
+	if err := state.Save(cfg.CacheDir); err != nil {
+		return err
+	}
+
+	if !syncJSON {
+		added, updated, removed := countSyncChanges(changes)
+		if len(changes) == 0 {
+			printInfo("No changes in '%s' since the last sync", syncFolder)
+		} else {
+			printSuccess("Synced '%s': %d added, %d updated, %d removed", syncFolder, added, updated, removed)
+		}
+	}
+
+	return nil
+}
+
+// storeSyncChanges fetches added and updated emails in full and saves them to the
+// local store, and drops removed ones. It fails before anything is saved to the sync
+// state, so emails that could not be fetched are reported again on the next sync.
+// It returns the IDs of emails skipped because they were deleted in the meantime.
+func storeSyncChanges(ctx context.Context, client *mail.GraphClient, account, folderID string, changes []syncChange) (map[string]bool, error) {
+	store := syncstate.OpenStore(cfg.CacheDir, account)
+
+	var ids []string
+	for _, c := range changes {
+		if c.Change == "removed" {
+			if err := store.Remove(c.ID, folderID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ids = append(ids, c.ID)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	debugLog("Fetching %d email(s) for the local store", len(ids))
+	details, results, err := client.GetMessageDetails(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch emails for the local store: %w", err)
+	}
+
+	now := time.Now().UTC()
+	skipped := map[string]bool{}
+	for i, detail := range details {
+		if results[i].Err != nil {
+			// Deleted since the delta query ran
+			if errors.Is(results[i].Err, mail.ErrNotFound) {
+				debugLog("Skipping %s: %v", ids[i], results[i].Err)
+				skipped[ids[i]] = true
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch email %s for the local store: %w", ids[i], results[i].Err)
+		}
+
+		detail.FolderID = folderID
+		if err := store.Put(&syncstate.StoredMessage{MessageDetail: *detail, Folder: syncFolder, SyncedAt: now}); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+func printSyncChanges(changes []syncChange) error {
+	if syncJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, c := range changes {
+			c.Folder = syncFolder
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	markers := map[string]string{"added": "+", "updated": "~", "removed": "-"}
+	for _, c := range changes {
+		if c.Message == nil {
+			fmt.Printf("%s %s\n", markers[c.Change], c.ID)
+			continue
+		}
+		date := c.Message.Date.Local().Format("2006-01-02 15:04")
+		fmt.Printf("%s [%s] %s - %s  (%s)\n", markers[c.Change], date, truncate(c.Message.From, 30), truncate(c.Message.Subject, 50), c.ID)
+	}
+	return nil
+}
+
+func countSyncChanges(changes []syncChange) (added, updated, removed int) {
+	for _, c := range changes {
+		switch c.Change {
+		case "added":
+			added++
+		case "updated":
+			updated++
+		case "removed":
+			removed++
+		}
+	}
+	return added, updated, removed
+}
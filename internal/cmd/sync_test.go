@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+	"github.com/yourname/o365-mail-cli/internal/syncstate"
+)
+
+func parseSyncOutput(t *testing.T, out string) map[string]string {
+	t.Helper()
+	changes := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var c syncChange
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		changes[c.ID] = c.Change
+	}
+	return changes
+}
+
+func TestMailSync(t *testing.T) {
+	srv := newTestServer(t)
+	srv.PageSize = 2
+	ids := seedMessages(srv, "a@example.com", 3)
+
+	res := runCLI(t, "", "mail", "sync", "--json")
+	mustSucceed(t, res)
+	if got := parseSyncOutput(t, res.Stdout); len(got) != 3 || got[ids[0]] != "added" {
+		t.Fatalf("initial sync = %v, want 3 added", got)
+	}
+
+	res = runCLI(t, "", "mail", "sync")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "No changes in 'inbox'")
+
+	newID := srv.AddMessage(graphtest.Message{Subject: "New", From: "b@example.com"})
+	mustSucceed(t, runCLI(t, "", "mail", "mark-read", ids[0]))
+	mustSucceed(t, runCLI(t, "", "mail", "trash", ids[1]))
+
+	res = runCLI(t, "", "mail", "sync", "--json")
+	mustSucceed(t, res)
+	want := map[string]string{newID: "added", ids[0]: "updated", ids[1]: "removed"}
+	if got := parseSyncOutput(t, res.Stdout); len(got) != 3 || got[newID] != want[newID] || got[ids[0]] != want[ids[0]] || got[ids[1]] != want[ids[1]] {
+		t.Errorf("incremental sync = %v, want %v", got, want)
+	}
+
+	res = runCLI(t, "", "mail", "sync")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "No changes")
+}
+
+func TestMailSync_TextOutput(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddMessage(graphtest.Message{Subject: "Hello", From: "a@example.com"})
+
+	res := runCLI(t, "", "mail", "sync")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "+ [")
+	assertContains(t, res.Stdout, "a@example.com - Hello")
+	assertContains(t, res.Stdout, "1 added, 0 updated, 0 removed")
+}
+
+func TestMailSync_ExpiredToken(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 2)
+	mustSucceed(t, runCLI(t, "", "mail", "sync"))
+
+	mustSucceed(t, runCLI(t, "", "mail", "trash", ids[0]))
+	newID := srv.AddMessage(graphtest.Message{Subject: "New"})
+	srv.ExpireDeltaTokens()
+
+	res := runCLI(t, "", "mail", "sync", "--json")
+	mustSucceed(t, res)
+	got := parseSyncOutput(t, res.Stdout)
+	if got[ids[0]] != "removed" || got[newID] != "added" || got[ids[1]] != "updated" {
+		t.Errorf("resync = %v", got)
+	}
+}
+
+func TestMailSync_Reset(t *testing.T) {
+	srv := newTestServer(t)
+	seedMessages(srv, "a@example.com", 2)
+	mustSucceed(t, runCLI(t, "", "mail", "sync"))
+
+	res := runCLI(t, "", "mail", "sync", "--reset", "--json")
+	mustSucceed(t, res)
+	if got := parseSyncOutput(t, res.Stdout); len(got) != 2 {
+		t.Errorf("sync after reset = %v, want 2 added", got)
+	}
+}
+
+func TestMailSync_DeletedBeforeFetch(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 2)
+	srv.InjectFault(graphtest.Fault{Method: "GET", PathPrefix: "/me/messages/" + ids[0], Status: 404, Times: 1})
+
+	res := runCLI(t, "", "mail", "sync", "--json")
+	mustSucceed(t, res)
+	if got := parseSyncOutput(t, res.Stdout); len(got) != 1 || got[ids[1]] != "added" {
+		t.Errorf("sync = %v, want only %s added", got, ids[1])
+	}
+
+	state, err := syncstate.Load(cfg.CacheDir, "", "inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if known := state.Known(); known[ids[0]] || !known[ids[1]] {
+		t.Errorf("known = %v, want only %s", known, ids[1])
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	srv := newTestServer(t)
+	budget := srv.AddMessage(graphtest.Message{
+		Subject:    "Planning",
+		Body:       "<p>The <b>budget</b> for 2024 is approved</p>",
+		BodyType:   "html",
+		From:       "cfo@example.com",
+		Attachment: []graphtest.Attachment{{Name: "forecast.xlsx", ContentType: "application/vnd.ms-excel", Content: []byte("x")}},
+		Headers:    []mail.MessageHeader{{Name: "X-Mailer", Value: "graphtest"}},
+	})
+	lunch := srv.AddMessage(graphtest.Message{Subject: "Lunch", Body: "Pizza?", From: "friend@example.com"})
+	mustSucceed(t, runCLI(t, "", "mail", "sync"))
+
+	// Everything below must work without Graph
+	srv.Close()
+
+	res := runCLI(t, "", "mail", "search", "--local", "--text", "BUDGET 2024")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Planning")
+	if strings.Contains(res.Stdout, "Lunch") {
+		t.Error("search matched unrelated email")
+	}
+
+	res = runCLI(t, "", "mail", "search", "--local", "--text", "forecast", "--json")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, budget)
+
+	res = runCLI(t, "", "mail", "list", "--local", "--json")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, budget)
+	assertContains(t, res.Stdout, lunch)
+
+	res = runCLI(t, "", "mail", "read", budget, "--local")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "Subject: Planning")
+	assertContains(t, res.Stdout, "forecast.xlsx")
+
+	stored, err := syncstate.OpenStore(cfg.CacheDir, "").Get(budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Headers) != 1 || stored.Headers[0].Name != "X-Mailer" || stored.BodyType != "html" {
+		t.Errorf("stored message = %+v", stored.MessageDetail)
+	}
+
+	res = runCLI(t, "", "mail", "read", "unknown", "--local")
+	if code := ExitCode(res.Err); code != ExitNotFound {
+		t.Errorf("exit code = %d, want %d", code, ExitNotFound)
+	}
+}
+
+func TestLocalStore_RemovedAndNoStore(t *testing.T) {
+	srv := newTestServer(t)
+	ids := seedMessages(srv, "a@example.com", 2)
+	mustSucceed(t, runCLI(t, "", "mail", "sync"))
+
+	mustSucceed(t, runCLI(t, "", "mail", "trash", ids[0]))
+	mustSucceed(t, runCLI(t, "", "mail", "sync"))
+
+	res := runCLI(t, "", "mail", "list", "--local", "--json")
+	mustSucceed(t, res)
+	if strings.Contains(res.Stdout, ids[0]) || !strings.Contains(res.Stdout, ids[1]) {
+		t.Errorf("local list after removal = %s", res.Stdout)
+	}
+
+	newID := srv.AddMessage(graphtest.Message{Subject: "Not stored"})
+	mustSucceed(t, runCLI(t, "", "mail", "sync", "--no-store"))
+	if _, err := syncstate.OpenStore(cfg.CacheDir, "").Get(newID); err == nil {
+		t.Error("--no-store saved the email")
+	}
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/compose"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+	"github.com/yourname/o365-mail-cli/internal/templates"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Manage message templates",
+	Long: `Commands for message templates used with 'mail send --template' and
+'mail reply --template'.
+
+Templates are stored as YAML files in ~/.o365-mail-cli/templates/. Subject
+and body are Go text/template sources: {{.name}} inserts the value given
+with --var name=value. Replies can also use the original message:
+
+  {{.Original.SenderName}}       display name of the sender (or address)
+  {{.Original.SenderFirstName}}  first word of the sender name
+  {{.Original.SenderEmail}}      address of the sender
+  {{.Original.Subject}}          subject of the original
+  {{.Original.Date}}             received time, e.g. {{.Original.Date.Format "2 Jan 2006"}}
+
+The body of an --html template is an html/template, so inserted values
+are HTML-escaped. A variable used in a template but not given is an error. Replies keep the
+subject of the original, so the subject template only applies to 'mail send'.`,
+}
+
+var templatesListJSON bool
+
+var templatesListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List templates",
+	Annotations: map[string]string{profile.AnnotationKey: "templates.read"},
+	RunE:        runTemplatesList,
+}
+
+var templatesShowCmd = &cobra.Command{
+	Use:         "show [name]",
+	Short:       "Show a template",
+	Annotations: map[string]string{profile.AnnotationKey: "templates.read"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runTemplatesShow,
+}
+
+var (
+	templateCreateDescription string
+	templateCreateSubject     string
+	templateCreateBody        string
+	templateCreateBodyFile    string
+	templateCreateHTML        bool
+	templateCreateMarkdown    bool
+	templateCreateTo          []string
+	templateCreateCc          []string
+	templateCreateBcc         []string
+	templateCreateForce       bool
+)
+
+var templatesCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a template",
+	Long: `Creates a message template. Names may contain letters, digits, '.', '_'
+and '-'.
+
+--to, --cc and --bcc are default recipients, used when sending without
+recipients of that kind and added to replies.
+
+Examples:
+  o365-mail-cli templates create password-reset --subject "Your password reset" \
+    --body-file reset.md --markdown
+  o365-mail-cli templates create thanks --body "Hi {{.Original.SenderFirstName}},
+
+thanks for your message about \"{{.Original.Subject}}\". We'll get back to you within {{.days}} days."
+  o365-mail-cli templates create escalate --subject "Escalation: {{.ticket}}" \
+    --body-file escalate.txt --to oncall@example.com --cc lead@example.com`,
+	Annotations: map[string]string{profile.AnnotationKey: "templates.manage"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runTemplatesCreate,
+}
+
+var templatesDeleteCmd = &cobra.Command{
+	Use:         "delete [name]",
+	Short:       "Delete a template",
+	Annotations: map[string]string{profile.AnnotationKey: "templates.manage"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runTemplatesDelete,
+}
+
+func init() {
+	templatesListCmd.Flags().BoolVar(&templatesListJSON, "json", false, "Output as JSON")
+
+	templatesCreateCmd.Flags().StringVar(&templateCreateDescription, "description", "", "Description shown by 'templates list'")
+	templatesCreateCmd.Flags().StringVar(&templateCreateSubject, "subject", "", "Subject template")
+	templatesCreateCmd.Flags().StringVar(&templateCreateBody, "body", "", "Body template")
+	templatesCreateCmd.Flags().StringVar(&templateCreateBodyFile, "body-file", "", "Read body template from file")
+	templatesCreateCmd.Flags().BoolVar(&templateCreateHTML, "html", false, "Body is HTML")
+	templatesCreateCmd.Flags().BoolVar(&templateCreateMarkdown, "markdown", false, "Body is Markdown, sent as HTML")
+	templatesCreateCmd.MarkFlagsMutuallyExclusive("html", "markdown")
+	templatesCreateCmd.Flags().StringArrayVar(&templateCreateTo, "to", nil, "Default recipients (can be specified multiple times)")
+	templatesCreateCmd.Flags().StringArrayVar(&templateCreateCc, "cc", nil, "Default CC recipients (can be specified multiple times)")
+	templatesCreateCmd.Flags().StringArrayVar(&templateCreateBcc, "bcc", nil, "Default BCC recipients (can be specified multiple times)")
+	templatesCreateCmd.Flags().BoolVar(&templateCreateForce, "force", false, "Replace an existing template")
+
+	templatesCmd.AddCommand(templatesListCmd)
+	templatesCmd.AddCommand(templatesShowCmd)
+	templatesCmd.AddCommand(templatesCreateCmd)
+	templatesCmd.AddCommand(templatesDeleteCmd)
+}
+
+func runTemplatesList(cmd *cobra.Command, args []string) error {
+	list, err := templates.List()
+	if err != nil {
+		return err
+	}
+
+	if templatesListJSON {
+		if list == nil {
+			list = []*templates.Template{}
+		}
+		return outputJSON(list)
+	}
+
+	if len(list) == 0 {
+		printInfo("No templates found. Create one with 'templates create'.")
+		return nil
+	}
+
+	fmt.Printf("\n%-25s %-10s %-35s %s\n", "Name", "Format", "Subject", "Description")
+	fmt.Println(strings.Repeat("─", 100))
+	for _, t := range list {
+		fmt.Printf("%-25s %-10s %-35s %s\n", truncate(t.Name, 25), templateFormat(t), truncate(t.Subject, 35), t.Description)
+	}
+
+	fmt.Printf("\n%d template(s) found\n", len(list))
+
+	return nil
+}
+
+func runTemplatesShow(cmd *cobra.Command, args []string) error {
+	t, err := templates.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Name:        %s\n", t.Name)
+	if t.Description != "" {
+		fmt.Printf("Description: %s\n", t.Description)
+	}
+	fmt.Printf("Format:      %s\n", templateFormat(t))
+	if len(t.To) > 0 {
+		fmt.Printf("To:          %s\n", strings.Join(t.To, ", "))
+	}
+	if len(t.Cc) > 0 {
+		fmt.Printf("Cc:          %s\n", strings.Join(t.Cc, ", "))
+	}
+	if len(t.Bcc) > 0 {
+		fmt.Printf("Bcc:         %s\n", strings.Join(t.Bcc, ", "))
+	}
+	if t.Subject != "" {
+		fmt.Printf("Subject:     %s\n", t.Subject)
+	}
+	fmt.Println()
+	fmt.Println(t.Body)
+
+	return nil
+}
+
+func runTemplatesCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	body := templateCreateBody
+	if templateCreateBodyFile != "" {
+		content, err := os.ReadFile(templateCreateBodyFile)
+		if err != nil {
+			return fmt.Errorf("could not read body file: %w", err)
+		}
+		body = string(content)
+	}
+	if body == "" {
+		return fmt.Errorf("template body required (--body or --body-file)")
+	}
+
+	if templates.Exists(name) && !templateCreateForce {
+		return fmt.Errorf("template '%s' already exists (use --force to replace it)", name)
+	}
+
+	t := &templates.Template{
+		Name:        name,
+		Description: templateCreateDescription,
+		Subject:     templateCreateSubject,
+		To:          templateCreateTo,
+		Cc:          templateCreateCc,
+		Bcc:         templateCreateBcc,
+		Body:        body,
+	}
+	switch {
+	case templateCreateHTML:
+		t.Format = "html"
+	case templateCreateMarkdown:
+		t.Format = "markdown"
+	}
+
+	if err := templates.Save(t); err != nil {
+		return err
+	}
+
+	printSuccess("Template '%s' saved", name)
+	return nil
+}
+
+func runTemplatesDelete(cmd *cobra.Command, args []string) error {
+	if err := templates.Delete(args[0]); err != nil {
+		return err
+	}
+	printSuccess("Template '%s' deleted", args[0])
+	return nil
+}
+
+func templateFormat(t *templates.Template) string {
+	if t.Format == "" {
+		return "text"
+	}
+	return t.Format
+}
+
+// templateFlags are the --template and --var options of the commands that
+// compose from a template
+type templateFlags struct {
+	name string
+	vars []string
+}
+
+var (
+	sendTemplate  templateFlags
+	replyTemplate templateFlags
+)
+
+// addFlags registers the template flags on a compose command
+func (f *templateFlags) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "template", "", "Compose from this template (see 'templates list')")
+	cmd.Flags().StringArrayVar(&f.vars, "var", nil, "Template variable as key=value (can be specified multiple times)")
+}
+
+// apply renders the template and fills in what the flags of cmd leave
+// open: subject, body, recipients of each kind and the body format.
+// original is the message replied to, or nil.
+func (f *templateFlags) apply(cmd *cobra.Command, original *templates.Original, msg *compose.Message, html, markdown *bool) error {
+	if f.name == "" {
+		if len(f.vars) > 0 {
+			return fmt.Errorf("--var requires --template")
+		}
+		return nil
+	}
+
+	vars, err := parseTemplateVars(f.vars)
+	if err != nil {
+		return err
+	}
+	t, err := templates.Load(f.name)
+	if err != nil {
+		return err
+	}
+	subject, body, err := t.Render(vars, original)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("subject") {
+		msg.Subject = subject
+	}
+	if !cmd.Flags().Changed("body") && !cmd.Flags().Changed("body-file") {
+		msg.Body = body
+	}
+	if len(msg.To) == 0 {
+		msg.To = t.To
+	}
+	if len(msg.Cc) == 0 {
+		msg.Cc = t.Cc
+	}
+	if len(msg.Bcc) == 0 {
+		msg.Bcc = t.Bcc
+	}
+	if !cmd.Flags().Changed("html") && !cmd.Flags().Changed("markdown") {
+		*html = t.Format == "html"
+		*markdown = t.Format == "markdown"
+	}
+
+	debugLog("Composed from template %s", t.Name)
+	return nil
+}
+
+// parseTemplateVars parses key=value pairs; values may contain '='
+func parseTemplateVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var value %q: expected key=value", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestTemplatesCommands(t *testing.T) {
+	newTestServer(t)
+
+	mustSucceed(t, runCLI(t, "", "templates", "create", "escalate", "--subject", "Escalation: {{.ticket}}",
+		"--body", "Customer {{.customer}} needs help", "--to", "oncall@example.com", "--description", "Hand over to on-call"))
+	if res := runCLI(t, "", "templates", "create", "escalate", "--body", "x"); res.Err == nil {
+		t.Error("expected error for an existing template without --force")
+	}
+	if res := runCLI(t, "", "templates", "create", "broken", "--body", "{{.x"); res.Err == nil {
+		t.Error("expected error for invalid template syntax")
+	}
+
+	res := runCLI(t, "", "templates", "list")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "escalate")
+	assertContains(t, res.Stdout, "Hand over to on-call")
+
+	res = runCLI(t, "", "templates", "show", "escalate")
+	mustSucceed(t, res)
+	assertContains(t, res.Stdout, "To:          oncall@example.com")
+	assertContains(t, res.Stdout, "Customer {{.customer}} needs help")
+
+	mustSucceed(t, runCLI(t, "", "templates", "delete", "escalate"))
+	if res := runCLI(t, "", "templates", "show", "escalate"); res.Err == nil {
+		t.Error("expected error for a deleted template")
+	}
+}
+
+func TestMailSend_Template(t *testing.T) {
+	srv := newTestServer(t)
+
+	mustSucceed(t, runCLI(t, "", "templates", "create", "escalate", "--subject", "Escalation: {{.ticket}}",
+		"--body", "**{{.customer}}** needs help", "--markdown", "--to", "oncall@example.com", "--cc", "lead@example.com"))
+
+	mustSucceed(t, runCLI(t, "", "mail", "send", "--template", "escalate", "--var", "ticket=4711", "--var", "customer=ACME"))
+	mustSucceed(t, runCLI(t, "", "mail", "send", "--template", "escalate", "--var", "ticket=1", "--var", "customer=B",
+		"--to", "other@example.com", "--subject", "Custom"))
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 2 {
+		t.Fatalf("sent %d message(s), want 2", len(sent))
+	}
+	first := sent[1]
+	if first.Subject != "Escalation: 4711" || first.To[0] != "oncall@example.com" || first.Cc[0] != "lead@example.com" || first.BodyType != "html" {
+		t.Errorf("first = %+v", first)
+	}
+	assertContains(t, first.Body, "<strong>ACME</strong>")
+	if sent[0].Subject != "Custom" || sent[0].To[0] != "other@example.com" {
+		t.Errorf("flags did not override the template: %+v", sent[0])
+	}
+
+	if res := runCLI(t, "", "mail", "send", "--template", "escalate", "--var", "ticket=1"); res.Err == nil {
+		t.Error("expected error for a missing template variable")
+	}
+	if res := runCLI(t, "", "mail", "send", "--to", "x@example.com", "--subject", "s", "--body", "b", "--var", "a=b"); res.Err == nil {
+		t.Error("expected error for --var without --template")
+	}
+}
+
+func TestMailReply_Template(t *testing.T) {
+	srv := newTestServer(t)
+	id := srv.AddMessage(graphtest.Message{Subject: "Login broken", From: "Anna Smith <anna@example.com>"})
+
+	mustSucceed(t, runCLI(t, "", "templates", "create", "thanks", "--cc", "lead@example.com", "--subject", "Ticket {{.days}}",
+		"--body", "Hi {{.Original.SenderFirstName}}, we are looking into \"{{.Original.Subject}}\" within {{.days}} days."))
+	mustSucceed(t, runCLI(t, "", "mail", "reply", id, "--template", "thanks", "--var", "days=2"))
+
+	actions := srv.Actions()
+	if len(actions) != 1 || actions[0].Kind != "reply" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	if got := actions[0].Body["comment"]; got != `Hi Anna, we are looking into "Login broken" within 2 days.` {
+		t.Errorf("comment = %v", got)
+	}
+	assertContains(t, fmt.Sprint(actions[0].Body["message"]), "lead@example.com")
+	// The subject of a template does not apply to replies
+	if strings.Contains(fmt.Sprint(actions[0].Body["message"]), "Ticket") {
+		t.Errorf("reply uses the template subject: %v", actions[0].Body["message"])
+	}
+}
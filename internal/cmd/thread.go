@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourname/o365-mail-cli/internal/compose"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/profile"
+)
+
+// Thread Command
+var (
+	threadFolders []string
+	threadExpand  bool
+	threadJSON    bool
+)
+
+var threadCmd = &cobra.Command{
+	Use:   "thread [message-id]",
+	Short: "Show the conversation of an email",
+	Long: `Shows all messages of the conversation an email belongs to, oldest first.
+
+Messages are collected from Inbox, Sent Items and Archive (or the folders
+given with --folder). Read messages are collapsed to a one-line preview;
+unread messages and the latest one are shown in full with quoted replies
+collapsed. --expand shows every message in full.
+
+Examples:
+  o365-mail-cli mail thread AAMkAGI2...
+  o365-mail-cli mail thread AAMkAGI2... --expand
+  o365-mail-cli mail thread AAMkAGI2... --folder inbox --folder "Projects/Alpha"
+  o365-mail-cli mail thread AAMkAGI2... --json`,
+	Annotations: map[string]string{profile.AnnotationKey: "mail.read"},
+	Args:        cobra.ExactArgs(1),
+	RunE:        runThread,
+}
+
+func init() {
+	threadCmd.Flags().StringArrayVar(&threadFolders, "folder", nil, "Folder to collect messages from (default: inbox, Sent Items and Archive)")
+	threadCmd.Flags().BoolVar(&threadExpand, "expand", false, "Show all messages in full")
+	threadCmd.Flags().BoolVar(&threadJSON, "json", false, "Output as JSON")
+
+	mailCmd.AddCommand(threadCmd)
+}
+
+func runThread(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	messageID := args[0]
+
+	client, err := getGraphClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	detail, err := client.GetMessageDetail(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if detail.ConversationID == "" {
+		return fmt.Errorf("email %s has no conversation ID", messageID)
+	}
+
+	folderIDs := mail.ThreadFolders
+	if len(threadFolders) > 0 {
+		folderIDs = nil
+		for _, name := range threadFolders {
+			folderID, err := client.GetFolderByName(ctx, name)
+			if err != nil {
+				return err
+			}
+			folderIDs = append(folderIDs, folderID)
+		}
+	}
+
+	debugLog("Fetching conversation %s from %d folders", detail.ConversationID, len(folderIDs))
+
+	emails, err := client.GetConversation(ctx, detail.ConversationID, folderIDs)
+	if err != nil {
+		return err
+	}
+
+	// The email may live in a folder that was not searched
+	found := false
+	for _, email := range emails {
+		if email.MessageID == detail.MessageID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		emails = append(emails, detail.Email)
+		sort.SliceStable(emails, func(i, j int) bool {
+			return emails[i].Date.Before(emails[j].Date)
+		})
+	}
+
+	thread := mail.GroupThreads(emails)[0]
+	if threadJSON {
+		return outputJSON(thread)
+	}
+
+	printThread(thread)
+	return nil
+}
+
+// printThread prints a conversation, collapsing read messages except the last
+func printThread(thread mail.Thread) {
+	width := terminalWidth()
+
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+	fmt.Printf("Thread:  %s\n", thread.Subject)
+	fmt.Printf("         %d messages, %d unread\n", len(thread.Messages), thread.UnreadCount)
+	fmt.Println("═══════════════════════════════════════════════════════════════")
+
+	for i, email := range thread.Messages {
+		marker := " "
+		if email.Unread {
+			marker = "●"
+		}
+		fmt.Println()
+		fmt.Printf("%s [%d/%d] %s, %s\n", marker, i+1, len(thread.Messages),
+			email.From, email.Date.Local().Format("Mon, 2 Jan 2006 15:04"))
+		fmt.Printf("  ID: %s\n", email.MessageID)
+
+		last := i == len(thread.Messages)-1
+		if !threadExpand && !last && !email.Unread {
+			preview := strings.Join(strings.Fields(email.Preview), " ")
+			fmt.Printf("  %s\n", truncate(preview, width-2))
+			continue
+		}
+
+		opts := compose.RenderOptions{Width: width - 2}
+		body := compose.RenderText(email.Body, opts)
+		if strings.EqualFold(email.BodyType, "html") {
+			body = compose.RenderHTML(email.Body, opts)
+		}
+		fmt.Println()
+		for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+			fmt.Println(strings.TrimRight("  "+line, " "))
+		}
+	}
+}
+
+// printThreadTable prints one row per thread with its latest email
+func printThreadTable(threads []mail.Thread) {
+	fmt.Printf("\n%-40s %-20s %-25s %s\n", "ID", "Date", "From", "Subject")
+	fmt.Println(strings.Repeat("─", 110))
+
+	for _, thread := range threads {
+		unreadMarker := " "
+		if thread.UnreadCount > 0 {
+			unreadMarker = "●"
+		}
+
+		latest := thread.Messages[0]
+		for _, email := range thread.Messages[1:] {
+			if email.Date.After(latest.Date) {
+				latest = email
+			}
+		}
+
+		subject := thread.Subject
+		if len(thread.Messages) > 1 {
+			subject = fmt.Sprintf("(%d) %s", len(thread.Messages), subject)
+		}
+
+		id := truncate(latest.MessageID, 38)
+		from := truncate(latest.From, 23)
+		date := thread.Latest.Local().Format("2006-01-02 15:04")
+
+		fmt.Printf("%s %-39s %-20s %-25s %s\n", unreadMarker, id, date, from, truncate(subject, 35))
+	}
+}
@@ -0,0 +1,253 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInlineImages(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("\x89PNG\r\n\x1a\nlogo"), 0600)
+	os.MkdirAll(filepath.Join(dir, "img"), 0700)
+	os.WriteFile(filepath.Join(dir, "img", "chart.gif"), []byte("GIF89a"), 0600)
+
+	body := `<p><img src="logo.png" alt="Logo"> <IMG SRC='img/chart.gif'></p>` +
+		`<img src="https://example.com/remote.png"><img src="cid:existing"><img src="data:image/png;base64,AAAA">` +
+		`<img width=10 src=logo.png>`
+
+	got, images, err := InlineImages(body, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2 (logo.png attached once)", len(images))
+	}
+	logo, chart := images[0], images[1]
+	if logo.Name != "logo.png" || logo.ContentType != "image/png" || !strings.HasPrefix(logo.ContentID, "image1.") {
+		t.Errorf("logo = %s %s %s", logo.Name, logo.ContentType, logo.ContentID)
+	}
+	if chart.ContentType != "image/gif" {
+		t.Errorf("chart content type = %s", chart.ContentType)
+	}
+
+	want := `<p><img src="cid:` + logo.ContentID + `" alt="Logo"> <IMG SRC='cid:` + chart.ContentID + `'></p>` +
+		`<img src="https://example.com/remote.png"><img src="cid:existing"><img src="data:image/png;base64,AAAA">` +
+		`<img width=10 src=cid:` + logo.ContentID + `>`
+	if got != want {
+		t.Errorf("rewritten body:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestInlineImages_FileURLAndMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "photo.jpg")
+	os.WriteFile(path, []byte("\xff\xd8\xff"), 0600)
+
+	_, images, err := InlineImages(`<img src="file://`+filepath.ToSlash(path)+`">`, "/elsewhere")
+	if err != nil || len(images) != 1 || images[0].ContentType != "image/jpeg" {
+		t.Errorf("file URL: images = %+v, err = %v", images, err)
+	}
+
+	_, _, err = InlineImages(`<img src="missing.png">`, dir)
+	if err == nil || !strings.Contains(err.Error(), "missing.png") {
+		t.Errorf("err = %v, want error naming the missing image", err)
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	body := `<html><head><title>Ignored</title><style>p { color: red; }</style></head><body>
+<h1>Quarterly   report</h1>
+<p>Hello <b>team</b>,<br>numbers are <a href="https://example.com/q1">here</a> &amp; attached.</p>
+<ul><li>Revenue up</li><li>Costs down</li></ul>
+<p>Contact <a href="mailto:boss@example.com">boss@example.com</a></p>
+<blockquote><p>Earlier <a href="https://example.com/a">message</a></p></blockquote>
+<img src="cid:logo" alt="Company logo">
+<!-- hidden comment -->
+<pre>  keep
+  spacing</pre>
+</body></html>`
+
+	want := `Quarterly report
+════════════════
+
+Hello team,
+numbers are here (https://example.com/q1) & attached.
+
+• Revenue up
+• Costs down
+
+Contact boss@example.com
+
+> Earlier message (https://example.com/a)
+
+[Company logo]
+
+  keep
+  spacing
+`
+	if got := HTMLToText(body); got != want {
+		t.Errorf("HTMLToText:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	src := "# Status\n\nAll **good**, see [docs](https://example.com) <script>alert(1)</script>\n\n" +
+		"| Team | Done |\n|------|-----:|\n| Ops  | 3    |\n\n```go\nfmt.Println(\"hi\")\n```\n\n[bad](javascript:alert(1))\n"
+
+	got, err := Markdown(src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h1>Status</h1>",
+		`<strong>good</strong>, see <a href="https://example.com">docs</a>`,
+		"<th>Team</th>",
+		`<td style="text-align:right">3</td>`,
+		`<pre><code class="language-go">fmt.Println(&quot;hi&quot;)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered HTML has no %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") || strings.Contains(got, "javascript:") {
+		t.Errorf("unsafe content not removed:\n%s", got)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	theme, err := ParseTheme(`/* mail theme */
+h1, H2 { color: red; font-weight:  bold }
+.note, p > a { color: blue }
+td { padding: 4px; }
+h1 { margin: 0 }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := theme.Apply(`<div><h1>A</h1><h2 id="x">B</h2><p><a href="#">c</a></p><td style="text-align:right">1</td></div>`)
+	want := `<div><h1 style="color: red; font-weight: bold; margin: 0">A</h1><h2 style="color: red; font-weight: bold" id="x">B</h2>` +
+		`<p><a href="#">c</a></p><td style="padding: 4px; text-align:right">1</td></div>`
+	if got != want {
+		t.Errorf("Apply:\n got %s\nwant %s", got, want)
+	}
+
+	if _, err := ParseTheme("h1 { color: red"); err == nil {
+		t.Error("expected error for an unterminated rule")
+	}
+	if DefaultTheme().rules["div"] == "" {
+		t.Error("default theme has no div rule")
+	}
+}
+
+func TestTemplateAndParse(t *testing.T) {
+	msg := Message{To: []string{"a@example.com", "b@example.com"}, Subject: "Plan", Attach: []string{"plan.pdf"}, Body: "Draft text"}
+	text := Template(msg, HeaderFields, "On Mon, x wrote:\n> original\n")
+
+	want := "To: a@example.com, b@example.com\nCc: \nBcc: \nSubject: Plan\nAttach: plan.pdf\n\nDraft text\n\n" + scissors
+	if !strings.HasPrefix(text, want) || !strings.HasSuffix(text, "> original\n") {
+		t.Errorf("template:\n%s", text)
+	}
+
+	edited := strings.Replace(text, "Cc: ", "cc: c@example.com, d@example.com", 1)
+	edited = strings.Replace(edited, "Draft text", "\nFinal text\n\nRegards  ", 1)
+	got, err := Parse(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got.Cc, ";") != "c@example.com;d@example.com" || got.Subject != "Plan" || len(got.Attach) != 1 || len(got.Bcc) != 0 {
+		t.Errorf("headers = %+v", got)
+	}
+	if got.Body != "Final text\n\nRegards\n" {
+		t.Errorf("body = %q", got.Body)
+	}
+
+	if _, err := Parse("To: a@example.com\nFrom: me\n\nText"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want error for line 2", err)
+	}
+	if got, _ := Parse("Hello: this is the body\n"); got.Body != "Hello: this is the body\n" {
+		t.Errorf("body without headers = %q", got.Body)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	date := time.Date(2024, 3, 4, 9, 30, 0, 0, time.Local)
+	got := Quote("a@example.com", date, "Hi,\n\nquestion?\n")
+	want := "On Mon, 4 Mar 2024 09:30, a@example.com wrote:\n> Hi,\n>\n> question?\n"
+	if got != want {
+		t.Errorf("Quote:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	body := `<html><head><style>p { margin: 0 }</style></head><body>
+<h1>Quarterly report</h1>
+<p>Hello <b>team</b>, the numbers are <a href="https://example.com/q1">here</a> and at <a href="https://example.com/q1">the same place</a>.</p>
+<ul><li>Revenue up</li><li>Costs down<ol><li>travel</li></ol></li></ul>
+<table><tr><th>Team</th><th>Done</th></tr><tr><td>Ops</td><td>3</td></tr><tr><td>Development</td><td>12</td></tr></table>
+<table><tr><td><p>Layout cell</p><p>with paragraphs</p></td><td>Side</td></tr></table>
+<blockquote type="cite">Earlier <a href="https://example.com/old">mail</a></blockquote>
+<p>Visit <a href="https://example.com">https://example.com</a></p>
+<div id="x_divRplyFwdMsg"><b>From:</b> Alice</div><p>Original</p>
+</body></html>`
+
+	want := `Quarterly report
+════════════════
+
+Hello team, the numbers are here[1] and at
+the same place[1].
+
+• Revenue up
+• Costs down
+  1. travel
+
+Team         Done
+───────────  ────
+Ops          3
+Development  12
+
+Layout cell
+with paragraphs
+Side
+
+[quoted text hidden]
+
+Visit https://example.com
+
+[quoted text hidden]
+
+Links:
+[1] https://example.com/q1
+`
+	if got := RenderHTML(body, RenderOptions{Width: 44}); got != want {
+		t.Errorf("RenderHTML:\n got %q\nwant %q", got, want)
+	}
+
+	got := RenderHTML(body, RenderOptions{ShowQuoted: true})
+	for _, want := range []string{"> Earlier mail[2]\n\nVisit", "From: Alice\n\nOriginal", "[2] https://example.com/old"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("with quotes shown, output has no %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderHTML_UnbalancedHead(t *testing.T) {
+	body := `</head><p>Hello</p><style>p { color: red }</style><script>alert(1)</script><p>Bye</p>`
+	if got, want := RenderHTML(body, RenderOptions{}), "Hello\n\nBye\n"; got != want {
+		t.Errorf("RenderHTML:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	body := "Thanks, that works for me and the team.\r\n\r\nOn Mon, Bob wrote:\r\n> Does Friday work?\r\n> Bob\r\n\r\n-----Original Message-----\r\nFrom: Alice\r\n"
+
+	want := "Thanks, that works for me\nand the team.\n\nOn Mon, Bob wrote:\n[2 quoted line(s) hidden]\n\n[quoted text hidden]\n"
+	if got := RenderText(body, RenderOptions{Width: 26}); got != want {
+		t.Errorf("RenderText:\n got %q\nwant %q", got, want)
+	}
+	if got := RenderText(body, RenderOptions{ShowQuoted: true}); !strings.Contains(got, "> Does Friday work?\n> Bob\n") {
+		t.Errorf("quoted lines not kept:\n%s", got)
+	}
+}
@@ -0,0 +1,194 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Message is the editable part of an outgoing message
+type Message struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	Subject string
+	// Attach lists file paths, one per Attach: line
+	Attach []string
+	Body   string
+}
+
+// HeaderFields are the headers of the editing template in their order
+var HeaderFields = []string{"To", "Cc", "Bcc", "Subject", "Attach"}
+
+// scissors separates the message from text that is only shown for context
+const scissors = "# ------------------------ >8 ------------------------"
+
+// Template renders msg for editing: a header block with the given fields
+// (none for a body-only template), a blank line and the body. The quoted
+// original of a reply goes below a scissors line and is ignored by Parse.
+func Template(msg Message, fields []string, quoted string) string {
+	var b strings.Builder
+	for _, field := range fields {
+		switch field {
+		case "To":
+			fmt.Fprintf(&b, "To: %s\n", strings.Join(msg.To, ", "))
+		case "Cc":
+			fmt.Fprintf(&b, "Cc: %s\n", strings.Join(msg.Cc, ", "))
+		case "Bcc":
+			fmt.Fprintf(&b, "Bcc: %s\n", strings.Join(msg.Bcc, ", "))
+		case "Subject":
+			fmt.Fprintf(&b, "Subject: %s\n", msg.Subject)
+		case "Attach":
+			if len(msg.Attach) == 0 {
+				b.WriteString("Attach: \n")
+			}
+			for _, path := range msg.Attach {
+				fmt.Fprintf(&b, "Attach: %s\n", path)
+			}
+		}
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(msg.Body)
+	if msg.Body != "" && !strings.HasSuffix(msg.Body, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("\n" + scissors + "\n")
+	b.WriteString("# Do not modify or remove the line above.\n")
+	b.WriteString("# Everything below it is ignored.\n")
+	if quoted != "" {
+		b.WriteString("\n" + quoted)
+	}
+	return b.String()
+}
+
+// Parse reads back a template edited by the user. Leading "Header: value"
+// lines up to the first blank line form the header block; addresses are
+// separated by commas.
+func Parse(text string) (Message, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if i := strings.Index(text, scissors); i >= 0 {
+		text = text[:i]
+	}
+	lines := strings.Split(text, "\n")
+
+	var msg Message
+	body := 0
+	if len(lines) > 0 && isHeaderLine(lines[0]) {
+		for body < len(lines) && strings.TrimSpace(lines[body]) != "" {
+			if !isHeaderLine(lines[body]) {
+				return Message{}, fmt.Errorf("line %d: expected a header (%s) or a blank line before the body",
+					body+1, strings.Join(HeaderFields, ", "))
+			}
+			name, value, _ := strings.Cut(lines[body], ":")
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "to":
+				msg.To = append(msg.To, splitAddresses(value)...)
+			case "cc":
+				msg.Cc = append(msg.Cc, splitAddresses(value)...)
+			case "bcc":
+				msg.Bcc = append(msg.Bcc, splitAddresses(value)...)
+			case "subject":
+				msg.Subject = value
+			case "attach":
+				if value != "" {
+					msg.Attach = append(msg.Attach, value)
+				}
+			}
+			body++
+		}
+	}
+
+	msg.Body = strings.TrimLeft(strings.Join(lines[body:], "\n"), "\n")
+	msg.Body = strings.TrimRight(msg.Body, " \t\n")
+	if msg.Body != "" {
+		msg.Body += "\n"
+	}
+	return msg, nil
+}
+
+func isHeaderLine(line string) bool {
+	name, _, ok := strings.Cut(line, ":")
+	if !ok {
+		return false
+	}
+	for _, field := range HeaderFields {
+		if strings.EqualFold(strings.TrimSpace(name), field) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitAddresses(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// Quote formats the original message of a reply with "> " line prefixes
+func Quote(from string, date time.Time, body string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "On %s, %s wrote:\n", date.Local().Format("Mon, 2 Jan 2006 15:04"), from)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// Editor returns the command from $VISUAL or $EDITOR, falling back to vi
+// (notepad on Windows)
+func Editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// Edit writes content to a temporary file with the given extension, opens
+// it in the user's editor and returns the saved text. The editor command
+// may include arguments, e.g. "code --wait".
+func Edit(content, ext string) (string, error) {
+	f, err := os.CreateTemp("", "o365-mail-*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	args := strings.Fields(Editor())
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return string(edited), nil
+}
@@ -0,0 +1,179 @@
+// Package compose prepares message bodies for sending and reading: it
+// inlines local images referenced from HTML, derives plain-text
+// alternatives and renders bodies for the terminal.
+package compose
+
+import (
+	"strings"
+)
+
+// token is a tag or the text between tags. Offsets refer to the source HTML.
+type token struct {
+	// tag is the lowercase tag name, empty for text
+	tag     string
+	closing bool
+	attrs   []attr
+	text    string
+	start   int
+	end     int
+}
+
+// attr is a tag attribute; valueStart and valueEnd locate the raw value
+// in the source HTML (equal when the attribute has no value)
+type attr struct {
+	name       string
+	value      string
+	valueStart int
+	valueEnd   int
+}
+
+// get returns the raw value of the named attribute
+func (t token) get(name string) (attr, bool) {
+	for _, a := range t.attrs {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return attr{}, false
+}
+
+// tokenize splits HTML into tags and text. It is lenient like browsers:
+// comments and doctypes are skipped, script and style content is kept as
+// a single text token, and a stray '<' is treated as text.
+func tokenize(src string) []token {
+	var tokens []token
+	text := 0
+	flushText := func(end int) {
+		if end > text {
+			tokens = append(tokens, token{text: src[text:end], start: text, end: end})
+		}
+	}
+
+	for i := 0; i < len(src); {
+		if src[i] != '<' {
+			i++
+			continue
+		}
+
+		rest := src[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			flushText(i)
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += 4 + end + 3
+			}
+			text = i
+			continue
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			flushText(i)
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 1
+			}
+			text = i
+			continue
+		}
+
+		tok, ok := parseTag(src, i)
+		if !ok {
+			i++
+			continue
+		}
+		flushText(i)
+		tokens = append(tokens, tok)
+		i, text = tok.end, tok.end
+
+		if !tok.closing && (tok.tag == "script" || tok.tag == "style") {
+			end := strings.Index(strings.ToLower(src[i:]), "</"+tok.tag)
+			if end < 0 {
+				end = len(src) - i
+			}
+			flushText(i + end)
+			i, text = i+end, i+end
+		}
+	}
+	flushText(len(src))
+	return tokens
+}
+
+// parseTag parses the tag starting at src[start] == '<'
+func parseTag(src string, start int) (token, bool) {
+	i := start + 1
+	tok := token{start: start}
+	if i < len(src) && src[i] == '/' {
+		tok.closing = true
+		i++
+	}
+
+	nameStart := i
+	for i < len(src) && isNameChar(src[i]) {
+		i++
+	}
+	if i == nameStart || !isLetter(src[nameStart]) {
+		return token{}, false
+	}
+	tok.tag = strings.ToLower(src[nameStart:i])
+
+	for i < len(src) {
+		for i < len(src) && (isSpace(src[i]) || src[i] == '/') {
+			i++
+		}
+		if i >= len(src) {
+			break
+		}
+		if src[i] == '>' {
+			tok.end = i + 1
+			return tok, true
+		}
+
+		nameStart := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' && src[i] != '/' {
+			i++
+		}
+		a := attr{name: strings.ToLower(src[nameStart:i]), valueStart: i, valueEnd: i}
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i < len(src) && src[i] == '=' {
+			i++
+			for i < len(src) && isSpace(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') {
+				quote := src[i]
+				end := strings.IndexByte(src[i+1:], quote)
+				if end < 0 {
+					return token{}, false
+				}
+				a.valueStart, a.valueEnd = i+1, i+1+end
+				i += end + 2
+			} else {
+				a.valueStart = i
+				for i < len(src) && !isSpace(src[i]) && src[i] != '>' {
+					i++
+				}
+				a.valueEnd = i
+			}
+			a.value = src[a.valueStart:a.valueEnd]
+		}
+		tok.attrs = append(tok.attrs, a)
+	}
+	return token{}, false
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9') || c == '-' || c == ':'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,86 @@
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+// InlineImages finds <img src> references to local files in an HTML body,
+// loads the files as inline attachments and rewrites the references to
+// cid: URLs. Relative paths are resolved against baseDir. Remote, data:
+// and cid: URLs are left untouched. An image referenced several times is
+// attached once.
+func InlineImages(body, baseDir string) (string, []mail.FileAttachment, error) {
+	var b strings.Builder
+	var images []mail.FileAttachment
+	contentIDs := map[string]string{}
+	last := 0
+
+	for _, tok := range tokenize(body) {
+		if tok.tag != "img" || tok.closing {
+			continue
+		}
+		src, ok := tok.get("src")
+		if !ok {
+			continue
+		}
+		path, ok := localImagePath(html.UnescapeString(src.value), baseDir)
+		if !ok {
+			continue
+		}
+
+		cid, seen := contentIDs[path]
+		if !seen {
+			loaded, err := mail.LoadAttachments([]string{path})
+			if err != nil {
+				return "", nil, fmt.Errorf("inline image: %w", err)
+			}
+			image := loaded[0]
+			sum := sha256.Sum256(image.Content)
+			image.ContentID = fmt.Sprintf("image%d.%s@o365-mail-cli", len(images)+1, hex.EncodeToString(sum[:4]))
+			images = append(images, image)
+			cid = image.ContentID
+			contentIDs[path] = cid
+		}
+
+		b.WriteString(body[last:src.valueStart])
+		b.WriteString("cid:" + cid)
+		last = src.valueEnd
+	}
+
+	if len(images) == 0 {
+		return body, nil, nil
+	}
+	b.WriteString(body[last:])
+	return b.String(), images, nil
+}
+
+// localImagePath resolves an image source to a file path, reporting false
+// for URLs that are not local files
+func localImagePath(src, baseDir string) (string, bool) {
+	src = strings.TrimSpace(src)
+	if src == "" || strings.HasPrefix(src, "//") {
+		return "", false
+	}
+
+	if u, err := url.Parse(src); err == nil && len(u.Scheme) > 1 {
+		// A one-letter scheme is a Windows drive letter
+		if !strings.EqualFold(u.Scheme, "file") {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
+	}
+
+	path := filepath.FromSlash(src)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return path, true
+}
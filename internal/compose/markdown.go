@@ -0,0 +1,32 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders CommonMark with the GitHub extensions (tables,
+// strikethrough, autolinks, task lists). Raw HTML is not rendered and
+// javascript:, vbscript:, file: and data: links are dropped.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// Markdown renders a Markdown body as HTML for sending. The result is a
+// fragment wrapped in a <div>, styled with theme as inline style
+// attributes since many mail clients ignore <style> elements. A nil theme
+// leaves the HTML unstyled.
+func Markdown(src string, theme *Theme) (string, error) {
+	var b bytes.Buffer
+	b.WriteString("<div>\n")
+	if err := markdown.Convert([]byte(src), &b); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	b.WriteString("</div>\n")
+
+	if theme == nil {
+		return b.String(), nil
+	}
+	return theme.Apply(b.String()), nil
+}
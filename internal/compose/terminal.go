@@ -0,0 +1,678 @@
+package compose
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RenderOptions controls how message bodies are rendered for the terminal
+type RenderOptions struct {
+	// Width wraps lines at this many characters (0 = no wrapping)
+	Width int
+	// ShowQuoted keeps quoted replies instead of collapsing them
+	ShowQuoted bool
+	// InlineLinks writes link targets in parentheses after the link text
+	// instead of numbering them as footnotes
+	InlineLinks bool
+}
+
+// quotedHidden replaces collapsed quoted text
+const quotedHidden = "[quoted text hidden]"
+
+// RenderHTML converts an HTML body to wrapped text for reading in a
+// terminal. Links are numbered and listed as footnotes unless
+// opts.InlineLinks is set, tables are laid out in aligned columns when they
+// fit and as paragraphs otherwise, and quoted replies are collapsed unless
+// opts.ShowQuoted is set.
+func RenderHTML(body string, opts RenderOptions) string {
+	r := &renderer{opts: opts, linkIndex: map[string]int{}}
+	r.cur = &r.inline
+	for _, tok := range tokenize(body) {
+		if r.stop {
+			break
+		}
+		r.token(tok)
+	}
+	r.flush()
+
+	lines := r.out
+	if !opts.ShowQuoted {
+		lines = collapseQuoted(lines)
+	}
+	return finish(lines, r.links)
+}
+
+// RenderText wraps a plain-text body for the terminal. Unless
+// opts.ShowQuoted is set, runs of "> " lines and forwarded originals
+// below an Outlook-style header are collapsed.
+func RenderText(body string, opts RenderOptions) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	if !opts.ShowQuoted {
+		lines = collapseQuoted(lines)
+	}
+
+	var out []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		prefix := quotePrefix(line)
+		out = append(out, wrap(line[len(prefix):], opts.Width, prefix, prefix)...)
+	}
+	return finish(out, nil)
+}
+
+// listState is an open <ul> or <ol>
+type listState struct {
+	ordered bool
+	n       int
+}
+
+// tableState collects the cells of an open <table>
+type tableState struct {
+	rows   [][]string
+	header []bool
+	cell   *strings.Builder
+	outer  *strings.Builder
+}
+
+type renderer struct {
+	opts RenderOptions
+	out  []string
+
+	// cur receives text: the paragraph being built or the open table cell
+	cur    *strings.Builder
+	inline strings.Builder
+	// bullet is the first-line prefix of a just opened list item
+	bullet string
+
+	links     []string
+	linkIndex map[string]int
+	href      string
+	linkStart int
+
+	lists      []listState
+	tables     []*tableState
+	heading    int
+	pre        int
+	skip       int
+	quoteDepth int
+	stop       bool
+}
+
+func (r *renderer) token(tok token) {
+	if tok.tag == "" {
+		if r.skip > 0 || (r.quoteDepth > 0 && !r.opts.ShowQuoted) {
+			return
+		}
+		text := html.UnescapeString(tok.text)
+		if r.pre == 0 {
+			text = strings.Map(func(c rune) rune {
+				if c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == ' ' {
+					return ' '
+				}
+				return c
+			}, text)
+		}
+		r.cur.WriteString(text)
+		return
+	}
+
+	tag := tok.tag
+	switch tag {
+	case "head", "script", "style", "title":
+		// stray closing tags are common in mail HTML; ignoring them keeps
+		// a later <style> or <script> hidden
+		if tok.closing {
+			if r.skip > 0 {
+				r.skip--
+			}
+		} else {
+			r.skip++
+		}
+		return
+	}
+	if r.skip > 0 {
+		return
+	}
+
+	if tag == "blockquote" && !r.opts.ShowQuoted {
+		if !tok.closing {
+			if r.quoteDepth == 0 {
+				r.block(true)
+				r.emit(quotedHidden)
+				r.blank()
+			}
+			r.quoteDepth++
+		} else if r.quoteDepth > 0 {
+			r.quoteDepth--
+		}
+		return
+	}
+	if r.quoteDepth > 0 && !r.opts.ShowQuoted {
+		return
+	}
+	if !tok.closing && !r.opts.ShowQuoted && isReplyMarker(tok) {
+		r.block(true)
+		r.emit(quotedHidden)
+		r.stop = true
+		return
+	}
+
+	switch tag {
+	case "br":
+		if r.inCell() {
+			r.cur.WriteString("\n")
+		} else {
+			r.flush()
+		}
+	case "hr":
+		r.block(false)
+		r.emit(strings.Repeat("─", r.ruleWidth()))
+	case "img":
+		if a, ok := tok.get("alt"); ok && strings.TrimSpace(a.value) != "" {
+			r.cur.WriteString("[" + html.UnescapeString(strings.TrimSpace(a.value)) + "]")
+		}
+	case "a":
+		r.link(tok)
+	case "blockquote":
+		if tok.closing {
+			r.block(false)
+			// the blank line after the last quoted paragraph goes outside the quote
+			if n := len(r.out); n > 0 && r.out[n-1] != "" && strings.TrimRight(r.out[n-1], "> ") == "" {
+				r.out = r.out[:n-1]
+			}
+			if r.quoteDepth > 0 {
+				r.quoteDepth--
+			}
+			r.blank()
+		} else {
+			r.block(true)
+			r.quoteDepth++
+		}
+	case "pre":
+		r.block(true)
+		if tok.closing {
+			r.pre--
+		} else {
+			r.pre++
+		}
+	case "ul", "ol":
+		if tok.closing {
+			r.block(len(r.lists) == 1)
+			if len(r.lists) > 0 {
+				r.lists = r.lists[:len(r.lists)-1]
+			}
+		} else {
+			r.block(len(r.lists) == 0)
+			r.lists = append(r.lists, listState{ordered: tag == "ol"})
+		}
+	case "li":
+		r.block(false)
+		if !tok.closing && len(r.lists) > 0 {
+			list := &r.lists[len(r.lists)-1]
+			list.n++
+			r.bullet = "• "
+			if list.ordered {
+				r.bullet = strconv.Itoa(list.n) + ". "
+			}
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if tok.closing && !r.inCell() {
+			r.flushHeading()
+			return
+		}
+		r.block(true)
+		if !r.inCell() {
+			r.heading = int(tag[1] - '0')
+		}
+	case "table":
+		r.table(tok)
+	case "tr":
+		if t := r.currentTable(); t != nil && !tok.closing {
+			r.closeCell()
+			t.rows = append(t.rows, nil)
+			t.header = append(t.header, false)
+		}
+	case "td", "th":
+		if t := r.currentTable(); t != nil {
+			r.closeCell()
+			if !tok.closing {
+				if len(t.rows) == 0 {
+					t.rows = append(t.rows, nil)
+					t.header = append(t.header, false)
+				}
+				t.cell = &strings.Builder{}
+				r.cur = t.cell
+				if tag == "th" {
+					t.header[len(t.header)-1] = true
+				}
+			}
+		}
+	case "p", "div", "dl", "dt", "dd", "section", "article", "header", "footer", "address", "center", "form":
+		r.block(tag == "p")
+	}
+}
+
+// isReplyMarker reports whether tok starts the quoted original in
+// messages replied to or forwarded from Outlook or Gmail
+func isReplyMarker(tok token) bool {
+	if id, ok := tok.get("id"); ok {
+		v := strings.ToLower(id.value)
+		if strings.HasSuffix(v, "divrplyfwdmsg") || strings.HasSuffix(v, "appendonsend") {
+			return true
+		}
+	}
+	if class, ok := tok.get("class"); ok {
+		for _, c := range strings.Fields(class.value) {
+			if c == "gmail_quote" || c == "moz-cite-prefix" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// link numbers a link when it closes, or adds its target inline, unless its
+// text already shows the URL
+func (r *renderer) link(tok token) {
+	if !tok.closing {
+		r.href = ""
+		if a, ok := tok.get("href"); ok {
+			r.href = html.UnescapeString(strings.TrimSpace(a.value))
+		}
+		r.linkStart = r.cur.Len()
+		return
+	}
+
+	href := r.href
+	r.href = ""
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "cid:") {
+		return
+	}
+	text := ""
+	if r.linkStart <= r.cur.Len() {
+		text = strings.TrimSpace(r.cur.String()[r.linkStart:])
+	}
+	target := strings.TrimPrefix(href, "mailto:")
+	if text == href || text == target {
+		return
+	}
+	if r.opts.InlineLinks {
+		fmt.Fprintf(r.cur, " (%s)", target)
+		return
+	}
+
+	n, ok := r.linkIndex[href]
+	if !ok {
+		r.links = append(r.links, href)
+		n = len(r.links)
+		r.linkIndex[href] = n
+	}
+	fmt.Fprintf(r.cur, "[%d]", n)
+}
+
+func (r *renderer) table(tok token) {
+	if !tok.closing {
+		if r.inCell() {
+			r.cur.WriteString("\n")
+		} else {
+			r.block(true)
+		}
+		r.tables = append(r.tables, &tableState{outer: r.cur})
+		return
+	}
+
+	t := r.currentTable()
+	if t == nil {
+		return
+	}
+	r.closeCell()
+	r.tables = r.tables[:len(r.tables)-1]
+	r.cur = t.outer
+
+	lines := r.layoutTable(t)
+	if r.inCell() {
+		r.cur.WriteString("\n" + strings.Join(lines, "\n") + "\n")
+		return
+	}
+	for _, line := range lines {
+		r.emit(r.prefix() + r.indent() + line)
+	}
+	r.blank()
+}
+
+func (r *renderer) currentTable() *tableState {
+	if len(r.tables) == 0 {
+		return nil
+	}
+	return r.tables[len(r.tables)-1]
+}
+
+func (r *renderer) inCell() bool {
+	t := r.currentTable()
+	return t != nil && t.cell != nil
+}
+
+// closeCell adds the open cell, if any, to the current row
+func (r *renderer) closeCell() {
+	t := r.currentTable()
+	if t == nil || t.cell == nil {
+		return
+	}
+	var lines []string
+	for _, line := range strings.Split(t.cell.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	t.rows[len(t.rows)-1] = append(t.rows[len(t.rows)-1], strings.Join(lines, "\n"))
+	t.cell = nil
+	r.cur = t.outer
+}
+
+// layoutTable aligns single-line cells in columns if they fit the width;
+// layout tables and tables too wide become one paragraph per cell
+func (r *renderer) layoutTable(t *tableState) []string {
+	var rows [][]string
+	var header []bool
+	cols := 0
+	for i, row := range t.rows {
+		empty := true
+		for _, cell := range row {
+			empty = empty && cell == ""
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, row)
+		header = append(header, t.header[i])
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+
+	widths := make([]int, cols)
+	aligned := cols >= 2
+	for _, row := range rows {
+		for i, cell := range row {
+			if strings.Contains(cell, "\n") {
+				aligned = false
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	total := 2 * (cols - 1)
+	for _, w := range widths {
+		total += w
+	}
+	if r.opts.Width > 0 && total > r.opts.Width-len(r.prefix()) {
+		aligned = false
+	}
+
+	var lines []string
+	if !aligned {
+		for _, row := range rows {
+			for _, cell := range row {
+				for _, para := range strings.Split(cell, "\n") {
+					if para != "" {
+						lines = append(lines, wrap(para, r.opts.Width-len(r.prefix()), "", "")...)
+					}
+				}
+			}
+		}
+		return lines
+	}
+
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+		if header[i] && i == 0 {
+			rules := make([]string, cols)
+			for j, w := range widths {
+				rules[j] = strings.Repeat("─", w)
+			}
+			lines = append(lines, strings.Join(rules, "  "))
+		}
+	}
+	return lines
+}
+
+// prefix returns the quote markers of the current position
+func (r *renderer) prefix() string {
+	return strings.Repeat("> ", r.quoteDepth)
+}
+
+// indent returns the list indentation of the current position
+func (r *renderer) indent() string {
+	if len(r.lists) == 0 {
+		return ""
+	}
+	return strings.Repeat("  ", len(r.lists)-1)
+}
+
+// flush wraps and emits the paragraph collected so far
+func (r *renderer) flush() {
+	if r.inCell() {
+		return
+	}
+	text := r.inline.String()
+	r.inline.Reset()
+
+	prefix := r.prefix() + r.indent()
+	if r.pre > 0 {
+		if text = strings.Trim(text, "\n"); text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				r.emit(prefix + strings.TrimRight(line, " \t\r"))
+			}
+		}
+		return
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+	first, rest := prefix, prefix
+	if r.bullet != "" {
+		first = prefix + r.bullet
+		rest = prefix + strings.Repeat(" ", utf8.RuneCountInString(r.bullet))
+		r.bullet = ""
+	} else if len(r.lists) > 0 {
+		first, rest = prefix+"  ", prefix+"  "
+	}
+	r.out = append(r.out, wrap(text, r.opts.Width, first, rest)...)
+}
+
+// flushHeading emits a heading, underlining the top two levels
+func (r *renderer) flushHeading() {
+	level := r.heading
+	r.heading = 0
+	text := strings.Join(strings.Fields(r.inline.String()), " ")
+	r.inline.Reset()
+	if text == "" {
+		return
+	}
+
+	lines := wrap(text, r.opts.Width, r.prefix(), r.prefix())
+	r.out = append(r.out, lines...)
+	if level <= 2 {
+		underline := "═"
+		if level == 2 {
+			underline = "─"
+		}
+		width := 0
+		for _, line := range lines {
+			if n := utf8.RuneCountInString(line) - len(r.prefix()); n > width {
+				width = n
+			}
+		}
+		r.emit(r.prefix() + strings.Repeat(underline, width))
+	}
+	r.blank()
+}
+
+// block ends the current paragraph, separating it by a blank line if para
+func (r *renderer) block(para bool) {
+	if r.inCell() {
+		r.cur.WriteString("\n")
+		return
+	}
+	if r.heading > 0 {
+		r.flushHeading()
+	}
+	r.flush()
+	if para {
+		r.blank()
+	}
+}
+
+func (r *renderer) emit(line string) {
+	r.out = append(r.out, line)
+}
+
+// blank adds an empty line unless there is one already
+func (r *renderer) blank() {
+	if len(r.out) > 0 && strings.TrimSpace(strings.TrimRight(r.out[len(r.out)-1], "> ")) != "" {
+		r.out = append(r.out, strings.TrimRight(r.prefix(), " "))
+	}
+}
+
+func (r *renderer) ruleWidth() int {
+	if r.opts.Width > 0 && r.opts.Width < 40 {
+		return r.opts.Width
+	}
+	return 40
+}
+
+// wrap breaks text into lines of at most width characters including the
+// prefixes. Words longer than a line, such as URLs, are not broken.
+func wrap(text string, width int, first, rest string) []string {
+	if width <= 0 || utf8.RuneCountInString(first+text) <= width {
+		return []string{first + text}
+	}
+
+	var lines []string
+	line := first
+	empty := true
+	for _, word := range strings.Fields(text) {
+		if !empty && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line, empty = rest, true
+		}
+		if !empty {
+			line += " "
+		}
+		line += word
+		empty = false
+	}
+	return append(lines, line)
+}
+
+// quotePrefix returns the leading "> " markers of a plain-text line
+func quotePrefix(line string) string {
+	i := 0
+	for i < len(line) && line[i] == '>' {
+		i++
+		if i < len(line) && line[i] == ' ' {
+			i++
+		}
+	}
+	return line[:i]
+}
+
+// originalHeader starts the quoted original of Outlook replies and forwards
+var originalHeader = regexp.MustCompile(`^(-{3,} ?Original Message ?-{3,}|_{10,})$`)
+
+// collapseQuoted replaces runs of "> " lines by a marker and drops
+// everything from an Outlook "Original Message" separator or a From:/Sent:
+// header block onwards
+func collapseQuoted(lines []string) []string {
+	var out []string
+	quoted := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if originalHeader.MatchString(trimmed) || isOutlookHeader(lines[i:]) {
+			for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+				out = out[:len(out)-1]
+			}
+			return append(out, "", quotedHidden)
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			quoted++
+			continue
+		}
+		if quoted > 0 {
+			out = append(out, fmt.Sprintf("[%d quoted line(s) hidden]", quoted))
+			quoted = 0
+		}
+		out = append(out, line)
+	}
+	if quoted > 0 {
+		out = append(out, fmt.Sprintf("[%d quoted line(s) hidden]", quoted))
+	}
+	return out
+}
+
+// isOutlookHeader reports whether lines start with "From:" followed by
+// "Sent:" within the next lines, as in replies written with Outlook
+func isOutlookHeader(lines []string) bool {
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "From:") {
+		return false
+	}
+	for i := 1; i < len(lines) && i < 4; i++ {
+		next := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(next, "Sent:") || strings.HasPrefix(next, "Date:") {
+			return true
+		}
+	}
+	return false
+}
+
+// footnoteRef matches link markers left in the rendered text
+var footnoteRef = regexp.MustCompile(`\[(\d+)\]`)
+
+// finish joins the lines, trims blank runs and appends the links that are
+// still referenced after collapsing quoted text
+func finish(lines []string, links []string) string {
+	var b strings.Builder
+	blank := true
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if strings.TrimSpace(line) == "" {
+			if !blank {
+				b.WriteString("\n")
+			}
+			blank = true
+			continue
+		}
+		b.WriteString(line + "\n")
+		blank = false
+	}
+	text := strings.TrimRight(b.String(), "\n") + "\n"
+
+	used := 0
+	for _, m := range footnoteRef.FindAllStringSubmatch(text, -1) {
+		if n, _ := strconv.Atoi(m[1]); n <= len(links) && n > used {
+			used = n
+		}
+	}
+	if used == 0 {
+		return text
+	}
+
+	text += "\nLinks:\n"
+	for i, link := range links[:used] {
+		text += fmt.Sprintf("[%d] %s\n", i+1, link)
+	}
+	return text
+}
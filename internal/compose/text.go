@@ -0,0 +1,8 @@
+package compose
+
+// HTMLToText renders an HTML body as plain text for the text alternative of
+// a message and for quoting it in a reply. It is RenderHTML without wrapping,
+// with link targets in parentheses after the link text and quoted text kept.
+func HTMLToText(body string) string {
+	return RenderHTML(body, RenderOptions{InlineLinks: true, ShowQuoted: true})
+}
@@ -0,0 +1,163 @@
+package compose
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// defaultCSS is the theme used unless markdown_css is configured
+const defaultCSS = `
+div { font-family: Calibri, Arial, Helvetica, sans-serif; font-size: 11pt; line-height: 1.4; color: #1f1f1f; }
+h1, h2, h3, h4, h5, h6 { margin: 16px 0 8px; font-weight: 600; }
+h1 { font-size: 20pt; }
+h2 { font-size: 16pt; }
+h3 { font-size: 13pt; }
+p, ul, ol, blockquote, pre, table { margin: 0 0 12px; }
+a { color: #0563c1; }
+blockquote { padding: 0 12px; border-left: 3px solid #d0d0d0; color: #555555; }
+code { font-family: Consolas, Menlo, monospace; font-size: 10pt; background-color: #f3f3f3; }
+pre { padding: 8px 12px; background-color: #f3f3f3; overflow-x: auto; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 8px; border: 1px solid #d0d0d0; }
+th { background-color: #f3f3f3; text-align: left; }
+`
+
+// Theme is a CSS style sheet reduced to declarations per element name
+type Theme struct {
+	rules map[string]string
+}
+
+// DefaultTheme returns the built-in theme for rendered Markdown
+func DefaultTheme() *Theme {
+	theme, err := ParseTheme(defaultCSS)
+	if err != nil {
+		panic(err)
+	}
+	return theme
+}
+
+// LoadTheme reads a theme from a CSS file. An empty path selects the
+// default theme and "none" disables styling.
+func LoadTheme(path string) (*Theme, error) {
+	switch path {
+	case "":
+		return DefaultTheme(), nil
+	case "none":
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read theme: %w", err)
+	}
+	theme, err := ParseTheme(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// ParseTheme parses CSS rules with element selectors such as "h1, h2 { ... }".
+// Rules with class, id or compound selectors cannot be inlined and are
+// ignored; div styles the element wrapping the message.
+func ParseTheme(css string) (*Theme, error) {
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated comment")
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+
+	theme := &Theme{rules: map[string]string{}}
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			if strings.TrimSpace(css) != "" {
+				return nil, fmt.Errorf("expected '{' after %q", strings.TrimSpace(css))
+			}
+			return theme, nil
+		}
+		end := strings.IndexByte(css[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("missing '}' after %q", strings.TrimSpace(css[:open]))
+		}
+		selectors, decls := css[:open], normalizeDeclarations(css[open+1:open+end])
+		css = css[open+end+1:]
+
+		if decls == "" {
+			continue
+		}
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.ToLower(strings.TrimSpace(sel))
+			if !isElementSelector(sel) {
+				continue
+			}
+			if prev := theme.rules[sel]; prev != "" {
+				decls = prev + "; " + decls
+			}
+			theme.rules[sel] = decls
+		}
+	}
+}
+
+// normalizeDeclarations turns "a: b; c:d;" into "a: b; c: d"
+func normalizeDeclarations(block string) string {
+	var decls []string
+	for _, decl := range strings.Split(block, ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		name, value = strings.TrimSpace(name), strings.Join(strings.Fields(value), " ")
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		decls = append(decls, strings.ToLower(name)+": "+value)
+	}
+	return strings.Join(decls, "; ")
+}
+
+func isElementSelector(sel string) bool {
+	if sel == "" || !isLetter(sel[0]) {
+		return false
+	}
+	for i := 0; i < len(sel); i++ {
+		if !isLetter(sel[i]) && !(sel[i] >= '0' && sel[i] <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply adds the theme's declarations to the style attribute of every
+// matching start tag. Existing style attributes take precedence.
+func (t *Theme) Apply(body string) string {
+	var b strings.Builder
+	last := 0
+	for _, tok := range tokenize(body) {
+		if tok.tag == "" || tok.closing {
+			continue
+		}
+		decls, ok := t.rules[tok.tag]
+		if !ok {
+			continue
+		}
+
+		if style, ok := tok.get("style"); ok && style.valueEnd > style.valueStart {
+			b.WriteString(body[last:style.valueStart])
+			b.WriteString(html.EscapeString(decls) + "; ")
+			last = style.valueStart
+			continue
+		}
+		nameEnd := tok.start + 1 + len(tok.tag)
+		b.WriteString(body[last:nameEnd])
+		b.WriteString(` style="` + html.EscapeString(decls) + `"`)
+		last = nameEnd
+	}
+	b.WriteString(body[last:])
+	return b.String()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/spf13/viper"
@@ -22,6 +23,21 @@ type Config struct {
 	CurrentAccount string `mapstructure:"current_account"`
 	CacheDir       string `mapstructure:"cache_dir"`
 	Debug          bool   `mapstructure:"debug"`
+
+	// GraphURL overrides the Microsoft Graph endpoint (national clouds, test servers)
+	GraphURL  string `mapstructure:"graph_url"`
+	UserAgent string `mapstructure:"user_agent"`
+
+	// Retry policy for throttled Graph requests (zero = built-in default)
+	RetryMaxAttempts int           `mapstructure:"retry_max_attempts"`
+	RetryBaseDelay   time.Duration `mapstructure:"retry_base_delay"`
+	RetryMaxDelay    time.Duration `mapstructure:"retry_max_delay"`
+
+	// MarkdownCSS is a CSS file styling --markdown bodies ("none" = unstyled)
+	MarkdownCSS string `mapstructure:"markdown_css"`
+
+	// AccessToken bypasses the OAuth flow when set (O365_ACCESS_TOKEN only, never saved)
+	AccessToken string `mapstructure:"-"`
 }
 
 // Account represents a logged-in O365 account
@@ -73,6 +89,12 @@ func Load() (*Config, error) {
 	viper.SetDefault("current_account", cfg.CurrentAccount)
 	viper.SetDefault("cache_dir", cfg.CacheDir)
 	viper.SetDefault("debug", cfg.Debug)
+	viper.SetDefault("graph_url", cfg.GraphURL)
+	viper.SetDefault("user_agent", cfg.UserAgent)
+	viper.SetDefault("retry_max_attempts", cfg.RetryMaxAttempts)
+	viper.SetDefault("retry_base_delay", cfg.RetryBaseDelay)
+	viper.SetDefault("retry_max_delay", cfg.RetryMaxDelay)
+	viper.SetDefault("markdown_css", cfg.MarkdownCSS)
 
 	// Read config file (if exists)
 	if err := viper.ReadInConfig(); err != nil {
@@ -87,6 +109,8 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("error unmarshalling config: %w", err)
 	}
 
+	cfg.AccessToken = os.Getenv("O365_ACCESS_TOKEN")
+
 	return cfg, nil
 }
 
@@ -109,6 +133,12 @@ func Save(cfg *Config) error {
 	viper.Set("current_account", cfg.CurrentAccount)
 	viper.Set("cache_dir", cfg.CacheDir)
 	viper.Set("debug", cfg.Debug)
+	viper.Set("graph_url", cfg.GraphURL)
+	viper.Set("user_agent", cfg.UserAgent)
+	viper.Set("retry_max_attempts", cfg.RetryMaxAttempts)
+	viper.Set("retry_base_delay", cfg.RetryBaseDelay.String())
+	viper.Set("retry_max_delay", cfg.RetryMaxDelay.String())
+	viper.Set("markdown_css", cfg.MarkdownCSS)
 
 	// Save
 	configPath := filepath.Join(configDir, ConfigFileName+".yaml")
@@ -137,6 +167,28 @@ func SetValue(key, value string) error {
 		cfg.ClientID = value
 	case "current_account":
 		cfg.CurrentAccount = value
+	case "graph_url":
+		cfg.GraphURL = value
+	case "user_agent":
+		cfg.UserAgent = value
+	case "retry_max_attempts":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		cfg.RetryMaxAttempts = n
+	case "retry_base_delay", "retry_max_delay":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		if key == "retry_base_delay" {
+			cfg.RetryBaseDelay = d
+		} else {
+			cfg.RetryMaxDelay = d
+		}
+	case "markdown_css":
+		cfg.MarkdownCSS = value
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
@@ -158,6 +210,18 @@ func GetValue(key string) (string, error) {
 		return cfg.CurrentAccount, nil
 	case "cache_dir":
 		return cfg.CacheDir, nil
+	case "graph_url":
+		return cfg.GraphURL, nil
+	case "user_agent":
+		return cfg.UserAgent, nil
+	case "retry_max_attempts":
+		return strconv.Itoa(cfg.RetryMaxAttempts), nil
+	case "retry_base_delay":
+		return cfg.RetryBaseDelay.String(), nil
+	case "retry_max_delay":
+		return cfg.RetryMaxDelay.String(), nil
+	case "markdown_css":
+		return cfg.MarkdownCSS, nil
 	default:
 		return "", fmt.Errorf("unknown config key: %s", key)
 	}
@@ -0,0 +1,284 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxInlineAttachmentSize is the largest attachment sent within the message
+	// request. Larger files are uploaded in chunks through an upload session.
+	MaxInlineAttachmentSize = 3 * 1024 * 1024
+
+	// MaxAttachmentsSize is the Graph limit for all attachments of one message
+	MaxAttachmentsSize = 150 * 1024 * 1024
+
+	// uploadChunkSize must be a multiple of 320 KiB and below the 4 MB request limit
+	uploadChunkSize = 10 * 320 * 1024
+)
+
+// FileAttachment is a file to attach to an outgoing message
+type FileAttachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+	// ContentID makes the attachment an inline image referenced as cid:<ContentID>
+	ContentID string
+}
+
+// LoadAttachments reads files to attach and detects their content types.
+// The total size is checked before any file is read.
+func LoadAttachments(paths []string) ([]FileAttachment, error) {
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("cannot attach %s: is a directory", path)
+		}
+		total += info.Size()
+	}
+	if err := checkAttachmentsSize(total); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]FileAttachment, 0, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+		}
+		name := filepath.Base(path)
+		attachments = append(attachments, FileAttachment{
+			Name:        name,
+			ContentType: detectContentType(name, content),
+			Content:     content,
+		})
+	}
+	return attachments, nil
+}
+
+// detectContentType uses the file extension, falling back to content sniffing
+func detectContentType(name string, content []byte) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); t != "" {
+		return t
+	}
+	return http.DetectContentType(content)
+}
+
+func checkAttachmentsSize(total int64) error {
+	if total > MaxAttachmentsSize {
+		return fmt.Errorf("attachments total %.1f MB, the limit is %d MB per message",
+			float64(total)/(1024*1024), MaxAttachmentsSize/(1024*1024))
+	}
+	return nil
+}
+
+// attachmentsSize returns the combined size of attachments in bytes
+func attachmentsSize(attachments []FileAttachment) int64 {
+	var total int64
+	for _, att := range attachments {
+		total += int64(len(att.Content))
+	}
+	return total
+}
+
+// canInlineAttachments reports whether attachments fit into the message request itself
+func canInlineAttachments(attachments []FileAttachment) bool {
+	return attachmentsSize(attachments) <= MaxInlineAttachmentSize
+}
+
+func fileAttachmentJSON(att FileAttachment) map[string]interface{} {
+	data := map[string]interface{}{
+		"@odata.type":  "#microsoft.graph.fileAttachment",
+		"name":         att.Name,
+		"contentType":  att.ContentType,
+		"contentBytes": base64.StdEncoding.EncodeToString(att.Content),
+	}
+	if att.ContentID != "" {
+		data["isInline"] = true
+		data["contentId"] = att.ContentID
+	}
+	return data
+}
+
+// addAttachments attaches files to an existing message, uploading large
+// files through upload sessions
+func (c *GraphClient) addAttachments(ctx context.Context, messageID string, attachments []FileAttachment) error {
+	for _, att := range attachments {
+		if len(att.Content) > MaxInlineAttachmentSize {
+			if err := c.uploadAttachment(ctx, messageID, att); err != nil {
+				return err
+			}
+			continue
+		}
+
+		body, err := json.Marshal(fileAttachmentJSON(att))
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		endpoint := c.baseURL + messagePath("", messageID) + "/attachments"
+		if _, err := c.doRequest(ctx, "POST", endpoint, body); err != nil {
+			return fmt.Errorf("failed to attach %s: %w", att.Name, err)
+		}
+	}
+	return nil
+}
+
+// uploadSession is the state of an attachment upload reported by Graph
+type uploadSession struct {
+	UploadURL          string   `json:"uploadUrl"`
+	NextExpectedRanges []string `json:"nextExpectedRanges"`
+}
+
+// uploadAttachment uploads a large file in chunks. A failed chunk is resumed
+// from the offset the upload session still expects, so chunks that arrived
+// before the failure are not sent again.
+func (c *GraphClient) uploadAttachment(ctx context.Context, messageID string, att FileAttachment) error {
+	size := int64(len(att.Content))
+	item := map[string]interface{}{
+		"attachmentType": "file",
+		"name":           att.Name,
+		"size":           size,
+		"contentType":    att.ContentType,
+	}
+	if att.ContentID != "" {
+		item["isInline"] = true
+		item["contentId"] = att.ContentID
+	}
+	body, err := json.Marshal(map[string]interface{}{"AttachmentItem": item})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := c.baseURL + messagePath("", messageID) + "/attachments/createUploadSession"
+	resp, err := c.doRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to start upload of %s: %w", att.Name, err)
+	}
+	var session uploadSession
+	if err := json.Unmarshal(resp, &session); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var offset int64
+	for failures := 0; offset < size; {
+		end := offset + uploadChunkSize
+		if end > size {
+			end = size
+		}
+
+		next, err := c.uploadChunk(ctx, session.UploadURL, att.Content[offset:end], offset, size)
+		if err == nil {
+			// MaxAttempts limits consecutive failures, not those of the whole upload
+			offset, failures = next, 0
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failures++
+		if failures >= c.retry.MaxAttempts {
+			return fmt.Errorf("failed to upload %s: %w", att.Name, err)
+		}
+		wait := c.retry.backoff(failures, "")
+		c.logf("upload of %s failed at byte %d (%v), resuming in %s (attempt %d/%d)", att.Name, offset, err, wait, failures+1, c.retry.MaxAttempts)
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+
+		// The failed chunk may have arrived, so ask where to continue
+		if next, err := c.uploadStatus(ctx, session.UploadURL); err == nil {
+			offset = next
+		}
+	}
+	return nil
+}
+
+// uploadChunk sends content starting at offset and returns the next offset
+// the session expects (size once the upload is complete)
+func (c *GraphClient) uploadChunk(ctx context.Context, uploadURL string, chunk []byte, offset, size int64) (int64, error) {
+	contentRange := fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, size)
+	resp, status, err := c.doUploadRequest(ctx, "PUT", uploadURL, contentRange, chunk)
+	if err != nil {
+		return 0, err
+	}
+
+	switch status {
+	case http.StatusCreated:
+		return size, nil
+	case http.StatusOK:
+		var session uploadSession
+		if err := json.Unmarshal(resp, &session); err != nil {
+			return 0, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return parseNextExpectedRange(session.NextExpectedRanges)
+	}
+	return 0, newGraphError(status, resp)
+}
+
+// uploadStatus returns the offset an upload session expects next
+func (c *GraphClient) uploadStatus(ctx context.Context, uploadURL string) (int64, error) {
+	resp, status, err := c.doUploadRequest(ctx, "GET", uploadURL, "", nil)
+	if err != nil {
+		return 0, err
+	}
+	if status != http.StatusOK {
+		return 0, newGraphError(status, resp)
+	}
+
+	var session uploadSession
+	if err := json.Unmarshal(resp, &session); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parseNextExpectedRange(session.NextExpectedRanges)
+}
+
+// parseNextExpectedRange returns the start of the first range, e.g. 3276800 for "3276800-"
+func parseNextExpectedRange(ranges []string) (int64, error) {
+	if len(ranges) == 0 {
+		return 0, fmt.Errorf("upload session reported no expected ranges")
+	}
+	start, _, _ := strings.Cut(ranges[0], "-")
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expected range %q", ranges[0])
+	}
+	return offset, nil
+}
+
+// doUploadRequest performs a request against a pre-authenticated upload URL.
+// Graph rejects these requests when they carry an Authorization header.
+func (c *GraphClient) doUploadRequest(ctx context.Context, method, uploadURL, contentRange string, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uploadURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if contentRange != "" {
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", contentRange)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	return respBody, resp.StatusCode, nil
+}
@@ -0,0 +1,186 @@
+package mail_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+// largeAttachment returns an attachment that needs an upload session
+func largeAttachment(size int) mail.FileAttachment {
+	content := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+	return mail.FileAttachment{Name: "big.bin", ContentType: "application/octet-stream", Content: content}
+}
+
+func TestLoadAttachments(t *testing.T) {
+	dir := t.TempDir()
+	pdf := filepath.Join(dir, "report.pdf")
+	noExt := filepath.Join(dir, "notes")
+	os.WriteFile(pdf, []byte("%PDF-1.4"), 0600)
+	os.WriteFile(noExt, []byte("plain words"), 0600)
+
+	atts, err := mail.LoadAttachments([]string{pdf, noExt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atts[0].Name != "report.pdf" || atts[0].ContentType != "application/pdf" {
+		t.Errorf("pdf = %s %s", atts[0].Name, atts[0].ContentType)
+	}
+	if !strings.HasPrefix(atts[1].ContentType, "text/plain") {
+		t.Errorf("sniffed content type = %s, want text/plain", atts[1].ContentType)
+	}
+
+	if _, err := mail.LoadAttachments([]string{dir}); err == nil {
+		t.Error("expected error for a directory")
+	}
+	if _, err := mail.LoadAttachments([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestLoadAttachments_TotalSizeLimit(t *testing.T) {
+	// Sparse files: the size check happens before anything is read
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.iso", "b.iso"} {
+		path := filepath.Join(dir, name)
+		f, _ := os.Create(path)
+		f.Truncate(80 * 1024 * 1024)
+		f.Close()
+		paths = append(paths, path)
+	}
+
+	_, err := mail.LoadAttachments(paths)
+	if err == nil || !strings.Contains(err.Error(), "limit is 150 MB") {
+		t.Errorf("err = %v, want total size limit error", err)
+	}
+}
+
+func TestSend_SmallAttachmentsInline(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	err := srv.Client().Send(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Report",
+		Body:        "Attached",
+		Attachments: []mail.FileAttachment{{Name: "r.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || len(sent[0].Attachment) != 1 || string(sent[0].Attachment[0].Content) != "%PDF" {
+		t.Fatalf("sent = %+v", sent)
+	}
+	if n := srv.CountRequests("POST", "/v1.0/me/messages"); n != 0 {
+		t.Errorf("created %d draft(s) for a small attachment", n)
+	}
+}
+
+func TestSend_LargeAttachmentUploadSession(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	big := largeAttachment(7 * 1024 * 1024)
+	small := mail.FileAttachment{Name: "note.txt", ContentType: "text/plain", Content: []byte("hi")}
+
+	err := srv.Client().Send(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Data",
+		Body:        "Attached",
+		Attachments: []mail.FileAttachment{small, big},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || len(sent[0].Attachment) != 2 {
+		t.Fatalf("sent = %d message(s), want 1 with 2 attachments", len(sent))
+	}
+	if got := sent[0].Attachment[1]; got.Name != "big.bin" || !bytes.Equal(got.Content, big.Content) {
+		t.Errorf("uploaded attachment %s has %d bytes, want %d", got.Name, len(got.Content), len(big.Content))
+	}
+	if n := srv.CountRequests("PUT", "/upload/"); n != 3 {
+		t.Errorf("uploaded in %d chunks, want 3", n)
+	}
+	if len(srv.Messages("drafts")) != 0 {
+		t.Error("draft left behind after sending")
+	}
+}
+
+func TestUploadAttachment_ResumesAfterFailure(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(graphtest.Fault{Method: "PUT", PathPrefix: "/upload/", Status: 503, Times: 1})
+	big := largeAttachment(4 * 1024 * 1024)
+
+	id, err := fastRetryClient(srv, 3).SaveDraft(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Data",
+		Body:        "Attached",
+		Attachments: []mail.FileAttachment{big},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	draft, _ := srv.Message(id)
+	if len(draft.Attachment) != 1 || !bytes.Equal(draft.Attachment[0].Content, big.Content) {
+		t.Fatal("attachment not complete after resuming")
+	}
+	if n := srv.CountRequests("GET", "/upload/"); n != 1 {
+		t.Errorf("queried upload status %d time(s), want 1", n)
+	}
+}
+
+func TestUploadAttachment_FailuresInDifferentChunks(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	// The first and the third chunk fail once each
+	srv.InjectFault(graphtest.Fault{Method: "PUT", PathPrefix: "/upload/", Status: 503, Times: 1})
+	srv.InjectFault(graphtest.Fault{Method: "PUT", PathPrefix: "/upload/", Status: 503, Times: 1, After: 2})
+	big := largeAttachment(7 * 1024 * 1024)
+
+	id, err := fastRetryClient(srv, 2).SaveDraft(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Data",
+		Body:        "Attached",
+		Attachments: []mail.FileAttachment{big},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	draft, _ := srv.Message(id)
+	if len(draft.Attachment) != 1 || !bytes.Equal(draft.Attachment[0].Content, big.Content) {
+		t.Fatal("attachment not complete after resuming")
+	}
+	if n := srv.CountRequests("PUT", "/upload/"); n != 5 {
+		t.Errorf("sent %d chunk request(s), want 5", n)
+	}
+}
+
+func TestUploadAttachment_FailureDeletesDraft(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(graphtest.Fault{Method: "PUT", PathPrefix: "/upload/", Status: 503, Times: 10})
+
+	_, err := fastRetryClient(srv, 2).SaveDraft(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Data",
+		Body:        "Attached",
+		Attachments: []mail.FileAttachment{largeAttachment(4 * 1024 * 1024)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to upload big.bin") {
+		t.Fatalf("err = %v, want upload failure", err)
+	}
+	if len(srv.Messages("drafts")) != 0 {
+		t.Error("incomplete draft was not deleted")
+	}
+}
@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"strings"
+)
+
+// AuthResult is the outcome of one sender authentication method as
+// recorded by the receiving server in the Authentication-Results header
+type AuthResult struct {
+	// Method is e.g. "spf", "dkim", "dmarc" or "compauth"
+	Method string `json:"method"`
+	// Result is e.g. "pass", "fail", "softfail" or "none"
+	Result string `json:"result"`
+	// Properties such as "smtp.mailfrom=example.com" or "header.d=example.com"
+	Properties string `json:"properties,omitempty"`
+}
+
+// AuthenticationResults parses the topmost Authentication-Results header,
+// which was added by the receiving mail server (RFC 8601). Comments in
+// parentheses are dropped.
+func AuthenticationResults(headers []MessageHeader) []AuthResult {
+	for _, h := range headers {
+		if !strings.EqualFold(h.Name, "Authentication-Results") {
+			continue
+		}
+		if results := parseAuthResults(h.Value); len(results) > 0 {
+			return results
+		}
+	}
+	return nil
+}
+
+func parseAuthResults(value string) []AuthResult {
+	var results []AuthResult
+	for _, stmt := range strings.Split(stripComments(value), ";") {
+		fields := strings.Fields(stmt)
+		if len(fields) == 0 {
+			continue
+		}
+		// The authserv-id (e.g. "mx.example.com") has no '='
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok || method == "" || result == "" {
+			continue
+		}
+		results = append(results, AuthResult{
+			Method:     strings.ToLower(method),
+			Result:     strings.ToLower(result),
+			Properties: strings.Join(fields[1:], " "),
+		})
+	}
+	return results
+}
+
+// stripComments removes (possibly nested) parenthesized comments
+func stripComments(s string) string {
+	var b strings.Builder
+	depth := 0
+	for _, c := range s {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
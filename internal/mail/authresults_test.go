@@ -0,0 +1,37 @@
+package mail_test
+
+import (
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+func TestAuthenticationResults(t *testing.T) {
+	headers := []mail.MessageHeader{
+		{Name: "Received", Value: "from mx.example.com"},
+		{Name: "Authentication-Results", Value: "spf=pass (sender IP is 192.0.2.1) smtp.mailfrom=example.com; dkim=pass (signature was verified)\r\n header.d=example.com;dmarc=fail action=none header.from=example.com;compauth=pass reason=100"},
+		{Name: "Authentication-Results", Value: "spf=fail smtp.mailfrom=forged.example"},
+	}
+
+	got := mail.AuthenticationResults(headers)
+	want := []mail.AuthResult{
+		{Method: "spf", Result: "pass", Properties: "smtp.mailfrom=example.com"},
+		{Method: "dkim", Result: "pass", Properties: "header.d=example.com"},
+		{Method: "dmarc", Result: "fail", Properties: "action=none header.from=example.com"},
+		{Method: "compauth", Result: "pass", Properties: "reason=100"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// An authserv-id without results is skipped
+	got = mail.AuthenticationResults([]mail.MessageHeader{{Name: "authentication-results", Value: "mx.example.com; none"}})
+	if len(got) != 0 {
+		t.Errorf("got %+v, want no results", got)
+	}
+}
@@ -0,0 +1,289 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// MaxBatchSize is the maximum number of sub-requests Graph accepts per $batch call
+const MaxBatchSize = 20
+
+// BatchRequest is a single sub-request of a JSON batch.
+// URL is relative to the Graph root, e.g. "/me/messages/{id}".
+type BatchRequest struct {
+	// ID identifies the request in DependsOn; defaults to its 1-based position
+	ID     string
+	Method string
+	URL    string
+	Body   interface{}
+	// DependsOn lists IDs of earlier requests that must succeed first
+	DependsOn []string
+}
+
+// BatchResponse is the result of a single sub-request
+type BatchResponse struct {
+	ID     string
+	Status int
+	Body   json.RawMessage
+	// Err is a *GraphError for failed sub-requests
+	Err error
+}
+
+// BulkResult is the outcome of a bulk operation for a single message
+type BulkResult struct {
+	MessageID string
+	Err       error
+}
+
+type batchWireRequest struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      interface{}       `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+type batchWireResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// Batch executes requests through /$batch, MaxBatchSize at a time, and returns
+// one response per request in request order. Throttled sub-requests are retried
+// according to the retry policy. A request whose dependency failed is not sent
+// and fails with 424 Failed Dependency.
+//
+// If the batch call itself fails (e.g. the context is canceled), the error is
+// returned along with the responses; requests that were not executed carry that
+// error and a zero Status.
+func (c *GraphClient) Batch(ctx context.Context, reqs []BatchRequest) ([]BatchResponse, error) {
+	reqs = append([]BatchRequest(nil), reqs...)
+	index := make(map[string]int, len(reqs))
+	for i := range reqs {
+		if reqs[i].ID == "" {
+			reqs[i].ID = strconv.Itoa(i + 1)
+		}
+		if _, dup := index[reqs[i].ID]; dup {
+			return nil, fmt.Errorf("duplicate batch request id '%s'", reqs[i].ID)
+		}
+		for _, dep := range reqs[i].DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("batch request '%s' depends on unknown or later request '%s'", reqs[i].ID, dep)
+			}
+		}
+		index[reqs[i].ID] = i
+	}
+
+	results := make([]BatchResponse, len(reqs))
+	for start := 0; start < len(reqs); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+
+		if err := c.runBatchChunk(ctx, reqs, index, results, start, end); err != nil {
+			for i := start; i < len(reqs); i++ {
+				if results[i].Status == 0 {
+					results[i] = BatchResponse{ID: reqs[i].ID, Err: err}
+				}
+			}
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// runBatchChunk sends reqs[start:end] and fills in their results. Dependencies
+// on requests of earlier chunks are resolved locally since Graph only supports
+// dependsOn within a single batch.
+func (c *GraphClient) runBatchChunk(ctx context.Context, reqs []BatchRequest, index map[string]int, results []BatchResponse, start, end int) error {
+	var pending []batchWireRequest
+	for i := start; i < end; i++ {
+		req := reqs[i]
+
+		var deps []string
+		failed := ""
+		for _, dep := range req.DependsOn {
+			j := index[dep]
+			switch {
+			case results[j].Err != nil:
+				failed = dep
+			case j >= start:
+				deps = append(deps, dep)
+			}
+		}
+		if failed != "" {
+			results[i] = failedDependency(req.ID, failed)
+			continue
+		}
+
+		wire := batchWireRequest{ID: req.ID, Method: req.Method, URL: req.URL, Body: req.Body, DependsOn: deps}
+		if req.Body != nil {
+			wire.Headers = map[string]string{"Content-Type": "application/json"}
+		}
+		pending = append(pending, wire)
+	}
+
+	for attempt := 1; len(pending) > 0; attempt++ {
+		responses, err := c.postBatch(ctx, pending)
+		if err != nil {
+			return err
+		}
+
+		var retry []batchWireRequest
+		retryIDs := map[string]bool{}
+		retryAfter := ""
+		for _, wire := range pending {
+			resp, ok := responses[wire.ID]
+			if !ok {
+				return fmt.Errorf("batch response is missing request '%s'", wire.ID)
+			}
+
+			if attempt < c.retry.MaxAttempts && (shouldRetry(wire.Method, resp.Status) ||
+				(resp.Status == http.StatusFailedDependency && dependsOnAny(wire.DependsOn, retryIDs))) {
+				retry = append(retry, wire)
+				retryIDs[wire.ID] = true
+				if ra := resp.Headers["Retry-After"]; laterRetryAfter(ra, retryAfter) {
+					retryAfter = ra
+				}
+				continue
+			}
+
+			result := BatchResponse{ID: wire.ID, Status: resp.Status, Body: resp.Body}
+			if resp.Status >= 400 {
+				result.Err = newGraphError(resp.Status, resp.Body)
+			}
+			results[index[wire.ID]] = result
+		}
+
+		if len(retry) == 0 {
+			break
+		}
+
+		// Dependencies that succeeded are not part of the retry, and Graph
+		// rejects a batch that names a request outside of it
+		for i := range retry {
+			var deps []string
+			for _, dep := range retry[i].DependsOn {
+				if retryIDs[dep] {
+					deps = append(deps, dep)
+				}
+			}
+			retry[i].DependsOn = deps
+		}
+
+		wait := c.retry.backoff(attempt, retryAfter)
+		c.logf("%d batched request(s) throttled, retrying in %s (attempt %d/%d)", len(retry), wait, attempt+1, c.retry.MaxAttempts)
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+		pending = retry
+	}
+
+	return nil
+}
+
+// postBatch sends one $batch call and returns the responses by request ID
+func (c *GraphClient) postBatch(ctx context.Context, reqs []batchWireRequest) (map[string]batchWireResponse, error) {
+	payload, err := json.Marshal(map[string]interface{}{"requests": reqs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	c.logf("POST /$batch with %d request(s)", len(reqs))
+	respBody, err := c.doRequest(ctx, "POST", c.baseURL+"/$batch", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Responses []batchWireResponse `json:"responses"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+
+	byID := make(map[string]batchWireResponse, len(result.Responses))
+	for _, resp := range result.Responses {
+		byID[resp.ID] = resp
+	}
+	return byID, nil
+}
+
+func failedDependency(id, dep string) BatchResponse {
+	return BatchResponse{
+		ID:     id,
+		Status: http.StatusFailedDependency,
+		Err: &GraphError{
+			StatusCode: http.StatusFailedDependency,
+			Code:       "FailedDependency",
+			Message:    fmt.Sprintf("dependent request '%s' failed", dep),
+		},
+	}
+}
+
+// laterRetryAfter reports whether Retry-After value a asks to wait longer than b
+func laterRetryAfter(a, b string) bool {
+	da, ok := parseRetryAfter(a)
+	if !ok {
+		return false
+	}
+	db, ok := parseRetryAfter(b)
+	return !ok || da > db
+}
+
+func dependsOnAny(deps []string, ids map[string]bool) bool {
+	for _, dep := range deps {
+		if ids[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkEmailsRead sets the read status of several emails using batched requests.
+// An empty folderID addresses the messages regardless of their folder.
+func (c *GraphClient) MarkEmailsRead(ctx context.Context, folderID string, messageIDs []string, read bool) ([]BulkResult, error) {
+	return c.bulkMessages(ctx, folderID, messageIDs, func(msgURL string) (string, string, interface{}) {
+		return "PATCH", msgURL, map[string]interface{}{"isRead": read}
+	})
+}
+
+// MoveEmails moves several emails to another folder using batched requests
+func (c *GraphClient) MoveEmails(ctx context.Context, folderID string, messageIDs []string, destinationFolderID string) ([]BulkResult, error) {
+	return c.bulkMessages(ctx, folderID, messageIDs, func(msgURL string) (string, string, interface{}) {
+		return "POST", msgURL + "/move", map[string]string{"destinationId": destinationFolderID}
+	})
+}
+
+// TrashEmails moves several emails to the Deleted Items folder using batched requests
+func (c *GraphClient) TrashEmails(ctx context.Context, folderID string, messageIDs []string) ([]BulkResult, error) {
+	return c.MoveEmails(ctx, folderID, messageIDs, "deleteditems")
+}
+
+// bulkMessages runs one sub-request per message, built by fn from the message URL
+func (c *GraphClient) bulkMessages(ctx context.Context, folderID string, messageIDs []string, fn func(msgURL string) (method, url string, body interface{})) ([]BulkResult, error) {
+	reqs := make([]BatchRequest, len(messageIDs))
+	for i, id := range messageIDs {
+		method, reqURL, body := fn(messagePath(folderID, id))
+		reqs[i] = BatchRequest{Method: method, URL: reqURL, Body: body}
+	}
+
+	responses, err := c.Batch(ctx, reqs)
+	if responses == nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(messageIDs))
+	for i, id := range messageIDs {
+		results[i] = BulkResult{MessageID: id, Err: responses[i].Err}
+	}
+	return results, err
+}
@@ -0,0 +1,159 @@
+package mail_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestBatch_Chunking(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	ids := addMessages(srv, "inbox", "a@example.com", 25)
+
+	results, err := srv.Client().MarkEmailsRead(ctx, "inbox", ids, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 25 {
+		t.Fatalf("got %d results, want 25", len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil || r.MessageID != ids[i] {
+			t.Errorf("result %d = %+v", i, r)
+		}
+	}
+	if got := srv.BatchCalls(); fmt.Sprint(got) != "[20 5]" {
+		t.Errorf("batch sizes = %v, want [20 5]", got)
+	}
+	for _, id := range ids {
+		if m, _ := srv.Message(id); !m.IsRead {
+			t.Errorf("message %s not marked read", id)
+		}
+	}
+}
+
+func TestBatch_PerItemErrors(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	id := srv.AddMessage(graphtest.Message{Subject: "hello"})
+
+	results, err := srv.Client().MoveEmails(ctx, "inbox", []string{"missing", id}, "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, mail.ErrNotFound) {
+		t.Errorf("missing message error = %v, want ErrNotFound", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("existing message error = %v", results[1].Err)
+	}
+	if m, _ := srv.Message(id); m.FolderID != "archive" {
+		t.Errorf("message in %q, want archive", m.FolderID)
+	}
+}
+
+func TestBatch_Dependencies(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	id := srv.AddMessage(graphtest.Message{Subject: "hello"})
+
+	// Pad the batch so the last request depends on one in the previous chunk
+	reqs := []mail.BatchRequest{
+		{ID: "read", Method: "PATCH", URL: "/me/messages/" + id, Body: map[string]bool{"isRead": true}},
+		{ID: "bad", Method: "PATCH", URL: "/me/messages/missing", Body: map[string]bool{"isRead": true}},
+		{ID: "after-bad", Method: "GET", URL: "/me/messages/" + id, DependsOn: []string{"bad"}},
+	}
+	for i := len(reqs); i < mail.MaxBatchSize; i++ {
+		reqs = append(reqs, mail.BatchRequest{Method: "GET", URL: "/me/mailFolders/inbox"})
+	}
+	reqs = append(reqs,
+		mail.BatchRequest{ID: "move", Method: "POST", URL: "/me/messages/" + id + "/move",
+			Body: map[string]string{"destinationId": "archive"}, DependsOn: []string{"read"}},
+		mail.BatchRequest{ID: "after-bad-2", Method: "GET", URL: "/me/messages/" + id, DependsOn: []string{"bad"}},
+	)
+
+	results, err := srv.Client().Batch(ctx, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := map[string]int{}
+	for _, r := range results {
+		status[r.ID] = r.Status
+	}
+	want := map[string]int{"read": 200, "bad": 404, "after-bad": 424, "move": 201, "after-bad-2": 424}
+	for id, code := range want {
+		if status[id] != code {
+			t.Errorf("status[%s] = %d, want %d", id, status[id], code)
+		}
+	}
+	if m, _ := srv.Message(id); !m.IsRead || m.FolderID != "archive" {
+		t.Errorf("message = %+v, want read and archived", m)
+	}
+	if got := srv.BatchCalls(); fmt.Sprint(got) != "[20 1]" {
+		t.Errorf("batch sizes = %v, want [20 1] (failed dependency must not be sent)", got)
+	}
+}
+
+func TestBatch_InvalidDependency(t *testing.T) {
+	c := mail.NewGraphClientWithOptions("token", mail.ClientOptions{BaseURL: "http://127.0.0.1:0"})
+	_, err := c.Batch(ctx, []mail.BatchRequest{
+		{ID: "a", Method: "GET", URL: "/me", DependsOn: []string{"b"}},
+		{ID: "b", Method: "GET", URL: "/me"},
+	})
+	if err == nil {
+		t.Error("expected error for dependency on a later request")
+	}
+}
+
+func TestBatch_RetriesThrottledDependent(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	id := srv.AddMessage(graphtest.Message{Subject: "hello"})
+	srv.InjectFault(graphtest.Fault{Method: "POST", PathPrefix: "/me/messages/" + id + "/move", Status: http.StatusTooManyRequests, RetryAfter: "0", Times: 1})
+
+	results, err := fastRetryClient(srv, 3).Batch(ctx, []mail.BatchRequest{
+		{ID: "read", Method: "PATCH", URL: "/me/messages/" + id, Body: map[string]bool{"isRead": true}},
+		{ID: "move", Method: "POST", URL: "/me/messages/" + id + "/move",
+			Body: map[string]string{"destinationId": "archive"}, DependsOn: []string{"read"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.ID, r.Err)
+		}
+	}
+	if got := srv.BatchCalls(); fmt.Sprint(got) != "[2 1]" {
+		t.Errorf("batch sizes = %v, want [2 1]", got)
+	}
+	if m, _ := srv.Message(id); !m.IsRead || m.FolderID != "archive" {
+		t.Errorf("message = %+v, want read and archived", m)
+	}
+}
+
+func TestBatch_RetriesThrottledItems(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	ids := addMessages(srv, "inbox", "a@example.com", 3)
+	srv.InjectFault(graphtest.Fault{Method: "PATCH", PathPrefix: "/me/mailFolders/inbox/messages/" + ids[1], Status: http.StatusTooManyRequests, RetryAfter: "0", Times: 1})
+
+	results, err := fastRetryClient(srv, 3).MarkEmailsRead(ctx, "inbox", ids, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("result %d: %v", i, r.Err)
+		}
+	}
+	if got := srv.BatchCalls(); fmt.Sprint(got) != "[3 1]" {
+		t.Errorf("batch sizes = %v, want [3 1]", got)
+	}
+}
@@ -0,0 +1,262 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Follow-up flag states of a message
+const (
+	FlagStatusNotFlagged = "notFlagged"
+	FlagStatusFlagged    = "flagged"
+	FlagStatusComplete   = "complete"
+)
+
+// graphDateTimeLayout is the format of GraphDateTimeTimeZone.DateTime
+const graphDateTimeLayout = "2006-01-02T15:04:05.9999999"
+
+// NewGraphDateTime converts t to a Graph dateTimeTimeZone in UTC
+func NewGraphDateTime(t time.Time) *GraphDateTimeTimeZone {
+	return &GraphDateTimeTimeZone{DateTime: t.UTC().Format(graphDateTimeLayout), TimeZone: "UTC"}
+}
+
+// Time parses the date and time. Unknown time zone names fall back to UTC.
+func (d *GraphDateTimeTimeZone) Time() (time.Time, bool) {
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil || d.TimeZone == "" {
+		loc = time.UTC
+	}
+	t, err := time.ParseInLocation(graphDateTimeLayout, d.DateTime, loc)
+	return t, err == nil
+}
+
+// FollowUp is the follow-up flag to set on messages
+type FollowUp struct {
+	// Status is FlagStatusFlagged, FlagStatusComplete or FlagStatusNotFlagged
+	Status string
+	// Start and Due are optional dates of a flagged message; only their
+	// calendar date is used. Graph requires a start date with a due date,
+	// it defaults to today (or the due date if that is earlier).
+	Start time.Time
+	Due   time.Time
+}
+
+// graphFlag converts the follow-up to the Graph representation
+func (f FollowUp) graphFlag() GraphFollowupFlag {
+	flag := GraphFollowupFlag{FlagStatus: f.Status}
+	switch f.Status {
+	case FlagStatusFlagged:
+		if !f.Due.IsZero() {
+			start := f.Start
+			if start.IsZero() {
+				start = time.Now()
+				if f.Due.Before(start) {
+					start = f.Due
+				}
+			}
+			flag.StartDateTime = NewGraphDateTime(calendarDate(start))
+			flag.DueDateTime = NewGraphDateTime(calendarDate(f.Due))
+		}
+	case FlagStatusComplete:
+		flag.CompletedDateTime = NewGraphDateTime(time.Now())
+	}
+	return flag
+}
+
+// calendarDate returns midnight UTC of the date of t, which keeps the
+// date when Outlook shows it in another time zone
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FlagEmails sets the follow-up flag of several emails using batched requests.
+// An empty folderID addresses the messages regardless of their folder.
+func (c *GraphClient) FlagEmails(ctx context.Context, folderID string, messageIDs []string, flag FollowUp) ([]BulkResult, error) {
+	graphFlag := flag.graphFlag()
+	return c.bulkMessages(ctx, folderID, messageIDs, func(msgURL string) (string, string, interface{}) {
+		return "PATCH", msgURL, map[string]interface{}{"flag": graphFlag}
+	})
+}
+
+// UpdateCategories changes the categories of several emails. update gets the
+// current categories of each email and returns the new ones; emails whose
+// categories don't change are not patched.
+func (c *GraphClient) UpdateCategories(ctx context.Context, folderID string, messageIDs []string, update func([]string) []string) ([]BulkResult, error) {
+	reqs := make([]BatchRequest, len(messageIDs))
+	for i, id := range messageIDs {
+		reqs[i] = BatchRequest{Method: "GET", URL: messagePath(folderID, id) + "?$select=id,categories"}
+	}
+	responses, err := c.Batch(ctx, reqs)
+	if responses == nil {
+		return nil, err
+	}
+
+	results := make([]BulkResult, len(messageIDs))
+	var patches []BatchRequest
+	var patched []int
+	for i, id := range messageIDs {
+		results[i] = BulkResult{MessageID: id, Err: responses[i].Err}
+		if responses[i].Err != nil {
+			continue
+		}
+
+		var msg struct {
+			Categories []string `json:"categories"`
+		}
+		if parseErr := json.Unmarshal(responses[i].Body, &msg); parseErr != nil {
+			results[i].Err = fmt.Errorf("failed to parse message: %w", parseErr)
+			continue
+		}
+
+		categories := update(msg.Categories)
+		if equalCategories(categories, msg.Categories) {
+			continue
+		}
+		if categories == nil {
+			categories = []string{}
+		}
+		patches = append(patches, BatchRequest{Method: "PATCH", URL: messagePath(folderID, id), Body: map[string]interface{}{"categories": categories}})
+		patched = append(patched, i)
+	}
+	if err != nil || len(patches) == 0 {
+		return results, err
+	}
+
+	responses, err = c.Batch(ctx, patches)
+	if responses == nil {
+		return nil, err
+	}
+	for j, i := range patched {
+		results[i].Err = responses[j].Err
+	}
+	return results, err
+}
+
+func equalCategories(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CategoryColors are the names of the Outlook category color presets,
+// index n being "presetn"
+var CategoryColors = []string{
+	"red", "orange", "brown", "yellow", "green", "teal", "olive", "blue", "purple", "cranberry",
+	"steel", "darksteel", "gray", "darkgray", "black", "darkred", "darkorange", "darkbrown",
+	"darkyellow", "darkgreen", "darkteal", "darkolive", "darkblue", "darkpurple", "darkcranberry",
+}
+
+// ParseCategoryColor accepts a color name (see CategoryColors), a preset
+// such as "preset7" or "none" and returns the Graph preset
+func ParseCategoryColor(s string) (string, error) {
+	name := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if name == "none" {
+		return "none", nil
+	}
+	for i, color := range CategoryColors {
+		if name == color || name == fmt.Sprintf("preset%d", i) {
+			return fmt.Sprintf("preset%d", i), nil
+		}
+	}
+	return "", fmt.Errorf("unknown category color %q (use one of %s or none)", s, strings.Join(CategoryColors, ", "))
+}
+
+// CategoryColorName returns the color name of a Graph preset
+func CategoryColorName(preset string) string {
+	var n int
+	if _, err := fmt.Sscanf(preset, "preset%d", &n); err == nil && n >= 0 && n < len(CategoryColors) {
+		return CategoryColors[n]
+	}
+	return preset
+}
+
+// Category is an entry of the Outlook master category list
+type Category struct {
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"displayName"`
+	// Color is a preset such as "preset0" or "none"
+	Color string `json:"color"`
+}
+
+type graphCategoriesResponse struct {
+	Value    []Category `json:"value"`
+	NextLink string     `json:"@odata.nextLink"`
+}
+
+// ListCategories lists the master categories of the mailbox
+func (c *GraphClient) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	currentEndpoint := c.baseURL + "/me/outlook/masterCategories"
+
+	for currentEndpoint != "" {
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result graphCategoriesResponse
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		categories = append(categories, result.Value...)
+		currentEndpoint = result.NextLink
+	}
+
+	return categories, nil
+}
+
+// GetCategoryByName finds a master category by its display name (case-insensitive)
+func (c *GraphClient) GetCategoryByName(ctx context.Context, name string) (*Category, error) {
+	categories, err := c.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		if strings.EqualFold(categories[i].DisplayName, name) {
+			return &categories[i], nil
+		}
+	}
+	return nil, fmt.Errorf("category '%s' %w", name, ErrNotFound)
+}
+
+// CreateCategory adds a category to the master list
+func (c *GraphClient) CreateCategory(ctx context.Context, category Category) (*Category, error) {
+	jsonBody, _ := json.Marshal(Category{DisplayName: category.DisplayName, Color: category.Color})
+
+	resp, err := c.doRequest(ctx, "POST", c.baseURL+"/me/outlook/masterCategories", jsonBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Category
+	if err := json.Unmarshal(resp, &created); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &created, nil
+}
+
+// SetCategoryColor changes the color of a master category. The display
+// name of a category cannot be changed.
+func (c *GraphClient) SetCategoryColor(ctx context.Context, categoryID, color string) error {
+	jsonBody, _ := json.Marshal(map[string]string{"color": color})
+
+	_, err := c.doRequest(ctx, "PATCH", c.baseURL+"/me/outlook/masterCategories/"+url.PathEscape(categoryID), jsonBody)
+	return err
+}
+
+// DeleteCategory removes a category from the master list. Messages keep
+// the category name.
+func (c *GraphClient) DeleteCategory(ctx context.Context, categoryID string) error {
+	_, err := c.doRequest(ctx, "DELETE", c.baseURL+"/me/outlook/masterCategories/"+url.PathEscape(categoryID), nil)
+	return err
+}
@@ -0,0 +1,104 @@
+package mail_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestFlagEmails(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	ids := addMessages(srv, "inbox", "a@example.com", 3)
+
+	due := time.Date(2024, 5, 31, 0, 0, 0, 0, time.Local)
+	results, err := c.FlagEmails(ctx, "", ids[:2], mail.FollowUp{Status: mail.FlagStatusFlagged, Due: due})
+	if err != nil || len(results) != 2 || results[0].Err != nil {
+		t.Fatalf("FlagEmails = %+v, %v", results, err)
+	}
+
+	emails, err := c.ListEmailsFiltered(ctx, "inbox", 0, mail.ListFilter{Flagged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 2 {
+		t.Fatalf("got %d flagged emails, want 2", len(emails))
+	}
+	if emails[0].FlagDue == nil || emails[0].FlagDue.Format("2006-01-02") != "2024-05-31" {
+		t.Errorf("FlagDue = %v, want 2024-05-31", emails[0].FlagDue)
+	}
+
+	if _, err := c.FlagEmails(ctx, "", ids[:1], mail.FollowUp{Status: mail.FlagStatusComplete}); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := srv.Message(ids[0]); m.Flagged || !m.FlagComplete {
+		t.Errorf("message = flagged %v, complete %v", m.Flagged, m.FlagComplete)
+	}
+}
+
+func TestUpdateCategories(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	a := srv.AddMessage(graphtest.Message{Subject: "A", Categories: []string{"Todo"}})
+	b := srv.AddMessage(graphtest.Message{Subject: "B"})
+
+	results, err := c.UpdateCategories(ctx, "", []string{a, b, "missing"}, func(current []string) []string {
+		return append(current, "Finance")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || results[1].Err != nil || results[2].Err == nil {
+		t.Errorf("results = %+v", results)
+	}
+	if m, _ := srv.Message(a); len(m.Categories) != 2 || m.Categories[1] != "Finance" {
+		t.Errorf("categories of A = %v", m.Categories)
+	}
+
+	emails, err := c.ListEmailsFiltered(ctx, "inbox", 0, mail.ListFilter{Category: "todo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0].Subject != "A" {
+		t.Errorf("emails with category Todo = %+v", emails)
+	}
+}
+
+func TestMasterCategories(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	color, err := mail.ParseCategoryColor("Dark Blue")
+	if err != nil || color != "preset22" {
+		t.Fatalf("ParseCategoryColor = %q, %v", color, err)
+	}
+	if _, err := mail.ParseCategoryColor("mauve"); err == nil {
+		t.Error("expected error for unknown color")
+	}
+
+	if _, err := c.CreateCategory(ctx, mail.Category{DisplayName: "Project Alpha", Color: color}); err != nil {
+		t.Fatal(err)
+	}
+	category, err := c.GetCategoryByName(ctx, "project alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetCategoryColor(ctx, category.ID, "preset0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Categories(); len(got) != 1 || mail.CategoryColorName(got[0].Color) != "red" {
+		t.Errorf("categories = %+v", got)
+	}
+
+	if err := c.DeleteCategory(ctx, category.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetCategoryByName(ctx, "Project Alpha"); err == nil {
+		t.Error("expected not found after delete")
+	}
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MessageChange is a message reported by a delta query
+type MessageChange struct {
+	ID string
+	// Removed is set when the message was deleted or moved out of the folder
+	Removed bool
+	// Email is nil for removed messages
+	Email *Email
+	// InternetMessageID is the RFC 822 Message-ID, which survives moves
+	InternetMessageID string
+}
+
+// DeltaResult holds the changes of a delta query and the link to resume from
+type DeltaResult struct {
+	Changes   []MessageChange
+	DeltaLink string
+}
+
+type graphDeltaResponse struct {
+	Value     []json.RawMessage `json:"value"`
+	NextLink  string            `json:"@odata.nextLink"`
+	DeltaLink string            `json:"@odata.deltaLink"`
+}
+
+// MessagesDelta returns the messages of a folder that changed since deltaLink
+// was issued. An empty deltaLink starts a full sync returning every message.
+// If Graph no longer accepts the delta link the error matches ErrSyncReset.
+func (c *GraphClient) MessagesDelta(ctx context.Context, folderID, deltaLink string) (*DeltaResult, error) {
+	endpoint := deltaLink
+	if endpoint == "" {
+		params := url.Values{}
+		params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,ccRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
+		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/messages/delta?%s", c.baseURL, url.PathEscape(folderID), params.Encode())
+	} else if !strings.HasPrefix(deltaLink, c.baseURL+"/") {
+		// Never send the token to a host the link was not issued for
+		return nil, fmt.Errorf("delta link was issued by a different Graph endpoint: %w", ErrSyncReset)
+	}
+
+	result := &DeltaResult{}
+	index := map[string]int{}
+
+	for endpoint != "" {
+		resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page graphDeltaResponse
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for _, raw := range page.Value {
+			change, err := parseMessageChange(raw)
+			if err != nil {
+				return nil, err
+			}
+
+			// A message may show up on several pages; the last state wins
+			if i, ok := index[change.ID]; ok {
+				result.Changes[i] = change
+				continue
+			}
+			index[change.ID] = len(result.Changes)
+			result.Changes = append(result.Changes, change)
+		}
+
+		if page.DeltaLink != "" {
+			result.DeltaLink = page.DeltaLink
+		}
+		endpoint = page.NextLink
+	}
+
+	if result.DeltaLink == "" {
+		return nil, fmt.Errorf("delta response did not include a delta link")
+	}
+	return result, nil
+}
+
+func parseMessageChange(raw json.RawMessage) (MessageChange, error) {
+	var item struct {
+		GraphMessageResponse
+		Removed *struct {
+			Reason string `json:"reason"`
+		} `json:"@removed"`
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return MessageChange{}, fmt.Errorf("failed to parse delta item: %w", err)
+	}
+
+	if item.Removed != nil {
+		return MessageChange{ID: item.ID, Removed: true}, nil
+	}
+
+	email := graphMessageToEmail(item.GraphMessageResponse)
+	return MessageChange{ID: item.ID, Email: &email, InternetMessageID: item.InternetMessageId}, nil
+}
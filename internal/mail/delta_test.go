@@ -0,0 +1,55 @@
+package mail_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestMessagesDelta(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	ids := addMessages(srv, "inbox", "a@example.com", 5)
+	c := srv.Client()
+
+	result, err := c.MessagesDelta(ctx, "inbox", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 5 || result.DeltaLink == "" {
+		t.Fatalf("initial delta = %d changes, link %q", len(result.Changes), result.DeltaLink)
+	}
+
+	if err := c.MoveEmail(ctx, "inbox", ids[0], "archive"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = c.MessagesDelta(ctx, "inbox", result.DeltaLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || !result.Changes[0].Removed || result.Changes[0].ID != ids[0] {
+		t.Errorf("changes = %+v, want removal of %s", result.Changes, ids[0])
+	}
+}
+
+func TestMessagesDelta_Expired(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	result, err := c.MessagesDelta(ctx, "inbox", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireDeltaTokens()
+
+	if _, err := c.MessagesDelta(ctx, "inbox", result.DeltaLink); !errors.Is(err, mail.ErrSyncReset) {
+		t.Errorf("err = %v, want ErrSyncReset", err)
+	}
+	if _, err := c.MessagesDelta(ctx, "inbox", "https://evil.example.com/delta"); !errors.Is(err, mail.ErrSyncReset) {
+		t.Errorf("foreign delta link: err = %v, want ErrSyncReset", err)
+	}
+}
@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// MessageHeader is an Internet message header
+type MessageHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// AttachmentInfo describes an attachment without its content
+type AttachmentInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	IsInline    bool   `json:"is_inline,omitempty"`
+}
+
+// MessageDetail is a message with its body, headers and attachment metadata
+type MessageDetail struct {
+	Email
+	FolderID          string           `json:"folder_id"`
+	InternetMessageID string           `json:"internet_message_id,omitempty"`
+	FlagStatus        string           `json:"flag_status,omitempty"`
+	Headers           []MessageHeader  `json:"headers,omitempty"`
+	Attachments       []AttachmentInfo `json:"attachments,omitempty"`
+}
+
+type graphMessageDetailResponse struct {
+	GraphMessageResponse
+	InternetMessageHeaders []MessageHeader `json:"internetMessageHeaders"`
+	Attachments            []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+		Size        int    `json:"size"`
+		IsInline    bool   `json:"isInline"`
+	} `json:"attachments"`
+}
+
+// messageDetailQuery selects everything MessageDetail holds
+func messageDetailQuery() string {
+	params := url.Values{}
+	params.Set("$select", "id,subject,body,bodyPreview,receivedDateTime,isRead,from,toRecipients,ccRecipients,bccRecipients,hasAttachments,internetMessageId,internetMessageHeaders,parentFolderId,conversationId,importance,categories,flag")
+	params.Set("$expand", "attachments($select=id,name,contentType,size,isInline)")
+	return params.Encode()
+}
+
+// GetMessageDetail fetches a message with its headers and attachment metadata
+func (c *GraphClient) GetMessageDetail(ctx context.Context, messageID string) (*MessageDetail, error) {
+	resp, err := c.doRequest(ctx, "GET", c.baseURL+messagePath("", messageID)+"?"+messageDetailQuery(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageDetail(resp)
+}
+
+// GetMessageDetails fetches full messages by ID using batched requests.
+// details is aligned with messageIDs and has nil entries where results report an error.
+func (c *GraphClient) GetMessageDetails(ctx context.Context, messageIDs []string) ([]*MessageDetail, []BulkResult, error) {
+	reqs := make([]BatchRequest, len(messageIDs))
+	for i, id := range messageIDs {
+		reqs[i] = BatchRequest{Method: "GET", URL: messagePath("", id) + "?" + messageDetailQuery()}
+	}
+
+	responses, err := c.Batch(ctx, reqs)
+	if responses == nil {
+		return nil, nil, err
+	}
+
+	details := make([]*MessageDetail, len(messageIDs))
+	results := make([]BulkResult, len(messageIDs))
+	for i, id := range messageIDs {
+		results[i] = BulkResult{MessageID: id, Err: responses[i].Err}
+		if responses[i].Err != nil {
+			continue
+		}
+
+		detail, parseErr := parseMessageDetail(responses[i].Body)
+		if parseErr != nil {
+			results[i].Err = parseErr
+			continue
+		}
+		details[i] = detail
+	}
+	return details, results, err
+}
+
+func parseMessageDetail(data []byte) (*MessageDetail, error) {
+	var msg graphMessageDetailResponse
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	detail := &MessageDetail{
+		Email:             graphMessageToEmail(msg.GraphMessageResponse),
+		FolderID:          msg.ParentFolderId,
+		InternetMessageID: msg.InternetMessageId,
+		Headers:           msg.InternetMessageHeaders,
+	}
+	detail.Body = msg.Body.Content
+	detail.BodyType = msg.Body.ContentType
+	if msg.Flag != nil {
+		detail.FlagStatus = msg.Flag.FlagStatus
+	}
+	for _, att := range msg.Attachments {
+		detail.Attachments = append(detail.Attachments, AttachmentInfo{
+			ID:          att.ID,
+			Name:        att.Name,
+			ContentType: att.ContentType,
+			Size:        att.Size,
+			IsInline:    att.IsInline,
+		})
+	}
+	return detail, nil
+}
@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DraftUpdate describes changes to a draft. Nil fields are left unchanged;
+// an empty non-nil recipient list removes all recipients of that kind.
+type DraftUpdate struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	Subject *string
+	Body    *string
+	// HTML marks Body as HTML
+	HTML bool
+	// AddAttachments are attached after RemoveAttachments are removed
+	AddAttachments []FileAttachment
+	// RemoveAttachments are attachment names (case-insensitive)
+	RemoveAttachments []string
+}
+
+// UpdateDraft changes the fields, body and attachments of a draft
+func (c *GraphClient) UpdateDraft(ctx context.Context, draftID string, update DraftUpdate) error {
+	if err := checkAttachmentsSize(attachmentsSize(update.AddAttachments)); err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{}
+	if update.To != nil {
+		patch["toRecipients"] = recipients(update.To)
+	}
+	if update.Cc != nil {
+		patch["ccRecipients"] = recipients(update.Cc)
+	}
+	if update.Bcc != nil {
+		patch["bccRecipients"] = recipients(update.Bcc)
+	}
+	if update.Subject != nil {
+		patch["subject"] = *update.Subject
+	}
+	if update.Body != nil {
+		contentType := "Text"
+		if update.HTML {
+			contentType = "HTML"
+		}
+		patch["body"] = GraphBody{ContentType: contentType, Content: *update.Body}
+	}
+	if len(patch) > 0 {
+		jsonBody, _ := json.Marshal(patch)
+		if _, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", draftID), jsonBody); err != nil {
+			return err
+		}
+	}
+
+	if len(update.RemoveAttachments) > 0 {
+		if err := c.removeAttachments(ctx, draftID, update.RemoveAttachments); err != nil {
+			return err
+		}
+	}
+	return c.addAttachments(ctx, draftID, update.AddAttachments)
+}
+
+// removeAttachments deletes the attachments with the given names from a
+// message. All names are checked before anything is deleted.
+func (c *GraphClient) removeAttachments(ctx context.Context, messageID string, names []string) error {
+	detail, err := c.GetMessageDetail(ctx, messageID)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for _, name := range names {
+		found := false
+		for _, att := range detail.Attachments {
+			if strings.EqualFold(att.Name, name) {
+				ids = append(ids, att.ID)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("attachment '%s' %w", name, ErrNotFound)
+		}
+	}
+
+	for _, id := range ids {
+		endpoint := c.baseURL + messagePath("", messageID) + "/attachments/" + url.PathEscape(id)
+		if _, err := c.doRequest(ctx, "DELETE", endpoint, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateReplyDraft creates a reply (or reply-all) to a message as a draft
+// and returns the draft ID
+func (c *GraphClient) CreateReplyDraft(ctx context.Context, messageID string, opts ResponseOptions, replyAll bool) (string, error) {
+	action := "createReply"
+	if replyAll {
+		action = "createReplyAll"
+	}
+	return c.createResponse(ctx, messageID, action, opts)
+}
+
+// CreateForwardDraft creates a forward of a message as a draft and returns
+// the draft ID
+func (c *GraphClient) CreateForwardDraft(ctx context.Context, messageID string, opts ResponseOptions) (string, error) {
+	return c.createResponse(ctx, messageID, "createForward", opts)
+}
@@ -0,0 +1,85 @@
+package mail_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestUpdateDraft(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	id, err := c.SaveDraft(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Cc:          []string{"b@example.com"},
+		Subject:     "Plan",
+		Body:        "v1",
+		Attachments: []mail.FileAttachment{{Name: "plan-v1.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, body := "Plan v2", "<p>v2</p>"
+	err = c.UpdateDraft(ctx, id, mail.DraftUpdate{
+		To:                []string{"c@example.com"},
+		Cc:                []string{},
+		Bcc:               []string{"d@example.com"},
+		Subject:           &subject,
+		Body:              &body,
+		HTML:              true,
+		AddAttachments:    []mail.FileAttachment{{Name: "plan-v2.pdf", ContentType: "application/pdf", Content: []byte("%PDF-2")}},
+		RemoveAttachments: []string{"PLAN-V1.pdf"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := srv.Message(id)
+	if m.Subject != "Plan v2" || m.Body != "<p>v2</p>" || m.BodyType != "html" {
+		t.Errorf("draft = %q %q %s", m.Subject, m.Body, m.BodyType)
+	}
+	if len(m.To) != 1 || m.To[0] != "c@example.com" || len(m.Cc) != 0 || len(m.Bcc) != 1 {
+		t.Errorf("recipients = %v / %v / %v", m.To, m.Cc, m.Bcc)
+	}
+	if len(m.Attachment) != 1 || m.Attachment[0].Name != "plan-v2.pdf" {
+		t.Errorf("attachments = %+v", m.Attachment)
+	}
+
+	err = c.UpdateDraft(ctx, id, mail.DraftUpdate{RemoveAttachments: []string{"missing.pdf"}})
+	if !errors.Is(err, mail.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateResponseDrafts(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com", To: []string{"me@example.com"}, Cc: []string{"b@example.com"}})
+
+	replyID, err := c.CreateReplyDraft(ctx, id, mail.ResponseOptions{Body: "Answer"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, _ := srv.Message(replyID)
+	if !reply.IsDraft || reply.Subject != "RE: Question" || len(reply.To) != 2 || len(reply.Cc) != 1 {
+		t.Errorf("reply draft = %+v", reply)
+	}
+
+	forwardID, err := c.CreateForwardDraft(ctx, id, mail.ResponseOptions{To: []string{"c@example.com"}, Body: "FYI"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forward, _ := srv.Message(forwardID)
+	if !forward.IsDraft || forward.Subject != "FW: Question" || len(forward.To) != 1 || forward.To[0] != "c@example.com" {
+		t.Errorf("forward draft = %+v", forward)
+	}
+	if n := len(srv.Messages("sentitems")); n != 0 {
+		t.Errorf("%d message(s) sent", n)
+	}
+}
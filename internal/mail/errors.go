@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error categories usable with errors.Is on errors returned by GraphClient
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrThrottled    = errors.New("throttled")
+	ErrConflict     = errors.New("conflict")
+	// ErrSyncReset means a delta link expired and a full sync is required
+	ErrSyncReset = errors.New("sync state expired")
+)
+
+// GraphError is an error response from Microsoft Graph
+type GraphError struct {
+	StatusCode int    `json:"status"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message"`
+	RequestID  string `json:"request_id,omitempty"`
+	Date       string `json:"date,omitempty"`
+}
+
+// graphErrorResponse is the error body returned by Graph
+type graphErrorResponse struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			RequestID string `json:"request-id"`
+			Date      string `json:"date"`
+		} `json:"innerError"`
+	} `json:"error"`
+}
+
+// newGraphError builds a GraphError from a status code and response body.
+// Bodies that are not Graph error JSON are kept verbatim as the message.
+func newGraphError(status int, body []byte) *GraphError {
+	e := &GraphError{StatusCode: status}
+
+	var resp graphErrorResponse
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error.Code != "" {
+		e.Code = resp.Error.Code
+		e.Message = resp.Error.Message
+		e.RequestID = resp.Error.InnerError.RequestID
+		e.Date = resp.Error.InnerError.Date
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	return e
+}
+
+func (e *GraphError) Error() string {
+	msg := fmt.Sprintf("Graph API error (status %d)", e.StatusCode)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	msg += ": " + e.Message
+	if e.RequestID != "" {
+		msg += " (request-id: " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is maps the error onto the category sentinels (ErrNotFound, ErrThrottled, ...)
+func (e *GraphError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.Code == "ErrorItemNotFound"
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.Code == "InvalidAuthenticationToken"
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden || e.Code == "ErrorAccessDenied"
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.Code == "ErrorFolderExists"
+	case ErrSyncReset:
+		return e.StatusCode == http.StatusGone || e.Code == "SyncStateNotFound" || e.Code == "SyncStateInvalid" || e.Code == "resyncRequired"
+	}
+	return false
+}
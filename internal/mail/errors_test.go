@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewGraphError_ParsesBody(t *testing.T) {
+	body := []byte(`{"error":{"code":"ErrorItemNotFound","message":"The specified object was not found in the store.","innerError":{"request-id":"abc-123","date":"2024-01-01T00:00:00"}}}`)
+	e := newGraphError(404, body)
+
+	if e.Code != "ErrorItemNotFound" || e.RequestID != "abc-123" {
+		t.Errorf("parsed = %+v", e)
+	}
+	if !strings.Contains(e.Error(), "request-id: abc-123") {
+		t.Errorf("Error() = %q", e.Error())
+	}
+
+	wrapped := fmt.Errorf("read failed: %w", e)
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("wrapped 404 should match ErrNotFound")
+	}
+	if errors.Is(wrapped, ErrThrottled) {
+		t.Error("404 should not match ErrThrottled")
+	}
+
+	var ge *GraphError
+	if !errors.As(wrapped, &ge) || ge.StatusCode != 404 {
+		t.Error("errors.As should find GraphError")
+	}
+}
+
+func TestNewGraphError_NonJSONBody(t *testing.T) {
+	e := newGraphError(502, []byte("<html>Bad Gateway</html>"))
+	if e.Code != "" || e.Message != "<html>Bad Gateway</html>" {
+		t.Errorf("parsed = %+v", e)
+	}
+
+	e = newGraphError(503, nil)
+	if e.Message != "Service Unavailable" {
+		t.Errorf("empty body message = %q", e.Message)
+	}
+}
+
+func TestGraphError_Categories(t *testing.T) {
+	tests := []struct {
+		err  *GraphError
+		want error
+	}{
+		{&GraphError{StatusCode: 401, Code: "InvalidAuthenticationToken"}, ErrUnauthorized},
+		{&GraphError{StatusCode: 403, Code: "ErrorAccessDenied"}, ErrForbidden},
+		{&GraphError{StatusCode: 429}, ErrThrottled},
+		{&GraphError{StatusCode: 409, Code: "ErrorFolderExists"}, ErrConflict},
+		{&GraphError{StatusCode: 400, Code: "ErrorFolderExists"}, ErrConflict},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%v should match %v", tt.err, tt.want)
+		}
+	}
+}
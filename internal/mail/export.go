@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GetMIME fetches the full RFC 822 (MIME) content of a message
+func (c *GraphClient) GetMIME(ctx context.Context, messageID string) ([]byte, error) {
+	endpoint := c.baseURL + messagePath("", messageID) + "/$value"
+	return c.doRequest(ctx, "GET", endpoint, nil)
+}
+
+// ListEmailsReceived lists emails received in [since, until), oldest first.
+// A zero since or until leaves that end open; an empty folderID lists all folders.
+func (c *GraphClient) ListEmailsReceived(ctx context.Context, folderID string, since, until time.Time, limit int) ([]Email, error) {
+	endpoint := c.baseURL + "/me/messages"
+	if folderID != "" {
+		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
+	}
+
+	pageSize := limit
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 100
+	}
+	params := url.Values{}
+	params.Set("$top", fmt.Sprintf("%d", pageSize))
+	params.Set("$orderby", "receivedDateTime asc")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId,conversationId")
+
+	var filters []string
+	if !since.IsZero() {
+		filters = append(filters, fmt.Sprintf("receivedDateTime ge %s", since.UTC().Format(time.RFC3339)))
+	}
+	if !until.IsZero() {
+		filters = append(filters, fmt.Sprintf("receivedDateTime lt %s", until.UTC().Format(time.RFC3339)))
+	}
+	if len(filters) > 0 {
+		params.Set("$filter", strings.Join(filters, " and "))
+	}
+
+	var allEmails []Email
+	currentEndpoint := endpoint + "?" + params.Encode()
+
+	for currentEndpoint != "" {
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result GraphMessagesResponse
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for _, msg := range result.Value {
+			allEmails = append(allEmails, graphMessageToEmail(msg))
+			if limit > 0 && len(allEmails) >= limit {
+				return allEmails, nil
+			}
+		}
+
+		currentEndpoint = result.NextLink
+	}
+
+	return allEmails, nil
+}
@@ -2,6 +2,7 @@ package mail
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -16,24 +17,87 @@ import (
 
 const (
 	GraphAPIBaseURL = "https://graph.microsoft.com/v1.0"
+
+	// DefaultUserAgent is sent with every request unless overridden
+	DefaultUserAgent = "o365-mail-cli"
 )
 
 // GraphClient for Microsoft Graph API operations
 type GraphClient struct {
 	httpClient  *http.Client
 	accessToken string
+	baseURL     string
+	userAgent   string
+	retry       RetryPolicy
+	logf        func(format string, args ...interface{})
+}
+
+// ClientOptions configures a GraphClient.
+// Zero values fall back to the public Graph endpoint and a 30s HTTP client.
+type ClientOptions struct {
+	// BaseURL is the Graph root including the API version,
+	// e.g. https://graph.microsoft.us/v1.0 or a local fake server
+	BaseURL    string
+	HTTPClient *http.Client
+	UserAgent  string
+	// Retry overrides DefaultRetryPolicy when set
+	Retry *RetryPolicy
+	// Logf receives diagnostic messages such as retries (optional)
+	Logf func(format string, args ...interface{})
 }
 
 // NewGraphClient creates a new Graph API client
 func NewGraphClient(accessToken string) *GraphClient {
-	return &GraphClient{
-		httpClient: &http.Client{
+	return NewGraphClientWithOptions(accessToken, ClientOptions{})
+}
+
+// NewGraphClientWithOptions creates a new Graph API client with custom options
+func NewGraphClientWithOptions(accessToken string, opts ClientOptions) *GraphClient {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = GraphAPIBaseURL
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
 			Timeout: 30 * time.Second,
-		},
+		}
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	retry := DefaultRetryPolicy()
+	if opts.Retry != nil {
+		retry = *opts.Retry
+	}
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+
+	logf := opts.Logf
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+
+	return &GraphClient{
+		httpClient:  httpClient,
 		accessToken: accessToken,
+		baseURL:     baseURL,
+		userAgent:   userAgent,
+		retry:       retry,
+		logf:        logf,
 	}
 }
 
+// BaseURL returns the Graph endpoint this client talks to
+func (c *GraphClient) BaseURL() string {
+	return c.baseURL
+}
+
 // Email represents an email message
 type Email struct {
 	ID        string    `json:"id"`
@@ -42,10 +106,21 @@ type Email struct {
 	From      string    `json:"from"`
 	To        []string  `json:"to"`
 	Cc        []string  `json:"cc,omitempty"`
+	Bcc       []string  `json:"bcc,omitempty"`
 	Date      time.Time `json:"date"`
 	Body      string    `json:"body,omitempty"`
+	BodyType  string    `json:"body_type,omitempty"` // "html" or "text", set with Body
 	Preview   string    `json:"preview,omitempty"`
 	Unread    bool      `json:"unread"`
+	Flagged   bool      `json:"flagged,omitempty"`
+
+	// ConversationID is shared by all messages of a thread
+	ConversationID string `json:"conversation_id,omitempty"`
+	// FlagDue is the due date of a flagged message (midnight UTC), if one was set
+	FlagDue    *time.Time `json:"flag_due,omitempty"`
+	Categories []string   `json:"categories,omitempty"`
+	// Importance is "low", "normal" or "high"
+	Importance string `json:"importance,omitempty"`
 }
 
 // Attachment represents an email attachment
@@ -64,23 +139,55 @@ type SendOptions struct {
 	Subject string
 	Body    string
 	HTML    bool
+	// Attachments are sent inline up to 3 MB in total, larger ones via upload sessions
+	Attachments []FileAttachment
+	// TextBody is a plain-text alternative to an HTML body
+	TextBody string
+	// Importance is one of Importances (empty = normal)
+	Importance string
+	// Sensitivity is one of Sensitivities (empty = normal)
+	Sensitivity     string
+	ReadReceipt     bool
+	DeliveryReceipt bool
+	// ReplyTo are the addresses replies go to instead of the sender
+	ReplyTo []string
+	// SendAt defers delivery until the given time (zero = send now)
+	SendAt time.Time
 }
 
-
 // GraphMessageResponse represents a message from Graph API
 type GraphMessageResponse struct {
-	ID                 string                `json:"id"`
-	Subject            string                `json:"subject"`
-	BodyPreview        string                `json:"bodyPreview"`
-	Body               GraphBodyResponse     `json:"body"`
-	ReceivedDateTime   string                `json:"receivedDateTime"`
-	IsRead             bool                  `json:"isRead"`
-	From               *GraphEmailAddressWrapper `json:"from"`
-	ToRecipients       []GraphEmailAddressWrapper `json:"toRecipients"`
-	CcRecipients       []GraphEmailAddressWrapper `json:"ccRecipients"`
-	HasAttachments     bool                  `json:"hasAttachments"`
-	InternetMessageId  string                `json:"internetMessageId"`
-	ParentFolderId     string                `json:"parentFolderId"`
+	ID                string                     `json:"id"`
+	Subject           string                     `json:"subject"`
+	BodyPreview       string                     `json:"bodyPreview"`
+	Body              GraphBodyResponse          `json:"body"`
+	ReceivedDateTime  string                     `json:"receivedDateTime"`
+	IsRead            bool                       `json:"isRead"`
+	From              *GraphEmailAddressWrapper  `json:"from"`
+	ToRecipients      []GraphEmailAddressWrapper `json:"toRecipients"`
+	CcRecipients      []GraphEmailAddressWrapper `json:"ccRecipients"`
+	BccRecipients     []GraphEmailAddressWrapper `json:"bccRecipients"`
+	HasAttachments    bool                       `json:"hasAttachments"`
+	InternetMessageId string                     `json:"internetMessageId"`
+	ParentFolderId    string                     `json:"parentFolderId"`
+	ConversationId    string                     `json:"conversationId"`
+	Categories        []string                   `json:"categories"`
+	Importance        string                     `json:"importance"`
+	Flag              *GraphFollowupFlag         `json:"flag,omitempty"`
+}
+
+// GraphFollowupFlag is the follow-up flag of a message
+type GraphFollowupFlag struct {
+	FlagStatus        string                 `json:"flagStatus"`
+	StartDateTime     *GraphDateTimeTimeZone `json:"startDateTime,omitempty"`
+	DueDateTime       *GraphDateTimeTimeZone `json:"dueDateTime,omitempty"`
+	CompletedDateTime *GraphDateTimeTimeZone `json:"completedDateTime,omitempty"`
+}
+
+// GraphDateTimeTimeZone is a local date and time with its time zone name
+type GraphDateTimeTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
 }
 
 type GraphBodyResponse struct {
@@ -137,29 +244,62 @@ type Folder struct {
 	ChildFolderCount int     `json:"child_folder_count"`
 }
 
+// ListFilter restricts the emails returned by ListEmailsFiltered
+type ListFilter struct {
+	UnreadOnly bool
+	Flagged    bool
+	// Category is a category name the emails must have
+	Category string
+	// Importance is one of Importances
+	Importance string
+}
+
+// clauses returns the $filter clauses of the filter
+func (f ListFilter) clauses() []string {
+	var filters []string
+	if f.UnreadOnly {
+		filters = append(filters, "isRead eq false")
+	}
+	if f.Flagged {
+		filters = append(filters, "flag/flagStatus eq 'flagged'")
+	}
+	if f.Category != "" {
+		filters = append(filters, fmt.Sprintf("categories/any(c:c eq '%s')", strings.ReplaceAll(f.Category, "'", "''")))
+	}
+	if f.Importance != "" {
+		filters = append(filters, fmt.Sprintf("importance eq '%s'", f.Importance))
+	}
+	return filters
+}
+
 // ListEmails lists emails from a folder
-func (c *GraphClient) ListEmails(folderID string, limit int, unreadOnly bool) ([]Email, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", GraphAPIBaseURL, url.PathEscape(folderID))
+func (c *GraphClient) ListEmails(ctx context.Context, folderID string, limit int, unreadOnly bool) ([]Email, error) {
+	return c.ListEmailsFiltered(ctx, folderID, limit, ListFilter{UnreadOnly: unreadOnly})
+}
+
+// ListEmailsFiltered lists emails from a folder matching filter, newest first
+func (c *GraphClient) ListEmailsFiltered(ctx context.Context, folderID string, limit int, filter ListFilter) ([]Email, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
 
 	// Build query parameters
 	pageSize := limit
-	if pageSize > 100 {
+	if pageSize <= 0 || pageSize > 100 {
 		pageSize = 100
 	}
 	params := url.Values{}
 	params.Set("$top", fmt.Sprintf("%d", pageSize))
 	params.Set("$orderby", "receivedDateTime desc")
-	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
 
-	if unreadOnly {
-		params.Set("$filter", "isRead eq false")
+	if filters := filter.clauses(); len(filters) > 0 {
+		params.Set("$filter", strings.Join(filters, " and "))
 	}
 
 	var allEmails []Email
 	currentEndpoint := endpoint + "?" + params.Encode()
 
 	for currentEndpoint != "" {
-		resp, err := c.doRequest("GET", currentEndpoint, nil)
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -171,7 +311,7 @@ func (c *GraphClient) ListEmails(folderID string, limit int, unreadOnly bool) ([
 
 		for _, msg := range result.Value {
 			allEmails = append(allEmails, graphMessageToEmail(msg))
-			if len(allEmails) >= limit {
+			if limit > 0 && len(allEmails) >= limit {
 				return allEmails, nil
 			}
 		}
@@ -183,13 +323,13 @@ func (c *GraphClient) ListEmails(folderID string, limit int, unreadOnly bool) ([
 }
 
 // GetEmail fetches a single email with full body
-func (c *GraphClient) GetEmail(folderID string, messageID string) (*Email, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s", GraphAPIBaseURL, url.PathEscape(folderID), messageID)
+func (c *GraphClient) GetEmail(ctx context.Context, folderID string, messageID string) (*Email, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s", c.baseURL, url.PathEscape(folderID), messageID)
 	params := url.Values{}
-	params.Set("$select", "id,subject,body,receivedDateTime,isRead,from,toRecipients,ccRecipients,hasAttachments,internetMessageId")
+	params.Set("$select", "id,subject,body,receivedDateTime,isRead,from,toRecipients,ccRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
 	endpoint += "?" + params.Encode()
 
-	resp, err := c.doRequest("GET", endpoint, nil)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -201,49 +341,80 @@ func (c *GraphClient) GetEmail(folderID string, messageID string) (*Email, error
 
 	email := graphMessageToEmail(msg)
 	email.Body = msg.Body.Content
+	email.BodyType = msg.Body.ContentType
 
 	return &email, nil
 }
 
 // MarkAsRead marks an email as read
-func (c *GraphClient) MarkAsRead(folderID string, messageID string) error {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s", GraphAPIBaseURL, url.PathEscape(folderID), messageID)
+func (c *GraphClient) MarkAsRead(ctx context.Context, folderID string, messageID string) error {
+	endpoint := c.baseURL + messagePath(folderID, messageID)
 	body := map[string]interface{}{"isRead": true}
 
 	jsonBody, _ := json.Marshal(body)
-	_, err := c.doRequest("PATCH", endpoint, jsonBody)
+	_, err := c.doRequest(ctx, "PATCH", endpoint, jsonBody)
 	return err
 }
 
 // MarkAsUnread marks an email as unread
-func (c *GraphClient) MarkAsUnread(folderID string, messageID string) error {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s", GraphAPIBaseURL, url.PathEscape(folderID), messageID)
+func (c *GraphClient) MarkAsUnread(ctx context.Context, folderID string, messageID string) error {
+	endpoint := c.baseURL + messagePath(folderID, messageID)
 	body := map[string]interface{}{"isRead": false}
 
 	jsonBody, _ := json.Marshal(body)
-	_, err := c.doRequest("PATCH", endpoint, jsonBody)
+	_, err := c.doRequest(ctx, "PATCH", endpoint, jsonBody)
 	return err
 }
 
 // MoveEmail moves an email to another folder
-func (c *GraphClient) MoveEmail(folderID string, messageID string, destinationFolderID string) error {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s/move", GraphAPIBaseURL, url.PathEscape(folderID), messageID)
+func (c *GraphClient) MoveEmail(ctx context.Context, folderID string, messageID string, destinationFolderID string) error {
+	endpoint := c.baseURL + messagePath(folderID, messageID) + "/move"
 	body := map[string]string{"destinationId": destinationFolderID}
 
 	jsonBody, _ := json.Marshal(body)
-	_, err := c.doRequest("POST", endpoint, jsonBody)
+	_, err := c.doRequest(ctx, "POST", endpoint, jsonBody)
+	return err
+}
+
+// MoveMessage moves a message to another folder and returns its new ID.
+// Graph assigns a new ID to moved messages unless immutable IDs are used.
+func (c *GraphClient) MoveMessage(ctx context.Context, messageID string, destinationFolderID string) (string, error) {
+	endpoint := c.baseURL + messagePath("", messageID) + "/move"
+	jsonBody, _ := json.Marshal(map[string]string{"destinationId": destinationFolderID})
+
+	resp, err := c.doRequest(ctx, "POST", endpoint, jsonBody)
+	if err != nil {
+		return "", err
+	}
+
+	var moved GraphMessageResponse
+	if err := json.Unmarshal(resp, &moved); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return moved.ID, nil
+}
+
+// SetFlag sets or clears the follow-up flag of a message
+func (c *GraphClient) SetFlag(ctx context.Context, messageID string, flagged bool) error {
+	status := "notFlagged"
+	if flagged {
+		status = "flagged"
+	}
+	jsonBody, _ := json.Marshal(map[string]interface{}{"flag": GraphFollowupFlag{FlagStatus: status}})
+
+	_, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", messageID), jsonBody)
 	return err
 }
 
 // TrashEmail moves an email to the deleted items folder
-func (c *GraphClient) TrashEmail(folderID string, messageID string) error {
-	return c.MoveEmail(folderID, messageID, "deleteditems")
+func (c *GraphClient) TrashEmail(ctx context.Context, folderID string, messageID string) error {
+	return c.MoveEmail(ctx, folderID, messageID, "deleteditems")
 }
 
 // ListEmailsFromSenders lists all emails from specific sender addresses (exact match)
 // It handles pagination to return all matching emails
 // Due to Graph API limitations on complex filters, this fetches all emails and filters in code
-func (c *GraphClient) ListEmailsFromSenders(folderID string, senderAddresses []string, limit int) ([]Email, error) {
+func (c *GraphClient) ListEmailsFromSenders(ctx context.Context, folderID string, senderAddresses []string, limit int) ([]Email, error) {
 	if len(senderAddresses) == 0 {
 		return nil, fmt.Errorf("at least one sender address required")
 	}
@@ -255,17 +426,17 @@ func (c *GraphClient) ListEmailsFromSenders(folderID string, senderAddresses []s
 	}
 
 	var allEmails []Email
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", GraphAPIBaseURL, url.PathEscape(folderID))
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
 
 	params := url.Values{}
 	params.Set("$top", "100") // Fetch in batches of 100
 	params.Set("$orderby", "receivedDateTime desc")
-	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
 
 	currentEndpoint := endpoint + "?" + params.Encode()
 
 	for currentEndpoint != "" {
-		resp, err := c.doRequest("GET", currentEndpoint, nil)
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -294,18 +465,24 @@ func (c *GraphClient) ListEmailsFromSenders(folderID string, senderAddresses []s
 	return allEmails, nil
 }
 
-// SearchEmails searches emails by criteria
-func (c *GraphClient) SearchEmails(folderID string, from, subject string, since time.Time, limit int) ([]Email, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", GraphAPIBaseURL, url.PathEscape(folderID))
+// SearchEmails searches emails by criteria.
+// An empty folderID searches all folders; a limit of 0 returns all matches.
+func (c *GraphClient) SearchEmails(ctx context.Context, folderID string, from, subject string, since time.Time, limit int) ([]Email, error) {
+	var endpoint string
+	if folderID == "" {
+		endpoint = fmt.Sprintf("%s/me/messages", c.baseURL)
+	} else {
+		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
+	}
 
 	pageSize := limit
-	if pageSize > 100 {
+	if pageSize <= 0 || pageSize > 100 {
 		pageSize = 100
 	}
 	params := url.Values{}
 	params.Set("$top", fmt.Sprintf("%d", pageSize))
 	params.Set("$orderby", "receivedDateTime desc")
-	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
 
 	// Build filter
 	var filters []string
@@ -327,7 +504,7 @@ func (c *GraphClient) SearchEmails(folderID string, from, subject string, since
 	currentEndpoint := endpoint + "?" + params.Encode()
 
 	for currentEndpoint != "" {
-		resp, err := c.doRequest("GET", currentEndpoint, nil)
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -339,7 +516,7 @@ func (c *GraphClient) SearchEmails(folderID string, from, subject string, since
 
 		for _, msg := range result.Value {
 			allEmails = append(allEmails, graphMessageToEmail(msg))
-			if len(allEmails) >= limit {
+			if limit > 0 && len(allEmails) >= limit {
 				return allEmails, nil
 			}
 		}
@@ -350,29 +527,30 @@ func (c *GraphClient) SearchEmails(folderID string, from, subject string, since
 	return allEmails, nil
 }
 
-// SearchEmailsKQL searches emails using KQL (Keyword Query Language) via the $search parameter
-func (c *GraphClient) SearchEmailsKQL(folderID, query string, limit int) ([]Email, error) {
+// SearchEmailsKQL searches emails using KQL (Keyword Query Language) via the $search parameter.
+// An empty folderID searches all folders; a limit of 0 returns all matches.
+func (c *GraphClient) SearchEmailsKQL(ctx context.Context, folderID, query string, limit int) ([]Email, error) {
 	var endpoint string
 	if folderID == "" {
-		endpoint = fmt.Sprintf("%s/me/messages", GraphAPIBaseURL)
+		endpoint = fmt.Sprintf("%s/me/messages", c.baseURL)
 	} else {
-		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/messages", GraphAPIBaseURL, url.PathEscape(folderID))
+		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
 	}
 
 	pageSize := limit
-	if pageSize > 100 {
+	if pageSize <= 0 || pageSize > 100 {
 		pageSize = 100
 	}
 	params := url.Values{}
 	params.Set("$top", fmt.Sprintf("%d", pageSize))
 	params.Set("$search", fmt.Sprintf("%q", query))
-	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
 
 	var allEmails []Email
 	currentEndpoint := endpoint + "?" + params.Encode()
 
 	for currentEndpoint != "" {
-		resp, err := c.doRequest("GET", currentEndpoint, nil)
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -384,7 +562,7 @@ func (c *GraphClient) SearchEmailsKQL(folderID, query string, limit int) ([]Emai
 
 		for _, msg := range result.Value {
 			allEmails = append(allEmails, graphMessageToEmail(msg))
-			if len(allEmails) >= limit {
+			if limit > 0 && len(allEmails) >= limit {
 				return allEmails, nil
 			}
 		}
@@ -396,10 +574,10 @@ func (c *GraphClient) SearchEmailsKQL(folderID, query string, limit int) ([]Emai
 }
 
 // GetAttachments downloads attachments from an email
-func (c *GraphClient) GetAttachments(folderID string, messageID string, saveDir string) ([]Attachment, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s/attachments", GraphAPIBaseURL, url.PathEscape(folderID), messageID)
+func (c *GraphClient) GetAttachments(ctx context.Context, folderID string, messageID string, saveDir string) ([]Attachment, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages/%s/attachments", c.baseURL, url.PathEscape(folderID), messageID)
 
-	resp, err := c.doRequest("GET", endpoint, nil)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -441,13 +619,13 @@ func (c *GraphClient) GetAttachments(folderID string, messageID string, saveDir
 }
 
 // ListFolders lists all mail folders
-func (c *GraphClient) ListFolders() ([]Folder, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders?$top=100", GraphAPIBaseURL)
+func (c *GraphClient) ListFolders(ctx context.Context) ([]Folder, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders?$top=100", c.baseURL)
 
 	var allFolders []Folder
 
 	for endpoint != "" {
-		resp, err := c.doRequest("GET", endpoint, nil)
+		resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -468,9 +646,11 @@ func (c *GraphClient) ListFolders() ([]Folder, error) {
 
 			// Fetch child folders if any
 			if f.ChildFolderCount > 0 {
-				children, err := c.listChildFolders(f.ID, f.DisplayName)
+				children, err := c.listChildFolders(ctx, f.ID, f.DisplayName)
 				if err == nil {
 					allFolders = append(allFolders, children...)
+				} else if ctx.Err() != nil {
+					return nil, ctx.Err()
 				}
 			}
 		}
@@ -482,10 +662,10 @@ func (c *GraphClient) ListFolders() ([]Folder, error) {
 }
 
 // listChildFolders recursively lists child folders
-func (c *GraphClient) listChildFolders(parentID, parentPath string) ([]Folder, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/childFolders", GraphAPIBaseURL, parentID)
+func (c *GraphClient) listChildFolders(ctx context.Context, parentID, parentPath string) ([]Folder, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/childFolders", c.baseURL, parentID)
 
-	resp, err := c.doRequest("GET", endpoint, nil)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -507,9 +687,11 @@ func (c *GraphClient) listChildFolders(parentID, parentPath string) ([]Folder, e
 		})
 
 		if f.ChildFolderCount > 0 {
-			children, err := c.listChildFolders(f.ID, fullPath)
+			children, err := c.listChildFolders(ctx, f.ID, fullPath)
 			if err == nil {
 				folders = append(folders, children...)
+			} else if ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
 		}
 	}
@@ -518,7 +700,7 @@ func (c *GraphClient) listChildFolders(parentID, parentPath string) ([]Folder, e
 }
 
 // GetFolderByName finds a folder by name and returns its ID
-func (c *GraphClient) GetFolderByName(name string) (string, error) {
+func (c *GraphClient) GetFolderByName(ctx context.Context, name string) (string, error) {
 	// Well-known folder names that can be used directly
 	wellKnown := map[string]string{
 		"inbox":        "inbox",
@@ -535,7 +717,7 @@ func (c *GraphClient) GetFolderByName(name string) (string, error) {
 	}
 
 	// Search in all folders
-	folders, err := c.ListFolders()
+	folders, err := c.ListFolders(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -546,34 +728,102 @@ func (c *GraphClient) GetFolderByName(name string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("folder '%s' not found", name)
+	return "", fmt.Errorf("folder '%s' %w", name, ErrNotFound)
+}
+
+// GetFolder fetches a folder by ID or well-known name (e.g. "deleteditems").
+// The returned folder carries the real folder ID.
+func (c *GraphClient) GetFolder(ctx context.Context, folderID string) (*Folder, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s", c.baseURL, url.PathEscape(folderID))
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var f GraphFolderResponse
+	if err := json.Unmarshal(resp, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &Folder{
+		ID:               f.ID,
+		Name:             f.DisplayName,
+		UnreadCount:      f.UnreadItemCount,
+		TotalCount:       f.TotalItemCount,
+		ChildFolderCount: f.ChildFolderCount,
+	}, nil
 }
 
 // CreateFolder creates a new mail folder
-func (c *GraphClient) CreateFolder(name string, parentFolderID string) error {
+func (c *GraphClient) CreateFolder(ctx context.Context, name string, parentFolderID string) error {
 	var endpoint string
 	if parentFolderID != "" {
-		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/childFolders", GraphAPIBaseURL, parentFolderID)
+		endpoint = fmt.Sprintf("%s/me/mailFolders/%s/childFolders", c.baseURL, parentFolderID)
 	} else {
-		endpoint = fmt.Sprintf("%s/me/mailFolders", GraphAPIBaseURL)
+		endpoint = fmt.Sprintf("%s/me/mailFolders", c.baseURL)
 	}
 
 	body := map[string]string{"displayName": name}
 	jsonBody, _ := json.Marshal(body)
 
-	_, err := c.doRequest("POST", endpoint, jsonBody)
+	_, err := c.doRequest(ctx, "POST", endpoint, jsonBody)
 	return err
 }
 
 // DeleteFolder deletes a mail folder
-func (c *GraphClient) DeleteFolder(folderID string) error {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/%s", GraphAPIBaseURL, folderID)
-	_, err := c.doRequest("DELETE", endpoint, nil)
+func (c *GraphClient) DeleteFolder(ctx context.Context, folderID string) error {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s", c.baseURL, folderID)
+	_, err := c.doRequest(ctx, "DELETE", endpoint, nil)
 	return err
 }
 
-// Send sends an email
-func (c *GraphClient) Send(opts SendOptions) error {
+// Send sends an email. Messages with attachments too large for a single
+// request are created as a draft, completed with upload sessions and then sent.
+// A plain-text alternative requires sending the message as MIME, which
+// also goes through a draft when receipts are requested. Deferred messages
+// are always sent as a draft.
+func (c *GraphClient) Send(ctx context.Context, opts SendOptions) error {
+	if err := checkAttachmentsSize(attachmentsSize(opts.Attachments)); err != nil {
+		return err
+	}
+	if !canInlineAttachments(opts.Attachments) || !opts.SendAt.IsZero() || (opts.TextBody != "" && opts.hasReceipts()) {
+		draftID, err := c.SaveDraft(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if err := c.SendDraft(ctx, draftID); err != nil {
+			return fmt.Errorf("message saved as draft %s but not sent: %w", draftID, err)
+		}
+		return nil
+	}
+
+	if opts.TextBody != "" {
+		content, err := buildMIME(opts, true)
+		if err != nil {
+			return err
+		}
+		encoded := []byte(base64.StdEncoding.EncodeToString(content))
+		_, err = c.doRequestWithType(ctx, "POST", c.baseURL+"/me/sendMail", "text/plain", encoded)
+		return err
+	}
+
+	request := map[string]interface{}{
+		"message":         newSendMessage(opts, true),
+		"saveToSentItems": true,
+	}
+
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	_, err = c.doRequest(ctx, "POST", c.baseURL+"/me/sendMail", jsonBody)
+	return err
+}
+
+// newSendMessage builds the Graph message payload, with the attachments
+// inlined when withAttachments is set
+func newSendMessage(opts SendOptions, withAttachments bool) map[string]interface{} {
 	toRecipients := make([]GraphEmailAddressWrapper, len(opts.To))
 	for i, to := range opts.To {
 		toRecipients[i] = GraphEmailAddressWrapper{
@@ -615,104 +865,45 @@ func (c *GraphClient) Send(opts SendOptions) error {
 	if len(bccRecipients) > 0 {
 		message["bccRecipients"] = bccRecipients
 	}
-
-	request := map[string]interface{}{
-		"message":         message,
-		"saveToSentItems": true,
-	}
-
-	jsonBody, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	_, err = c.doRequest("POST", GraphAPIBaseURL+"/me/sendMail", jsonBody)
-	return err
-}
-
-// Reply sends a reply using native Graph API
-func (c *GraphClient) Reply(messageID string, comment string, replyAll bool) error {
-	action := "reply"
-	if replyAll {
-		action = "replyAll"
-	}
-	endpoint := fmt.Sprintf("%s/me/messages/%s/%s", GraphAPIBaseURL, messageID, action)
-
-	body := map[string]interface{}{}
-	if comment != "" {
-		body["comment"] = comment
-	}
-
-	jsonBody, _ := json.Marshal(body)
-	_, err := c.doRequest("POST", endpoint, jsonBody)
-	return err
-}
-
-// Forward forwards an email using native Graph API
-func (c *GraphClient) Forward(messageID string, to []string, comment string) error {
-	endpoint := fmt.Sprintf("%s/me/messages/%s/forward", GraphAPIBaseURL, messageID)
-
-	toRecipients := make([]GraphEmailAddressWrapper, len(to))
-	for i, addr := range to {
-		toRecipients[i] = GraphEmailAddressWrapper{
-			EmailAddress: GraphEmailAddress{Address: ParseEmail(addr)},
+	setMessageProperties(message, opts)
+	if withAttachments && len(opts.Attachments) > 0 {
+		attachments := make([]map[string]interface{}, len(opts.Attachments))
+		for i, att := range opts.Attachments {
+			attachments[i] = fileAttachmentJSON(att)
 		}
+		message["attachments"] = attachments
 	}
 
-	body := map[string]interface{}{
-		"toRecipients": toRecipients,
-	}
-	if comment != "" {
-		body["comment"] = comment
-	}
-
-	jsonBody, _ := json.Marshal(body)
-	_, err := c.doRequest("POST", endpoint, jsonBody)
-	return err
+	return message
 }
 
 // SaveDraft saves an email as draft and returns the draft ID
-func (c *GraphClient) SaveDraft(to, cc []string, subject, body string, html bool) (string, error) {
-	toRecipients := make([]GraphEmailAddressWrapper, len(to))
-	for i, addr := range to {
-		toRecipients[i] = GraphEmailAddressWrapper{
-			EmailAddress: GraphEmailAddress{Address: ParseEmail(addr)},
-		}
+func (c *GraphClient) SaveDraft(ctx context.Context, opts SendOptions) (string, error) {
+	if err := checkAttachmentsSize(attachmentsSize(opts.Attachments)); err != nil {
+		return "", err
 	}
+	inline := canInlineAttachments(opts.Attachments)
 
-	ccRecipients := make([]GraphEmailAddressWrapper, len(cc))
-	for i, addr := range cc {
-		ccRecipients[i] = GraphEmailAddressWrapper{
-			EmailAddress: GraphEmailAddress{Address: ParseEmail(addr)},
+	var resp []byte
+	if opts.TextBody != "" {
+		content, err := buildMIME(opts, inline)
+		if err != nil {
+			return "", err
+		}
+		encoded := []byte(base64.StdEncoding.EncodeToString(content))
+		resp, err = c.doRequestWithType(ctx, "POST", c.baseURL+"/me/messages", "text/plain", encoded)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		jsonBody, err := json.Marshal(newSendMessage(opts, inline))
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal request: %w", err)
+		}
+		resp, err = c.doRequest(ctx, "POST", c.baseURL+"/me/messages", jsonBody)
+		if err != nil {
+			return "", err
 		}
-	}
-
-	contentType := "text"
-	if html {
-		contentType = "html"
-	}
-
-	message := map[string]interface{}{
-		"subject": subject,
-		"body": map[string]string{
-			"contentType": contentType,
-			"content":     body,
-		},
-		"toRecipients": toRecipients,
-	}
-
-	if len(ccRecipients) > 0 {
-		message["ccRecipients"] = ccRecipients
-	}
-
-	jsonBody, err := json.Marshal(message)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	resp, err := c.doRequest("POST", GraphAPIBaseURL+"/me/messages", jsonBody)
-	if err != nil {
-		return "", err
 	}
 
 	var result struct {
@@ -722,58 +913,123 @@ func (c *GraphClient) SaveDraft(to, cc []string, subject, body string, html bool
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	if patch := mimeDraftPatch(opts); opts.TextBody != "" && patch != nil {
+		jsonBody, _ := json.Marshal(patch)
+		if _, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", result.ID), jsonBody); err != nil {
+			c.DeleteDraft(context.WithoutCancel(ctx), result.ID)
+			return "", err
+		}
+	}
+
+	if !inline {
+		if err := c.addAttachments(ctx, result.ID, opts.Attachments); err != nil {
+			// Don't leave a draft behind that is missing attachments
+			c.DeleteDraft(context.WithoutCancel(ctx), result.ID)
+			return "", err
+		}
+	}
+
 	return result.ID, nil
 }
 
 // ListDrafts lists draft emails
-func (c *GraphClient) ListDrafts(limit int) ([]Email, error) {
-	return c.ListEmails("drafts", limit, false)
+func (c *GraphClient) ListDrafts(ctx context.Context, limit int) ([]Email, error) {
+	return c.ListEmails(ctx, "drafts", limit, false)
 }
 
 // SendDraft sends a draft and deletes it
-func (c *GraphClient) SendDraft(messageID string) error {
-	endpoint := fmt.Sprintf("%s/me/messages/%s/send", GraphAPIBaseURL, messageID)
-	_, err := c.doRequest("POST", endpoint, nil)
+func (c *GraphClient) SendDraft(ctx context.Context, messageID string) error {
+	endpoint := fmt.Sprintf("%s/me/messages/%s/send", c.baseURL, messageID)
+	_, err := c.doRequest(ctx, "POST", endpoint, nil)
 	return err
 }
 
 // DeleteDraft deletes a draft
-func (c *GraphClient) DeleteDraft(messageID string) error {
-	endpoint := fmt.Sprintf("%s/me/messages/%s", GraphAPIBaseURL, messageID)
-	_, err := c.doRequest("DELETE", endpoint, nil)
+func (c *GraphClient) DeleteDraft(ctx context.Context, messageID string) error {
+	endpoint := fmt.Sprintf("%s/me/messages/%s", c.baseURL, messageID)
+	_, err := c.doRequest(ctx, "DELETE", endpoint, nil)
 	return err
 }
 
-// doRequest performs an HTTP request to Graph API
-func (c *GraphClient) doRequest(method, endpoint string, body []byte) ([]byte, error) {
+// doRequest performs an HTTP request to Graph API.
+// Throttled and unavailable responses are retried according to the client's RetryPolicy.
+func (c *GraphClient) doRequest(ctx context.Context, method, endpoint string, body []byte) ([]byte, error) {
+	return c.doRequestWithType(ctx, method, endpoint, "application/json", body)
+}
+
+// doRequestWithType is doRequest for request bodies that are not JSON
+func (c *GraphClient) doRequestWithType(ctx context.Context, method, endpoint, contentType string, body []byte) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		respBody, status, retryAfter, err := c.doRequestOnce(ctx, method, endpoint, contentType, body)
+
+		retryable := false
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if err != nil {
+			// Transport errors leave it unknown whether the request was processed
+			retryable = isIdempotent(method)
+		} else if status >= 400 {
+			retryable = shouldRetry(method, status)
+		}
+
+		if !retryable || attempt >= c.retry.MaxAttempts {
+			if err != nil {
+				return nil, err
+			}
+			if status >= 400 {
+				return nil, newGraphError(status, respBody)
+			}
+			return respBody, nil
+		}
+
+		wait := c.retry.backoff(attempt, retryAfter)
+		if err != nil {
+			c.logf("%s %s failed (%v), retrying in %s (attempt %d/%d)", method, endpoint, err, wait, attempt+1, c.retry.MaxAttempts)
+		} else {
+			c.logf("%s %s returned %d, retrying in %s (attempt %d/%d)", method, endpoint, status, wait, attempt+1, c.retry.MaxAttempts)
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doRequestOnce performs a single HTTP request and returns body, status and Retry-After header
+func (c *GraphClient) doRequestOnce(ctx context.Context, method, endpoint, contentType string, body []byte) ([]byte, int, string, error) {
 	var req *http.Request
 	var err error
 
 	if body != nil {
-		req, err = http.NewRequest(method, endpoint, bytes.NewBuffer(body))
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, bytes.NewBuffer(body))
 	} else {
-		req, err = http.NewRequest(method, endpoint, nil)
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, nil)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", c.userAgent)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, 0, "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("Graph API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
+	return respBody, resp.StatusCode, resp.Header.Get("Retry-After"), nil
+}
 
-	return respBody, nil
+// messagePath returns the path of a message below the Graph root,
+// scoped to folderID unless it is empty
+func messagePath(folderID, messageID string) string {
+	if folderID == "" {
+		return "/me/messages/" + messageID
+	}
+	return fmt.Sprintf("/me/mailFolders/%s/messages/%s", url.PathEscape(folderID), messageID)
 }
 
 // graphMessageToEmail converts a Graph API message to our Email struct
@@ -783,6 +1039,16 @@ func graphMessageToEmail(msg GraphMessageResponse) Email {
 		Subject:   msg.Subject,
 		Preview:   msg.BodyPreview,
 		Unread:    !msg.IsRead,
+		Flagged:   msg.Flag != nil && msg.Flag.FlagStatus == "flagged",
+
+		ConversationID: msg.ConversationId,
+		Categories:     msg.Categories,
+		Importance:     msg.Importance,
+	}
+	if msg.Flag != nil && msg.Flag.DueDateTime != nil {
+		if due, ok := msg.Flag.DueDateTime.Time(); ok {
+			email.FlagDue = &due
+		}
 	}
 
 	if t, err := time.Parse(time.RFC3339, msg.ReceivedDateTime); err == nil {
@@ -796,6 +1062,12 @@ func graphMessageToEmail(msg GraphMessageResponse) Email {
 	for _, to := range msg.ToRecipients {
 		email.To = append(email.To, formatGraphAddress(to.EmailAddress))
 	}
+	for _, cc := range msg.CcRecipients {
+		email.Cc = append(email.Cc, formatGraphAddress(cc.EmailAddress))
+	}
+	for _, bcc := range msg.BccRecipients {
+		email.Bcc = append(email.Bcc, formatGraphAddress(bcc.EmailAddress))
+	}
 
 	return email
 }
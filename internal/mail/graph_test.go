@@ -0,0 +1,351 @@
+package mail_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+var ctx = context.Background()
+
+func addMessages(srv *graphtest.Server, folderID, from string, n int) []string {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		ids[i] = srv.AddMessage(graphtest.Message{
+			FolderID: folderID,
+			Subject:  fmt.Sprintf("Message %d", i),
+			Body:     "Body",
+			From:     from,
+			To:       []string{"me@example.com"},
+			Received: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return ids
+}
+
+func TestNewGraphClientWithOptions_Defaults(t *testing.T) {
+	c := mail.NewGraphClientWithOptions("token", mail.ClientOptions{})
+	if c.BaseURL() != mail.GraphAPIBaseURL {
+		t.Errorf("BaseURL = %q, want %q", c.BaseURL(), mail.GraphAPIBaseURL)
+	}
+
+	c = mail.NewGraphClientWithOptions("token", mail.ClientOptions{BaseURL: "https://graph.microsoft.us/v1.0/"})
+	if c.BaseURL() != "https://graph.microsoft.us/v1.0" {
+		t.Errorf("trailing slash not trimmed: %q", c.BaseURL())
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.Write([]byte(`{"value":[]}`))
+	}))
+	defer srv.Close()
+
+	c := mail.NewGraphClientWithOptions("token", mail.ClientOptions{BaseURL: srv.URL, UserAgent: "test-agent/1.0"})
+	if _, err := c.ListRules(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got != "test-agent/1.0" {
+		t.Errorf("User-Agent = %q", got)
+	}
+}
+
+func TestListEmails_Pagination(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 3
+
+	addMessages(srv, "inbox", "sender@example.com", 8)
+
+	emails, err := srv.Client().ListEmails(ctx, "inbox", 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 5 {
+		t.Fatalf("got %d emails, want 5", len(emails))
+	}
+	if emails[0].Subject != "Message 7" {
+		t.Errorf("first email = %q, want newest first", emails[0].Subject)
+	}
+	if n := srv.CountRequests("GET", "/v1.0/me/mailFolders/inbox/messages"); n != 2 {
+		t.Errorf("made %d page requests, want 2", n)
+	}
+
+	emails, err = srv.Client().ListEmails(ctx, "inbox", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 8 {
+		t.Errorf("got %d emails, want all 8", len(emails))
+	}
+}
+
+func TestListEmails_UnreadOnly(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	srv.AddMessage(graphtest.Message{Subject: "read", IsRead: true})
+	srv.AddMessage(graphtest.Message{Subject: "unread"})
+
+	emails, err := srv.Client().ListEmails(ctx, "inbox", 10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0].Subject != "unread" {
+		t.Errorf("got %+v, want only the unread email", emails)
+	}
+}
+
+func TestListEmailsFromSenders_ExactMatch(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+
+	addMessages(srv, "inbox", "news@example.com", 3)
+	addMessages(srv, "inbox", "other-news@example.com", 2)
+
+	emails, err := srv.Client().ListEmailsFromSenders(ctx, "inbox", []string{"NEWS@example.com"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 3 {
+		t.Errorf("got %d emails, want 3", len(emails))
+	}
+}
+
+func TestSearchEmails(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	old := time.Now().Add(-72 * time.Hour)
+	srv.AddMessage(graphtest.Message{Subject: "Quarterly report", From: "boss@example.com"})
+	srv.AddMessage(graphtest.Message{Subject: "Old report", From: "boss@example.com", Received: old})
+	srv.AddMessage(graphtest.Message{Subject: "Lunch", From: "friend@example.com"})
+
+	emails, err := srv.Client().SearchEmails(ctx, "inbox", "boss", "report", time.Now().Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0].Subject != "Quarterly report" {
+		t.Errorf("got %+v", emails)
+	}
+}
+
+func TestListFolders_ChildFolders(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	projects := srv.AddFolder("", "Projects")
+	alpha := srv.AddFolder(projects, "Alpha")
+	srv.AddFolder(alpha, "Specs")
+
+	c := srv.Client()
+	folders, err := c.ListFolders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, f := range folders {
+		names[f.Name] = true
+	}
+	for _, want := range []string{"Inbox", "Projects", "Projects/Alpha", "Projects/Alpha/Specs"} {
+		if !names[want] {
+			t.Errorf("missing folder %q in %v", want, names)
+		}
+	}
+
+	id, err := c.GetFolderByName(ctx, "projects/alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != alpha {
+		t.Errorf("GetFolderByName = %q, want %q", id, alpha)
+	}
+}
+
+func TestMoveAndMarkRead(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	id := srv.AddMessage(graphtest.Message{Subject: "hello"})
+	c := srv.Client()
+
+	if err := c.MarkAsRead(ctx, "inbox", id); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MoveEmail(ctx, "inbox", id, "archive"); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, _ := srv.Message(id)
+	if !msg.IsRead || msg.FolderID != "archive" {
+		t.Errorf("message = %+v, want read and archived", msg)
+	}
+
+	if err := c.MarkAsRead(ctx, "inbox", id); err == nil {
+		t.Error("expected error for message no longer in inbox")
+	}
+}
+
+func TestSendAndDrafts(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	err := c.Send(ctx, mail.SendOptions{
+		To:      []string{"Alice <alice@example.com>"},
+		Subject: "Hi",
+		Body:    "<p>Hello</p>",
+		HTML:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].To[0] != "alice@example.com" || sent[0].BodyType != "html" {
+		t.Fatalf("sent = %+v", sent)
+	}
+
+	draftID, err := c.SaveDraft(ctx, mail.SendOptions{To: []string{"bob@example.com"}, Subject: "Draft", Body: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendDraft(ctx, draftID); err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := srv.Message(draftID); msg.FolderID != "sentitems" {
+		t.Errorf("draft folder = %q, want sentitems", msg.FolderID)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	c := mail.NewGraphClientWithOptions("wrong", mail.ClientOptions{BaseURL: srv.BaseURL()})
+	if _, err := c.ListFolders(ctx); err == nil {
+		t.Error("expected error for invalid token")
+	}
+}
+
+func fastRetryClient(srv *graphtest.Server, attempts int) *mail.GraphClient {
+	return mail.NewGraphClientWithOptions(graphtest.Token, mail.ClientOptions{
+		BaseURL: srv.BaseURL(),
+		Retry:   &mail.RetryPolicy{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+}
+
+func TestRetry_ThrottledGetSucceeds(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.AddMessage(graphtest.Message{Subject: "hello"})
+	srv.InjectFault(graphtest.Fault{Method: "GET", PathPrefix: "/me/mailFolders/inbox/messages", Status: 429, RetryAfter: "0", Times: 2})
+
+	emails, err := fastRetryClient(srv, 3).ListEmails(ctx, "inbox", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 {
+		t.Errorf("got %d emails", len(emails))
+	}
+	if n := srv.CountRequests("GET", "/v1.0/me/mailFolders/inbox/messages"); n != 3 {
+		t.Errorf("made %d requests, want 3", n)
+	}
+}
+
+func TestRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(graphtest.Fault{PathPrefix: "/me/mailFolders", Status: 503, Times: 10})
+
+	if _, err := fastRetryClient(srv, 3).ListFolders(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	if n := srv.CountRequests("GET", "/v1.0/me/mailFolders"); n != 3 {
+		t.Errorf("made %d requests, want 3", n)
+	}
+}
+
+func TestRetry_SendMailNotRetriedOnUnavailable(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(graphtest.Fault{PathPrefix: "/me/sendMail", Status: 503, Times: 1})
+
+	err := fastRetryClient(srv, 3).Send(ctx, mail.SendOptions{To: []string{"a@example.com"}, Subject: "x", Body: "y"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if n := srv.CountRequests("POST", "/v1.0/me/sendMail"); n != 1 {
+		t.Errorf("sendMail attempted %d times, want 1", n)
+	}
+}
+
+func TestRetry_SendMailRetriedWhenThrottled(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.InjectFault(graphtest.Fault{PathPrefix: "/me/sendMail", Status: 429, RetryAfter: "0", Times: 1})
+
+	err := fastRetryClient(srv, 3).Send(ctx, mail.SendOptions{To: []string{"a@example.com"}, Subject: "x", Body: "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Messages("sentitems")) != 1 {
+		t.Error("mail should be sent exactly once")
+	}
+}
+
+func TestGetConversation(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv.AddMessage(graphtest.Message{FolderID: "inbox", Subject: "RE: Plan", ConversationID: "c1", Received: base.Add(2 * time.Hour)})
+	srv.AddMessage(graphtest.Message{FolderID: "sentitems", Subject: "Plan", ConversationID: "c1", Received: base})
+	srv.AddMessage(graphtest.Message{FolderID: "inbox", Subject: "Other", ConversationID: "c2", Received: base.Add(time.Hour)})
+	srv.AddMessage(graphtest.Message{FolderID: "deleteditems", Subject: "RE: RE: Plan", ConversationID: "c1", Received: base.Add(3 * time.Hour)})
+
+	emails, err := srv.Client().GetConversation(ctx, "c1", mail.ThreadFolders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 2 || emails[0].Subject != "Plan" || emails[1].Subject != "RE: Plan" {
+		t.Fatalf("got %+v, want Plan then RE: Plan", emails)
+	}
+	if emails[0].ConversationID != "c1" {
+		t.Errorf("ConversationID = %q", emails[0].ConversationID)
+	}
+
+	if _, err := srv.Client().GetConversation(ctx, "c1", []string{"missing", "inbox"}); err != nil {
+		t.Errorf("missing folder should be skipped: %v", err)
+	}
+}
+
+func TestGroupThreads(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	emails := []mail.Email{
+		{MessageID: "m4", ConversationID: "c1", Subject: "RE: Plan", Date: base.Add(3 * time.Hour), Unread: true},
+		{MessageID: "m3", ConversationID: "c2", Subject: "Other", Date: base.Add(2 * time.Hour)},
+		{MessageID: "m2", Subject: "No conversation", Date: base.Add(time.Hour)},
+		{MessageID: "m1", ConversationID: "c1", Subject: "Plan", Date: base},
+	}
+
+	threads := mail.GroupThreads(emails)
+	if len(threads) != 3 {
+		t.Fatalf("got %d threads, want 3", len(threads))
+	}
+	if th := threads[0]; th.ConversationID != "c1" || len(th.Messages) != 2 || th.UnreadCount != 1 || !th.Latest.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("thread 0 = %+v", th)
+	}
+	if threads[2].Messages[0].MessageID != "m2" {
+		t.Errorf("thread 2 = %+v", threads[2])
+	}
+}
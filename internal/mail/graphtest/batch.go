@@ -0,0 +1,137 @@
+package graphtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+// maxBatchRequests is the Graph limit of sub-requests per $batch call
+const maxBatchRequests = 20
+
+type batchRequest struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+type batchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// handleBatch executes the sub-requests of a JSON batch in order.
+// Requests whose dependency failed get 424 Failed Dependency like on Graph.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var payload struct {
+		Requests []batchRequest `json:"requests"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Invalid batch payload.")
+		return
+	}
+	if len(payload.Requests) > maxBatchRequests {
+		writeError(w, http.StatusBadRequest, "BadRequest",
+			fmt.Sprintf("Number of batch request steps exceeds the maximum value of %d.", maxBatchRequests))
+		return
+	}
+
+	// Like Graph, reject the whole batch if a request depends on one that
+	// is not part of it
+	ids := map[string]bool{}
+	for _, req := range payload.Requests {
+		ids[req.ID] = true
+	}
+	for _, req := range payload.Requests {
+		for _, dep := range req.DependsOn {
+			if !ids[dep] {
+				writeError(w, http.StatusBadRequest, "BadRequest",
+					fmt.Sprintf("Request %s depends on %s, which is not in the batch.", req.ID, dep))
+				return
+			}
+		}
+	}
+
+	status := map[string]int{}
+	responses := make([]batchResponse, 0, len(payload.Requests))
+	for _, req := range payload.Requests {
+		if failed := failedDependency(req.DependsOn, status); failed != "" {
+			status[req.ID] = http.StatusFailedDependency
+			responses = append(responses, batchErrorResponse(req.ID, http.StatusFailedDependency,
+				"FailedDependency", "Dependent request "+failed+" failed."))
+			continue
+		}
+
+		resp := s.serveBatchItem(r, req)
+		status[req.ID] = resp.Status
+		responses = append(responses, resp)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"responses": responses})
+}
+
+// serveBatchItem runs one sub-request through the regular routing
+func (s *Server) serveBatchItem(parent *http.Request, req batchRequest) batchResponse {
+	path := "/v1.0" + req.URL
+	body := []byte(req.Body)
+
+	sub := httptest.NewRequest(req.Method, path, bytes.NewReader(body))
+	sub.Header = parent.Header.Clone()
+
+	s.requests = append(s.requests, Request{
+		Method: req.Method,
+		Path:   sub.URL.Path,
+		Query:  sub.URL.Query(),
+		Body:   body,
+	})
+
+	rec := httptest.NewRecorder()
+	s.serve(rec, sub, body)
+
+	resp := batchResponse{ID: req.ID, Status: rec.Code}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "" {
+		resp.Headers = map[string]string{"Retry-After": retryAfter}
+	}
+	if b := bytes.TrimSpace(rec.Body.Bytes()); len(b) > 0 {
+		resp.Body = b
+	}
+	return resp
+}
+
+func failedDependency(dependsOn []string, status map[string]int) string {
+	for _, id := range dependsOn {
+		if code, ok := status[id]; !ok || code >= 400 {
+			return id
+		}
+	}
+	return ""
+}
+
+func batchErrorResponse(id string, status int, code, message string) batchResponse {
+	rec := httptest.NewRecorder()
+	writeError(rec, status, code, message)
+	return batchResponse{ID: id, Status: status, Body: bytes.TrimSpace(rec.Body.Bytes())}
+}
+
+// BatchCalls returns the number of sub-requests in each $batch call received
+func (s *Server) BatchCalls() []int {
+	var sizes []int
+	for _, r := range s.Requests() {
+		if r.Method != http.MethodPost || r.Path != "/v1.0/$batch" {
+			continue
+		}
+		var payload struct {
+			Requests []json.RawMessage `json:"requests"`
+		}
+		json.Unmarshal(r.Body, &payload)
+		sizes = append(sizes, len(payload.Requests))
+	}
+	return sizes
+}
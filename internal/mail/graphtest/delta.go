@@ -0,0 +1,107 @@
+package graphtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// deltaState is the folder snapshot a delta token refers to,
+// mapping message IDs to a fingerprint of their content
+type deltaState struct {
+	folderID string
+	messages map[string]string
+}
+
+// deltaPage holds the remaining changes of a paged delta response
+type deltaPage struct {
+	items []interface{}
+	token string
+}
+
+// ExpireDeltaTokens invalidates all delta tokens issued so far, so the
+// next delta request with one of them fails with 410 Gone like on Graph
+func (s *Server) ExpireDeltaTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deltaStates = nil
+}
+
+// handleDelta implements /me/mailFolders/{id}/messages/delta by diffing the
+// folder against the snapshot taken when the delta token was issued
+func (s *Server) handleDelta(w http.ResponseWriter, r *http.Request, folderID string) {
+	q := r.URL.Query()
+
+	if skip := q.Get("$skiptoken"); skip != "" {
+		page, ok := s.deltaPages[skip]
+		if !ok {
+			writeError(w, http.StatusGone, "SyncStateNotFound", "The sync state is not found or expired.")
+			return
+		}
+		delete(s.deltaPages, skip)
+		s.writeDeltaPage(w, r, page)
+		return
+	}
+
+	previous := map[string]string{}
+	if token := q.Get("$deltatoken"); token != "" {
+		state, ok := s.deltaStates[token]
+		if !ok || state.folderID != folderID {
+			writeError(w, http.StatusGone, "SyncStateNotFound", "The sync state is not found or expired.")
+			return
+		}
+		previous = state.messages
+	}
+
+	current := map[string]string{}
+	var items []interface{}
+	for _, m := range s.folderMessages(folderID) {
+		data := s.messageJSON(m)
+		fingerprint, _ := json.Marshal(data)
+		current[m.ID] = string(fingerprint)
+		if previous[m.ID] != string(fingerprint) {
+			items = append(items, data)
+		}
+	}
+	for id := range previous {
+		if _, ok := current[id]; !ok {
+			items = append(items, map[string]interface{}{
+				"id":       id,
+				"@removed": map[string]string{"reason": "deleted"},
+			})
+		}
+	}
+
+	if s.deltaStates == nil {
+		s.deltaStates = map[string]*deltaState{}
+	}
+	token := s.newID("delta")
+	s.deltaStates[token] = &deltaState{folderID: folderID, messages: current}
+
+	s.writeDeltaPage(w, r, &deltaPage{items: items, token: token})
+}
+
+func (s *Server) writeDeltaPage(w http.ResponseWriter, r *http.Request, page *deltaPage) {
+	link := func(param, value string) string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		u.RawQuery = url.Values{param: {value}}.Encode()
+		return u.String()
+	}
+
+	items := page.items
+	resp := map[string]interface{}{}
+	if s.PageSize > 0 && len(items) > s.PageSize {
+		if s.deltaPages == nil {
+			s.deltaPages = map[string]*deltaPage{}
+		}
+		skip := s.newID("skip")
+		s.deltaPages[skip] = &deltaPage{items: items[s.PageSize:], token: page.token}
+		items = items[:s.PageSize]
+		resp["@odata.nextLink"] = link("$skiptoken", skip)
+	} else {
+		resp["@odata.deltaLink"] = link("$deltatoken", page.token)
+	}
+	resp["value"] = nonNil(items)
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,157 @@
+package graphtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+var (
+	containsClause   = regexp.MustCompile(`^contains\(([\w/]+),'(.*)'\)$`)
+	comparisonClause = regexp.MustCompile(`^([\w/]+) (eq|ne|ge|gt|le|lt) (.+)$`)
+	anyClause        = regexp.MustCompile(`^categories/any\((\w+):(\w+) eq '(.*)'\)$`)
+)
+
+// parseFilter compiles the small OData $filter subset used by GraphClient.
+// Clauses may only be combined with "and".
+func parseFilter(filter string) (func(*Message) bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(*Message) bool { return true }, nil
+	}
+
+	var preds []func(*Message) bool
+	for _, clause := range strings.Split(filter, " and ") {
+		pred, err := parseClause(strings.TrimSpace(clause))
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, pred)
+	}
+
+	return func(m *Message) bool {
+		for _, p := range preds {
+			if !p(m) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseClause(clause string) (func(*Message) bool, error) {
+	if match := containsClause.FindStringSubmatch(clause); match != nil {
+		field, value := match[1], strings.ToLower(strings.ReplaceAll(match[2], "''", "'"))
+		get, err := stringField(field)
+		if err != nil {
+			return nil, err
+		}
+		return func(m *Message) bool {
+			return strings.Contains(strings.ToLower(get(m)), value)
+		}, nil
+	}
+
+	if match := anyClause.FindStringSubmatch(clause); match != nil {
+		if match[1] != match[2] {
+			return nil, fmt.Errorf("unsupported filter clause: %s", clause)
+		}
+		value := strings.ReplaceAll(match[3], "''", "'")
+		return func(m *Message) bool {
+			for _, c := range m.Categories {
+				if strings.EqualFold(c, value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	match := comparisonClause.FindStringSubmatch(clause)
+	if match == nil {
+		return nil, fmt.Errorf("unsupported filter clause: %s", clause)
+	}
+	field, op, value := match[1], match[2], match[3]
+
+	switch field {
+	case "isRead", "isDraft":
+		want := value == "true"
+		if op != "eq" && op != "ne" {
+			return nil, fmt.Errorf("unsupported operator %s for %s", op, field)
+		}
+		return func(m *Message) bool {
+			got := m.IsRead
+			if field == "isDraft" {
+				got = m.IsDraft
+			}
+			return (got == want) == (op == "eq")
+		}, nil
+
+	case "receivedDateTime":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in filter: %s", value)
+		}
+		return func(m *Message) bool {
+			return compareTime(m.Received, op, t)
+		}, nil
+
+	default:
+		get, err := stringField(field)
+		if err != nil {
+			return nil, err
+		}
+		value = strings.Trim(value, "'")
+		if op != "eq" && op != "ne" {
+			return nil, fmt.Errorf("unsupported operator %s for %s", op, field)
+		}
+		return func(m *Message) bool {
+			return strings.EqualFold(get(m), value) == (op == "eq")
+		}, nil
+	}
+}
+
+func stringField(field string) (func(*Message) string, error) {
+	switch field {
+	case "subject":
+		return func(m *Message) string { return m.Subject }, nil
+	case "from/emailAddress/address":
+		return func(m *Message) string { return mail.ParseEmail(m.From) }, nil
+	case "body/content":
+		return func(m *Message) string { return m.Body }, nil
+	case "conversationId":
+		return func(m *Message) string { return m.ConversationID }, nil
+	case "importance":
+		return func(m *Message) string { return m.Importance }, nil
+	case "flag/flagStatus":
+		return func(m *Message) string {
+			switch {
+			case m.Flagged:
+				return mail.FlagStatusFlagged
+			case m.FlagComplete:
+				return mail.FlagStatusComplete
+			}
+			return mail.FlagStatusNotFlagged
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported filter field: %s", field)
+}
+
+func compareTime(got time.Time, op string, want time.Time) bool {
+	switch op {
+	case "eq":
+		return got.Equal(want)
+	case "ne":
+		return !got.Equal(want)
+	case "ge":
+		return !got.Before(want)
+	case "gt":
+		return got.After(want)
+	case "le":
+		return !got.After(want)
+	case "lt":
+		return got.Before(want)
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package graphtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// messageMIME returns the RFC 822 form of a message as served by $value
+func messageMIME(m *Message) []byte {
+	if len(m.MIME) > 0 {
+		return m.MIME
+	}
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+		}
+	}
+
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Cc", strings.Join(m.Cc, ", "))
+	header("Subject", m.Subject)
+	header("Date", m.Received.UTC().Format(time.RFC1123Z))
+	header("Message-ID", m.InternetMessageID)
+	for _, h := range m.Headers {
+		header(h.Name, h.Value)
+	}
+	header("MIME-Version", "1.0")
+
+	contentType := "text/plain"
+	if m.BodyType == "html" {
+		contentType = "text/html"
+	}
+	header("Content-Type", contentType+"; charset=utf-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
+
+// decodeMIMEUpload parses a base64 encoded RFC 822 message as posted with
+// Content-Type text/plain. Like Graph it keeps the upload time as received date.
+func decodeMIMEUpload(data []byte) (*Message, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 MIME content: %w", err)
+	}
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MIME content: %w", err)
+	}
+
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	m := &Message{
+		Subject: subject,
+		To:      mimeAddresses(msg.Header.Get("To")),
+		Cc:      mimeAddresses(msg.Header.Get("Cc")),
+		Bcc:     mimeAddresses(msg.Header.Get("Bcc")),
+		MIME:    raw,
+
+		InternetMessageID: msg.Header.Get("Message-ID"),
+		ReplyTo:           mimeAddresses(msg.Header.Get("Reply-To")),
+		Importance:        strings.ToLower(msg.Header.Get("Importance")),
+		Sensitivity:       mimeSensitivity(msg.Header.Get("Sensitivity")),
+	}
+	if from := mimeAddresses(msg.Header.Get("From")); len(from) > 0 {
+		m.From = from[0]
+	}
+
+	if err := readMIMEPart(m, textproto.MIMEHeader(msg.Header), msg.Body); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readMIMEPart fills body and attachments of m from a MIME entity
+func readMIMEPart(m *Message, header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart content: %w", err)
+			}
+			if err := readMIMEPart(m, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("invalid part content: %w", err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dparams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if disposition == "attachment" || name != "" || !strings.HasPrefix(mediaType, "text/") {
+		m.Attachment = append(m.Attachment, Attachment{
+			Name:        name,
+			ContentType: mediaType,
+			Content:     content,
+			Inline:      disposition == "inline",
+			ContentID:   strings.Trim(header.Get("Content-ID"), "<>"),
+		})
+		return nil
+	}
+
+	// Prefer HTML over the plain text alternative
+	if m.Body == "" || mediaType == "text/html" {
+		m.Body = string(content)
+		m.BodyType = "text"
+		if mediaType == "text/html" {
+			m.BodyType = "html"
+		}
+	}
+	return nil
+}
+
+// mimeSensitivity maps an RFC 2156 Sensitivity header to mail.Sensitivities
+func mimeSensitivity(value string) string {
+	switch strings.ToLower(value) {
+	case "personal":
+		return "personal"
+	case "private":
+		return "private"
+	case "company-confidential":
+		return "confidential"
+	}
+	return ""
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(encoding) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+func mimeAddresses(value string) []string {
+	list, err := netmail.ParseAddressList(value)
+	if err != nil {
+		return nil
+	}
+	result := make([]string, len(list))
+	for i, addr := range list {
+		result[i] = addr.Address
+	}
+	return result
+}
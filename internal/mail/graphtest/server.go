@@ -0,0 +1,1308 @@
+// Package graphtest provides an in-memory fake of the Microsoft Graph mail API
+// for tests. It implements the subset of endpoints used by mail.GraphClient.
+package graphtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+// Token is the access token the fake server accepts
+const Token = "graphtest-token"
+
+// deferredSendTimeProperty is PidTagDeferredSendTime
+const deferredSendTimeProperty = "SystemTime 0x3FEF"
+
+// Folder is a mail folder held by the fake server
+type Folder struct {
+	ID          string
+	DisplayName string
+	ParentID    string
+}
+
+// Attachment is a file attachment held by the fake server
+type Attachment struct {
+	ID          string
+	Name        string
+	ContentType string
+	Content     []byte
+	Inline      bool
+	ContentID   string
+}
+
+// Message is a mail message held by the fake server
+type Message struct {
+	ID       string
+	FolderID string
+	Subject  string
+	Body     string
+	BodyType string
+	From     string
+	To       []string
+	Cc       []string
+	Bcc      []string
+	Received time.Time
+	IsRead   bool
+	IsDraft  bool
+	Flagged  bool
+	// FlagComplete marks a follow-up flag as completed (Flagged is false then)
+	FlagComplete bool
+	// FlagDue is the due date of the follow-up flag (zero = none)
+	FlagDue    time.Time
+	Attachment []Attachment
+	Headers    []mail.MessageHeader
+	// MIME is returned by $value; when empty a message is generated from the fields
+	MIME []byte
+	// InternetMessageID defaults to "<ID@graphtest.local>"
+	InternetMessageID string
+	// ConversationID defaults to "conv-ID"
+	ConversationID string
+	// Importance defaults to "normal"
+	Importance string
+	Categories []string
+	// Sensitivity is set through the PidTagSensitivity extended property
+	// or the Sensitivity header, as one of mail.Sensitivities (defaults to "normal")
+	Sensitivity     string
+	ReadReceipt     bool
+	DeliveryReceipt bool
+	ReplyTo         []string
+	// SendAt is the deferred send time (PidTagDeferredSendTime). Drafts
+	// sent before that time wait in the Outbox.
+	SendAt time.Time
+}
+
+// Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// Action records a reply, replyAll or forward call
+type Action struct {
+	Kind      string
+	MessageID string
+	Body      map[string]interface{}
+}
+
+// Fault makes the server fail matching requests, e.g. to simulate throttling
+type Fault struct {
+	// Method to match (empty matches any method)
+	Method string
+	// PathPrefix is matched against the path below /v1.0, e.g. "/me/sendMail"
+	PathPrefix string
+	Status     int
+	RetryAfter string
+	// Times is the number of matching requests to fail
+	Times int
+	// After is the number of matching requests to let through first
+	After int
+}
+
+// Server is a fake Microsoft Graph server backed by httptest
+type Server struct {
+	*httptest.Server
+
+	// PageSize caps the number of items per page regardless of $top (0 = no cap)
+	PageSize int
+	// NewIDOnMove gives moved messages a new ID like Graph does without immutable IDs
+	NewIDOnMove bool
+
+	mu         sync.Mutex
+	folders    []*Folder
+	messages   []*Message
+	rules      []*mail.MessageRule
+	categories []*mail.Category
+	actions    []Action
+	requests   []Request
+	faults     []*Fault
+	nextID     int
+
+	deltaStates map[string]*deltaState
+	deltaPages  map[string]*deltaPage
+	uploads     map[string]*uploadSession
+}
+
+// wellKnownFolders are created for every new server, using the
+// well-known folder name as ID so aliases resolve without a lookup
+var wellKnownFolders = []Folder{
+	{ID: "inbox", DisplayName: "Inbox"},
+	{ID: "drafts", DisplayName: "Drafts"},
+	{ID: "sentitems", DisplayName: "Sent Items"},
+	{ID: "outbox", DisplayName: "Outbox"},
+	{ID: "deleteditems", DisplayName: "Deleted Items"},
+	{ID: "junkemail", DisplayName: "Junk Email"},
+	{ID: "archive", DisplayName: "Archive"},
+}
+
+// NewServer starts a fake Graph server with the well-known folders
+func NewServer() *Server {
+	s := &Server{}
+	for _, f := range wellKnownFolders {
+		f := f
+		s.folders = append(s.folders, &f)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// BaseURL returns the Graph root to pass to mail.ClientOptions
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1.0"
+}
+
+// Client returns a GraphClient pointed at the fake server
+func (s *Server) Client() *mail.GraphClient {
+	return mail.NewGraphClientWithOptions(Token, mail.ClientOptions{
+		BaseURL:    s.BaseURL(),
+		HTTPClient: s.Server.Client(),
+	})
+}
+
+// AddFolder adds a folder below parentID ("" for top level) and returns its ID
+func (s *Server) AddFolder(parentID, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addFolderLocked(parentID, name).ID
+}
+
+// AddMessage stores a message and returns its ID.
+// Empty FolderID defaults to inbox, zero Received defaults to now.
+func (s *Server) AddMessage(m Message) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addMessageLocked(m).ID
+}
+
+// AddRule stores an inbox rule and returns its ID
+func (s *Server) AddRule(rule mail.MessageRule) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule.ID = s.newID("rule")
+	rule.Sequence = len(s.rules) + 1
+	s.rules = append(s.rules, &rule)
+	return rule.ID
+}
+
+// AddCategory adds a category to the master list and returns its ID
+func (s *Server) AddCategory(category mail.Category) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	category.ID = s.newID("category")
+	s.categories = append(s.categories, &category)
+	return category.ID
+}
+
+// Categories returns copies of the master categories
+func (s *Server) Categories() []mail.Category {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]mail.Category, len(s.categories))
+	for i, c := range s.categories {
+		result[i] = *c
+	}
+	return result
+}
+
+// InjectFault registers a fault for upcoming requests
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// Message returns a copy of the message with the given ID
+func (s *Server) Message(id string) (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m := s.findMessage(id); m != nil {
+		return *m, true
+	}
+	return Message{}, false
+}
+
+// Messages returns copies of all messages in a folder, newest first
+func (s *Server) Messages(folderID string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Message
+	for _, m := range s.folderMessages(folderID) {
+		result = append(result, *m)
+	}
+	return result
+}
+
+// Folders returns copies of all folders
+func (s *Server) Folders() []Folder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Folder, len(s.folders))
+	for i, f := range s.folders {
+		result[i] = *f
+	}
+	return result
+}
+
+// Rules returns copies of all inbox rules
+func (s *Server) Rules() []mail.MessageRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]mail.MessageRule, len(s.rules))
+	for i, r := range s.rules {
+		result[i] = *r
+	}
+	return result
+}
+
+// Actions returns the reply/forward calls received so far
+func (s *Server) Actions() []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Action(nil), s.actions...)
+}
+
+// Requests returns all requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CountRequests counts received requests matching method and path prefix
+func (s *Server) CountRequests(method, pathPrefix string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Method == method && strings.HasPrefix(r.Path, pathPrefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%04d", prefix, s.nextID)
+}
+
+func (s *Server) addFolderLocked(parentID, name string) *Folder {
+	f := &Folder{ID: s.newID("folder"), DisplayName: name, ParentID: parentID}
+	s.folders = append(s.folders, f)
+	return f
+}
+
+func (s *Server) addMessageLocked(m Message) *Message {
+	if m.ID == "" {
+		m.ID = s.newID("msg")
+	}
+	if m.FolderID == "" {
+		m.FolderID = "inbox"
+	}
+	if m.Received.IsZero() {
+		m.Received = time.Now().UTC()
+	}
+	if m.InternetMessageID == "" {
+		m.InternetMessageID = "<" + m.ID + "@graphtest.local>"
+	}
+	if m.ConversationID == "" {
+		m.ConversationID = "conv-" + m.ID
+	}
+	if m.Importance == "" {
+		m.Importance = "normal"
+	}
+	if m.Sensitivity == "" {
+		m.Sensitivity = "normal"
+	}
+	if m.BodyType == "" {
+		m.BodyType = "text"
+	}
+	for i := range m.Attachment {
+		if m.Attachment[i].ID == "" {
+			m.Attachment[i].ID = s.newID("att")
+		}
+	}
+	s.messages = append(s.messages, &m)
+	return &m
+}
+
+func (s *Server) findFolder(id string) *Folder {
+	for _, f := range s.folders {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Server) findMessage(id string) *Message {
+	for _, m := range s.messages {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) childFolders(parentID string) []*Folder {
+	var result []*Folder
+	for _, f := range s.folders {
+		if f.ParentID == parentID {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// folderMessages returns messages in a folder ("" = all folders), newest first
+func (s *Server) folderMessages(folderID string) []*Message {
+	var result []*Message
+	for _, m := range s.messages {
+		if folderID == "" || m.FolderID == folderID {
+			result = append(result, m)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Received.After(result[j].Received)
+	})
+	return result
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   body,
+	})
+
+	// Upload URLs are pre-authenticated and bypass the token check
+	if strings.HasPrefix(r.URL.Path, "/upload/") {
+		s.handleUpload(w, r, body)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty or invalid.")
+		return
+	}
+
+	if r.Method == http.MethodPost && r.URL.Path == "/v1.0/$batch" {
+		if f := s.matchFault(r.Method, "/$batch"); f != nil {
+			writeFault(w, f)
+			return
+		}
+		s.handleBatch(w, r, body)
+		return
+	}
+
+	s.serve(w, r, body)
+}
+
+// serve dispatches a single authenticated request, either received
+// directly or unpacked from a $batch
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, body []byte) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1.0")
+
+	if f := s.matchFault(r.Method, path); f != nil {
+		writeFault(w, f)
+		return
+	}
+
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) == 0 || segs[0] != "me" {
+		writeError(w, http.StatusNotFound, "ResourceNotFound", "Unknown path "+r.URL.Path)
+		return
+	}
+	segs = segs[1:]
+
+	s.route(w, r, segs, body)
+}
+
+// matchFault returns and consumes the first fault matching the request
+func (s *Server) matchFault(method, path string) *Fault {
+	for _, f := range s.faults {
+		if f.Times <= 0 || !strings.HasPrefix(path, f.PathPrefix) {
+			continue
+		}
+		if f.Method != "" && f.Method != method {
+			continue
+		}
+		if f.After > 0 {
+			f.After--
+			continue
+		}
+		f.Times--
+		return f
+	}
+	return nil
+}
+
+func writeFault(w http.ResponseWriter, f *Fault) {
+	if f.RetryAfter != "" {
+		w.Header().Set("Retry-After", f.RetryAfter)
+	}
+	writeError(w, f.Status, faultCode(f.Status), "Injected fault.")
+}
+
+func faultCode(status int) string {
+	switch status {
+	case http.StatusTooManyRequests:
+		return "ApplicationThrottled"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailable"
+	case http.StatusGatewayTimeout:
+		return "GatewayTimeout"
+	}
+	return "InternalServerError"
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, segs []string, body []byte) {
+	n := len(segs)
+	switch {
+	case n == 1 && segs[0] == "sendMail" && r.Method == http.MethodPost:
+		s.handleSendMail(w, r, body)
+
+	case n >= 1 && segs[0] == "messages":
+		s.routeMessages(w, r, "", segs[1:], body)
+
+	case n == 1 && segs[0] == "mailFolders":
+		switch r.Method {
+		case http.MethodGet:
+			s.writeFolderPage(w, r, s.childFolders(""))
+		case http.MethodPost:
+			s.handleCreateFolder(w, "", body)
+		default:
+			writeMethodNotAllowed(w)
+		}
+
+	case n >= 2 && segs[0] == "outlook" && segs[1] == "masterCategories":
+		s.routeCategories(w, r, segs[2:], body)
+
+	case n >= 2 && segs[0] == "mailFolders":
+		folder := s.findFolder(segs[1])
+		if folder == nil {
+			writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified folder could not be found in the store.")
+			return
+		}
+		rest := segs[2:]
+		switch {
+		case len(rest) == 0 && r.Method == http.MethodDelete:
+			s.deleteFolder(folder.ID)
+			w.WriteHeader(http.StatusNoContent)
+		case len(rest) == 0 && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, s.folderJSON(folder))
+		case len(rest) == 1 && rest[0] == "childFolders" && r.Method == http.MethodGet:
+			s.writeFolderPage(w, r, s.childFolders(folder.ID))
+		case len(rest) == 1 && rest[0] == "childFolders" && r.Method == http.MethodPost:
+			s.handleCreateFolder(w, folder.ID, body)
+		case len(rest) >= 1 && rest[0] == "messages":
+			s.routeMessages(w, r, folder.ID, rest[1:], body)
+		case len(rest) >= 1 && rest[0] == "messageRules":
+			s.routeRules(w, r, rest[1:], body)
+		default:
+			writeError(w, http.StatusNotFound, "ResourceNotFound", "Unknown path "+r.URL.Path)
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, "ResourceNotFound", "Unknown path "+r.URL.Path)
+	}
+}
+
+// routeMessages handles /me/messages/... and /me/mailFolders/{id}/messages/...
+func (s *Server) routeMessages(w http.ResponseWriter, r *http.Request, folderID string, segs []string, body []byte) {
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.handleListMessages(w, r, folderID)
+		case http.MethodPost:
+			s.handleCreateMessage(w, r, folderID, body)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	if len(segs) == 1 && segs[0] == "delta" && folderID != "" && r.Method == http.MethodGet {
+		s.handleDelta(w, r, folderID)
+		return
+	}
+
+	msg := s.findMessage(segs[0])
+	if msg == nil || (folderID != "" && msg.FolderID != folderID) {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+		return
+	}
+
+	if len(segs) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			data := s.messageJSON(msg)
+			if strings.HasPrefix(r.URL.Query().Get("$expand"), "attachments") {
+				value := []interface{}{}
+				for _, att := range msg.Attachment {
+					meta := attachmentJSON(att)
+					delete(meta, "contentBytes")
+					value = append(value, meta)
+				}
+				data["attachments"] = value
+			}
+			writeJSON(w, http.StatusOK, data)
+		case http.MethodPatch:
+			s.handlePatchMessage(w, msg, body)
+		case http.MethodDelete:
+			s.deleteMessage(msg.ID)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	switch action := segs[1]; {
+	case action == "attachments" && r.Method == http.MethodGet:
+		var value []interface{}
+		for _, att := range msg.Attachment {
+			value = append(value, attachmentJSON(att))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"value": nonNil(value)})
+
+	case action == "attachments" && len(segs) == 2 && r.Method == http.MethodPost:
+		s.handleAddAttachment(w, msg, body)
+
+	case action == "attachments" && len(segs) == 3 && r.Method == http.MethodDelete:
+		for i, att := range msg.Attachment {
+			if att.ID == segs[2] {
+				msg.Attachment = append(msg.Attachment[:i], msg.Attachment[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+
+	case action == "attachments" && len(segs) == 3 && segs[2] == "createUploadSession" && r.Method == http.MethodPost:
+		s.handleCreateUploadSession(w, msg, body)
+
+	case action == "$value" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "message/rfc822")
+		w.Write(messageMIME(msg))
+
+	case action == "move" && r.Method == http.MethodPost:
+		var req struct {
+			DestinationID string `json:"destinationId"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+			return
+		}
+		if s.findFolder(req.DestinationID) == nil {
+			writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified folder could not be found in the store.")
+			return
+		}
+		// Moving a scheduled message out of the Outbox cancels its
+		// submission, it becomes a draft again
+		if msg.FolderID == "outbox" && req.DestinationID == "drafts" {
+			msg.IsDraft = true
+		}
+		msg.FolderID = req.DestinationID
+		if s.NewIDOnMove {
+			msg.ID = s.newID("msg")
+		}
+		writeJSON(w, http.StatusCreated, s.messageJSON(msg))
+
+	case (action == "reply" || action == "replyAll" || action == "forward") && r.Method == http.MethodPost:
+		payload := map[string]interface{}{}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &payload); err != nil {
+				writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+				return
+			}
+		}
+		s.actions = append(s.actions, Action{Kind: action, MessageID: msg.ID, Body: payload})
+		w.WriteHeader(http.StatusAccepted)
+
+	case (action == "createReply" || action == "createReplyAll" || action == "createForward") && r.Method == http.MethodPost:
+		s.handleCreateResponse(w, msg, action, body)
+
+	case action == "send" && r.Method == http.MethodPost:
+		if !msg.IsDraft {
+			writeError(w, http.StatusBadRequest, "ErrorInvalidOperation", "Only drafts can be sent.")
+			return
+		}
+		msg.IsDraft = false
+		msg.IsRead = true
+		if msg.SendAt.After(time.Now()) {
+			msg.FolderID = "outbox"
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		msg.FolderID = "sentitems"
+		msg.Received = time.Now().UTC()
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeError(w, http.StatusNotFound, "ResourceNotFound", "Unknown path "+r.URL.Path)
+	}
+}
+
+func (s *Server) routeCategories(w http.ResponseWriter, r *http.Request, segs []string, body []byte) {
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			value := make([]*mail.Category, len(s.categories))
+			copy(value, s.categories)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"value": value})
+		case http.MethodPost:
+			var category mail.Category
+			if err := json.Unmarshal(body, &category); err != nil {
+				writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+				return
+			}
+			for _, c := range s.categories {
+				if strings.EqualFold(c.DisplayName, category.DisplayName) {
+					writeError(w, http.StatusConflict, "ErrorDuplicateCategoryName", "A category with this name already exists.")
+					return
+				}
+			}
+			category.ID = s.newID("category")
+			s.categories = append(s.categories, &category)
+			writeJSON(w, http.StatusCreated, category)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	idx := -1
+	for i, c := range s.categories {
+		if c.ID == segs[0] {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified category was not found.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.categories[idx])
+	case http.MethodPatch:
+		var patch struct {
+			Color string `json:"color"`
+		}
+		if err := json.Unmarshal(body, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+			return
+		}
+		s.categories[idx].Color = patch.Color
+		writeJSON(w, http.StatusOK, s.categories[idx])
+	case http.MethodDelete:
+		s.categories = append(s.categories[:idx], s.categories[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (s *Server) routeRules(w http.ResponseWriter, r *http.Request, segs []string, body []byte) {
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			value := make([]*mail.MessageRule, len(s.rules))
+			copy(value, s.rules)
+			writeJSON(w, http.StatusOK, map[string]interface{}{"value": value})
+		case http.MethodPost:
+			var rule mail.MessageRule
+			if err := json.Unmarshal(body, &rule); err != nil {
+				writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+				return
+			}
+			rule.ID = s.newID("rule")
+			rule.Sequence = len(s.rules) + 1
+			s.rules = append(s.rules, &rule)
+			writeJSON(w, http.StatusCreated, rule)
+		default:
+			writeMethodNotAllowed(w)
+		}
+		return
+	}
+
+	idx := -1
+	for i, rule := range s.rules {
+		if rule.ID == segs[0] {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified rule was not found.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.rules[idx])
+	case http.MethodPatch:
+		if err := json.Unmarshal(body, s.rules[idx]); err != nil {
+			writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, s.rules[idx])
+	case http.MethodDelete:
+		s.rules = append(s.rules[:idx], s.rules[idx+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (s *Server) handleListMessages(w http.ResponseWriter, r *http.Request, folderID string) {
+	q := r.URL.Query()
+
+	filter, err := parseFilter(q.Get("$filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	search := strings.ToLower(strings.Trim(q.Get("$search"), `"`))
+
+	var matched []*Message
+	for _, m := range s.folderMessages(folderID) {
+		if !filter(m) {
+			continue
+		}
+		if search != "" && !matchesSearch(m, search) {
+			continue
+		}
+		matched = append(matched, m)
+	}
+	if q.Get("$orderby") == "receivedDateTime asc" {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	expandProperties := strings.HasPrefix(q.Get("$expand"), "singleValueExtendedProperties")
+	var value []interface{}
+	for _, m := range matched {
+		data := s.messageJSON(m)
+		if expandProperties && !m.SendAt.IsZero() {
+			data["singleValueExtendedProperties"] = []map[string]string{
+				{"id": deferredSendTimeProperty, "value": m.SendAt.UTC().Format(time.RFC3339)},
+			}
+		}
+		value = append(value, data)
+	}
+	s.writePage(w, r, value)
+}
+
+func (s *Server) handleCreateMessage(w http.ResponseWriter, r *http.Request, folderID string, body []byte) {
+	decode, mimeUpload := decodeMessage, false
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/plain") {
+		decode, mimeUpload = decodeMIMEUpload, true
+	}
+	m, err := decode(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+	if folderID == "" {
+		folderID = "drafts"
+	}
+	m.FolderID = folderID
+	// Like Exchange, MIME uploads always become drafts; the flag can only
+	// be set when the item is created and no later update clears it
+	m.IsDraft = folderID == "drafts" || mimeUpload
+	m.IsRead = true
+	created := s.addMessageLocked(*m)
+	writeJSON(w, http.StatusCreated, s.messageJSON(created))
+}
+
+func (s *Server) handlePatchMessage(w http.ResponseWriter, msg *Message, body []byte) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+	if raw, ok := patch["isRead"]; ok {
+		json.Unmarshal(raw, &msg.IsRead)
+	}
+	if raw, ok := patch["flag"]; ok {
+		var flag mail.GraphFollowupFlag
+		json.Unmarshal(raw, &flag)
+		msg.Flagged = flag.FlagStatus == mail.FlagStatusFlagged
+		msg.FlagComplete = flag.FlagStatus == mail.FlagStatusComplete
+		msg.FlagDue = time.Time{}
+		if flag.DueDateTime != nil {
+			if flag.StartDateTime == nil {
+				writeError(w, http.StatusBadRequest, "ErrorInvalidArgument", "The flag's startDateTime is required with dueDateTime.")
+				return
+			}
+			msg.FlagDue, _ = flag.DueDateTime.Time()
+		}
+	}
+	if raw, ok := patch["categories"]; ok {
+		msg.Categories = nil
+		json.Unmarshal(raw, &msg.Categories)
+	}
+	if raw, ok := patch["isReadReceiptRequested"]; ok {
+		json.Unmarshal(raw, &msg.ReadReceipt)
+	}
+	if raw, ok := patch["isDeliveryReceiptRequested"]; ok {
+		json.Unmarshal(raw, &msg.DeliveryReceipt)
+	}
+	if !msg.IsDraft {
+		for _, key := range []string{"body", "toRecipients", "ccRecipients", "bccRecipients"} {
+			if _, ok := patch[key]; ok {
+				writeError(w, http.StatusBadRequest, "ErrorInvalidPropertyUpdateSentMessage", "Update operation is invalid for property of a sent message.")
+				return
+			}
+		}
+	}
+	for key, field := range map[string]*[]string{"toRecipients": &msg.To, "ccRecipients": &msg.Cc, "bccRecipients": &msg.Bcc} {
+		if raw, ok := patch[key]; ok {
+			var wrappers []mail.GraphEmailAddressWrapper
+			json.Unmarshal(raw, &wrappers)
+			*field = addresses(wrappers)
+		}
+	}
+	if raw, ok := patch["subject"]; ok {
+		json.Unmarshal(raw, &msg.Subject)
+	}
+	if raw, ok := patch["body"]; ok {
+		var b mail.GraphBody
+		json.Unmarshal(raw, &b)
+		msg.Body, msg.BodyType = b.Content, strings.ToLower(b.ContentType)
+	}
+	if raw, ok := patch["singleValueExtendedProperties"]; ok {
+		var props []struct {
+			ID    string `json:"id"`
+			Value string `json:"value"`
+		}
+		json.Unmarshal(raw, &props)
+		for _, p := range props {
+			// PR_MESSAGE_DELIVERY_TIME is what Graph reports as receivedDateTime
+			if strings.EqualFold(p.ID, "SystemTime 0x0E06") {
+				if t, err := time.Parse(time.RFC3339, p.Value); err == nil {
+					msg.Received = t
+				}
+			}
+			if strings.EqualFold(p.ID, deferredSendTimeProperty) {
+				if t, err := time.Parse(time.RFC3339, p.Value); err == nil {
+					msg.SendAt = t
+				}
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, s.messageJSON(msg))
+}
+
+// handleCreateResponse creates a reply or forward draft of msg with the
+// comment above a quoted copy of the original
+func (s *Server) handleCreateResponse(w http.ResponseWriter, msg *Message, action string, body []byte) {
+	var req struct {
+		Comment      string                          `json:"comment"`
+		ToRecipients []mail.GraphEmailAddressWrapper `json:"toRecipients"`
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+			return
+		}
+	}
+
+	draft := Message{
+		FolderID:       "drafts",
+		IsDraft:        true,
+		IsRead:         true,
+		BodyType:       msg.BodyType,
+		Body:           req.Comment + "\n\nFrom: " + msg.From + "\n" + msg.Body,
+		ConversationID: msg.ConversationID,
+	}
+	switch action {
+	case "createReply", "createReplyAll":
+		draft.Subject = "RE: " + msg.Subject
+		if msg.From != "" {
+			draft.To = []string{mail.ParseEmail(msg.From)}
+		}
+		if action == "createReplyAll" {
+			draft.To = append(draft.To, msg.To...)
+			draft.Cc = append(draft.Cc, msg.Cc...)
+		}
+	case "createForward":
+		draft.Subject = "FW: " + msg.Subject
+		draft.To = addresses(req.ToRecipients)
+		draft.Attachment = append(draft.Attachment, msg.Attachment...)
+		for i := range draft.Attachment {
+			draft.Attachment[i].ID = ""
+		}
+	}
+	created := s.addMessageLocked(draft)
+	writeJSON(w, http.StatusCreated, s.messageJSON(created))
+}
+
+func (s *Server) handleSendMail(w http.ResponseWriter, r *http.Request, body []byte) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "text/plain") {
+		m, err := decodeMIMEUpload(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+			return
+		}
+		s.deliverSent(w, m)
+		return
+	}
+
+	var req struct {
+		Message         json.RawMessage `json:"message"`
+		SaveToSentItems *bool           `json:"saveToSentItems"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || len(req.Message) == 0 {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", "Missing message.")
+		return
+	}
+	m, err := decodeMessage(req.Message)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+	s.deliverSent(w, m)
+}
+
+// deliverSent stores a message sent with sendMail in Sent Items
+func (s *Server) deliverSent(w http.ResponseWriter, m *Message) {
+	if len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		writeError(w, http.StatusBadRequest, "ErrorInvalidRecipients", "At least one recipient is not valid.")
+		return
+	}
+	m.FolderID = "sentitems"
+	m.IsRead = true
+	s.addMessageLocked(*m)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleCreateFolder(w http.ResponseWriter, parentID string, body []byte) {
+	var req struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.DisplayName == "" {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", "Missing displayName.")
+		return
+	}
+	for _, f := range s.childFolders(parentID) {
+		if strings.EqualFold(f.DisplayName, req.DisplayName) {
+			writeError(w, http.StatusConflict, "ErrorFolderExists", "A folder with the specified name already exists.")
+			return
+		}
+	}
+	f := s.addFolderLocked(parentID, req.DisplayName)
+	writeJSON(w, http.StatusCreated, s.folderJSON(f))
+}
+
+func (s *Server) deleteFolder(id string) {
+	for _, child := range s.childFolders(id) {
+		s.deleteFolder(child.ID)
+	}
+	var folders []*Folder
+	for _, f := range s.folders {
+		if f.ID != id {
+			folders = append(folders, f)
+		}
+	}
+	s.folders = folders
+
+	var messages []*Message
+	for _, m := range s.messages {
+		if m.FolderID != id {
+			messages = append(messages, m)
+		}
+	}
+	s.messages = messages
+}
+
+func (s *Server) deleteMessage(id string) {
+	for i, m := range s.messages {
+		if m.ID == id {
+			s.messages = append(s.messages[:i], s.messages[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Server) writeFolderPage(w http.ResponseWriter, r *http.Request, folders []*Folder) {
+	var value []interface{}
+	for _, f := range folders {
+		value = append(value, s.folderJSON(f))
+	}
+	s.writePage(w, r, value)
+}
+
+// writePage writes one page of a collection honoring $top, $skip and PageSize.
+// Remaining items are announced via @odata.nextLink.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, value []interface{}) {
+	q := r.URL.Query()
+
+	top := 10
+	if v := q.Get("$top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			top = n
+		}
+	}
+	if s.PageSize > 0 && top > s.PageSize {
+		top = s.PageSize
+	}
+
+	skip, _ := strconv.Atoi(q.Get("$skip"))
+	if skip > len(value) {
+		skip = len(value)
+	}
+	end := skip + top
+	if end > len(value) {
+		end = len(value)
+	}
+
+	resp := map[string]interface{}{"value": nonNil(value[skip:end])}
+	if end < len(value) {
+		q.Set("$skip", strconv.Itoa(end))
+		next := *r.URL
+		next.Scheme = "http"
+		next.Host = r.Host
+		next.RawQuery = q.Encode()
+		resp["@odata.nextLink"] = next.String()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) folderJSON(f *Folder) map[string]interface{} {
+	unread, total := 0, 0
+	for _, m := range s.messages {
+		if m.FolderID == f.ID {
+			total++
+			if !m.IsRead {
+				unread++
+			}
+		}
+	}
+	return map[string]interface{}{
+		"id":               f.ID,
+		"displayName":      f.DisplayName,
+		"parentFolderId":   f.ParentID,
+		"childFolderCount": len(s.childFolders(f.ID)),
+		"unreadItemCount":  unread,
+		"totalItemCount":   total,
+	}
+}
+
+func (s *Server) messageJSON(m *Message) map[string]interface{} {
+	preview := m.Body
+	if len(preview) > 255 {
+		preview = preview[:255]
+	}
+	result := map[string]interface{}{
+		"id":                m.ID,
+		"subject":           m.Subject,
+		"bodyPreview":       preview,
+		"body":              map[string]string{"contentType": m.BodyType, "content": m.Body},
+		"receivedDateTime":  m.Received.UTC().Format(time.RFC3339),
+		"isRead":            m.IsRead,
+		"isDraft":           m.IsDraft,
+		"toRecipients":      recipientsJSON(m.To),
+		"ccRecipients":      recipientsJSON(m.Cc),
+		"bccRecipients":     recipientsJSON(m.Bcc),
+		"hasAttachments":    len(m.Attachment) > 0,
+		"internetMessageId": m.InternetMessageID,
+		"parentFolderId":    m.FolderID,
+		"conversationId":    m.ConversationID,
+		"importance":        m.Importance,
+		"categories":        append([]string{}, m.Categories...),
+		"flag":              map[string]interface{}{"flagStatus": mail.FlagStatusNotFlagged},
+
+		"isReadReceiptRequested":     m.ReadReceipt,
+		"isDeliveryReceiptRequested": m.DeliveryReceipt,
+		"replyTo":                    recipientsJSON(m.ReplyTo),
+	}
+	switch {
+	case m.Flagged && !m.FlagDue.IsZero():
+		result["flag"] = map[string]interface{}{
+			"flagStatus":    mail.FlagStatusFlagged,
+			"startDateTime": mail.NewGraphDateTime(m.FlagDue),
+			"dueDateTime":   mail.NewGraphDateTime(m.FlagDue),
+		}
+	case m.Flagged:
+		result["flag"] = map[string]interface{}{"flagStatus": mail.FlagStatusFlagged}
+	case m.FlagComplete:
+		result["flag"] = map[string]interface{}{"flagStatus": mail.FlagStatusComplete}
+	}
+	if m.From != "" {
+		result["from"] = addressJSON(m.From)
+	}
+	if len(m.Headers) > 0 {
+		result["internetMessageHeaders"] = m.Headers
+	}
+	return result
+}
+
+func attachmentJSON(att Attachment) map[string]interface{} {
+	return map[string]interface{}{
+		"@odata.type":  "#microsoft.graph.fileAttachment",
+		"id":           att.ID,
+		"name":         att.Name,
+		"contentType":  att.ContentType,
+		"size":         len(att.Content),
+		"isInline":     att.Inline,
+		"contentId":    att.ContentID,
+		"contentBytes": base64.StdEncoding.EncodeToString(att.Content),
+	}
+}
+
+func addressJSON(addr string) mail.GraphEmailAddressWrapper {
+	name := ""
+	if idx := strings.Index(addr, "<"); idx > 0 {
+		name = strings.TrimSpace(addr[:idx])
+	}
+	return mail.GraphEmailAddressWrapper{
+		EmailAddress: mail.GraphEmailAddress{Address: mail.ParseEmail(addr), Name: name},
+	}
+}
+
+func recipientsJSON(addrs []string) []mail.GraphEmailAddressWrapper {
+	result := make([]mail.GraphEmailAddressWrapper, 0, len(addrs))
+	for _, a := range addrs {
+		result = append(result, addressJSON(a))
+	}
+	return result
+}
+
+// decodeMessage converts a Graph message JSON payload into a Message
+func decodeMessage(data []byte) (*Message, error) {
+	var gm struct {
+		Subject       string                          `json:"subject"`
+		Body          mail.GraphBody                  `json:"body"`
+		From          *mail.GraphEmailAddressWrapper  `json:"from"`
+		ToRecipients  []mail.GraphEmailAddressWrapper `json:"toRecipients"`
+		CcRecipients  []mail.GraphEmailAddressWrapper `json:"ccRecipients"`
+		BccRecipients []mail.GraphEmailAddressWrapper `json:"bccRecipients"`
+		ReplyTo       []mail.GraphEmailAddressWrapper `json:"replyTo"`
+		Importance    string                          `json:"importance"`
+		ReadReceipt   bool                            `json:"isReadReceiptRequested"`
+		Delivery      bool                            `json:"isDeliveryReceiptRequested"`
+		Properties    []struct {
+			ID    string `json:"id"`
+			Value string `json:"value"`
+		} `json:"singleValueExtendedProperties"`
+		Attachments []struct {
+			Name         string `json:"name"`
+			ContentType  string `json:"contentType"`
+			ContentBytes string `json:"contentBytes"`
+			IsInline     bool   `json:"isInline"`
+			ContentID    string `json:"contentId"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(data, &gm); err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		Subject:  gm.Subject,
+		Body:     gm.Body.Content,
+		BodyType: strings.ToLower(gm.Body.ContentType),
+		To:       addresses(gm.ToRecipients),
+		Cc:       addresses(gm.CcRecipients),
+		Bcc:      addresses(gm.BccRecipients),
+
+		ReplyTo:         addresses(gm.ReplyTo),
+		Importance:      gm.Importance,
+		ReadReceipt:     gm.ReadReceipt,
+		DeliveryReceipt: gm.Delivery,
+	}
+	if gm.From != nil {
+		m.From = gm.From.EmailAddress.Address
+	}
+	for _, p := range gm.Properties {
+		// PidTagSensitivity holds the index into mail.Sensitivities
+		if strings.EqualFold(p.ID, "Integer 0x0036") {
+			n, err := strconv.Atoi(p.Value)
+			if err != nil || n < 0 || n >= len(mail.Sensitivities) {
+				return nil, fmt.Errorf("invalid sensitivity value: %s", p.Value)
+			}
+			m.Sensitivity = mail.Sensitivities[n]
+		}
+		if strings.EqualFold(p.ID, deferredSendTimeProperty) {
+			t, err := time.Parse(time.RFC3339, p.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid deferred send time: %s", p.Value)
+			}
+			m.SendAt = t
+		}
+	}
+	for _, a := range gm.Attachments {
+		content, err := base64.StdEncoding.DecodeString(a.ContentBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contentBytes for %s: %w", a.Name, err)
+		}
+		m.Attachment = append(m.Attachment, Attachment{Name: a.Name, ContentType: a.ContentType, Content: content, Inline: a.IsInline, ContentID: a.ContentID})
+	}
+	return m, nil
+}
+
+func addresses(wrappers []mail.GraphEmailAddressWrapper) []string {
+	var result []string
+	for _, w := range wrappers {
+		result = append(result, w.EmailAddress.Address)
+	}
+	return result
+}
+
+func matchesSearch(m *Message, term string) bool {
+	for _, field := range []string{m.Subject, m.Body, m.From} {
+		if strings.Contains(strings.ToLower(field), term) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"innerError": map[string]string{
+				"request-id": "00000000-0000-0000-0000-000000000000",
+				"date":       time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+	})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "UnsupportedHttpMethod", "Method not allowed.")
+}
+
+func nonNil(v []interface{}) []interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+	return v
+}
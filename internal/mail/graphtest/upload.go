@@ -0,0 +1,149 @@
+package graphtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// uploadSession is an attachment upload in progress
+type uploadSession struct {
+	messageID   string
+	name        string
+	contentType string
+	size        int64
+	inline      bool
+	contentID   string
+	data        []byte
+}
+
+func (s *Server) handleAddAttachment(w http.ResponseWriter, msg *Message, body []byte) {
+	var req struct {
+		ODataType    string `json:"@odata.type"`
+		Name         string `json:"name"`
+		ContentType  string `json:"contentType"`
+		ContentBytes string `json:"contentBytes"`
+		IsInline     bool   `json:"isInline"`
+		ContentID    string `json:"contentId"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+	if req.ODataType != "#microsoft.graph.fileAttachment" {
+		writeError(w, http.StatusBadRequest, "ErrorInvalidRequest", "Unsupported attachment type "+req.ODataType)
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(req.ContentBytes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+
+	att := Attachment{ID: s.newID("att"), Name: req.Name, ContentType: req.ContentType, Content: content, Inline: req.IsInline, ContentID: req.ContentID}
+	msg.Attachment = append(msg.Attachment, att)
+	writeJSON(w, http.StatusCreated, attachmentJSON(att))
+}
+
+func (s *Server) handleCreateUploadSession(w http.ResponseWriter, msg *Message, body []byte) {
+	var req struct {
+		AttachmentItem struct {
+			AttachmentType string `json:"attachmentType"`
+			Name           string `json:"name"`
+			Size           int64  `json:"size"`
+			ContentType    string `json:"contentType"`
+			IsInline       bool   `json:"isInline"`
+			ContentID      string `json:"contentId"`
+		} `json:"AttachmentItem"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "RequestBodyRead", err.Error())
+		return
+	}
+	item := req.AttachmentItem
+	if item.AttachmentType != "file" || item.Name == "" || item.Size <= 0 {
+		writeError(w, http.StatusBadRequest, "ErrorInvalidRequest", "AttachmentItem requires attachmentType file, name and size.")
+		return
+	}
+
+	if s.uploads == nil {
+		s.uploads = map[string]*uploadSession{}
+	}
+	id := s.newID("upload")
+	s.uploads[id] = &uploadSession{messageID: msg.ID, name: item.Name, contentType: item.ContentType, size: item.Size, inline: item.IsInline, contentID: item.ContentID}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"uploadUrl":          s.URL + "/upload/" + id,
+		"expirationDateTime": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"nextExpectedRanges": []string{"0-"},
+	})
+}
+
+// handleUpload serves the pre-authenticated upload URL of a session:
+// PUT appends a chunk, GET reports the expected range, DELETE cancels
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request, body []byte) {
+	if f := s.matchFault(r.Method, r.URL.Path); f != nil {
+		writeFault(w, f)
+		return
+	}
+	if r.Header.Get("Authorization") != "" {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Upload URLs must be used without an Authorization header.")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/upload/")
+	session := s.uploads[id]
+	if session == nil {
+		writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The upload session was not found or has expired.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, session.status())
+
+	case http.MethodPut:
+		var start, end, total int64
+		if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidContentRange", "Invalid Content-Range header.")
+			return
+		}
+		if start != int64(len(session.data)) || total != session.size || end-start+1 != int64(len(body)) || end >= total {
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The range does not match the expected range.")
+			return
+		}
+		session.data = append(session.data, body...)
+		if int64(len(session.data)) < session.size {
+			writeJSON(w, http.StatusOK, session.status())
+			return
+		}
+
+		delete(s.uploads, id)
+		msg := s.findMessage(session.messageID)
+		if msg == nil {
+			writeError(w, http.StatusNotFound, "ErrorItemNotFound", "The specified object was not found in the store.")
+			return
+		}
+		att := Attachment{ID: s.newID("att"), Name: session.name, ContentType: session.contentType, Content: session.data, Inline: session.inline, ContentID: session.contentID}
+		msg.Attachment = append(msg.Attachment, att)
+		w.Header().Set("Location", fmt.Sprintf("%s/v1.0/me/messages/%s/attachments/%s", s.URL, msg.ID, att.ID))
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		delete(s.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (u *uploadSession) status() map[string]interface{} {
+	return map[string]interface{}{
+		"expirationDateTime": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"nextExpectedRanges": []string{fmt.Sprintf("%d-", len(u.data))},
+	}
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ImportOptions are the properties set on an imported message
+type ImportOptions struct {
+	// Received is the original delivery date (zero keeps the upload time)
+	Received time.Time
+	Read     bool
+}
+
+// ImportMIME creates a message from RFC 822 content in a folder and returns its ID.
+// Graph takes MIME content base64 encoded as text/plain and stamps it with the
+// upload time, so the received date and read state are set by a follow-up update.
+// Exchange creates MIME uploads as drafts (PR_MESSAGE_FLAGS), and that flag can
+// only be set when the item is created, so imported messages stay drafts.
+func (c *GraphClient) ImportMIME(ctx context.Context, folderID string, content []byte, opts ImportOptions) (string, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages", c.baseURL, url.PathEscape(folderID))
+	encoded := []byte(base64.StdEncoding.EncodeToString(content))
+
+	resp, err := c.doRequestWithType(ctx, "POST", endpoint, "text/plain", encoded)
+	if err != nil {
+		return "", err
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	patch := map[string]interface{}{"isRead": opts.Read}
+	if !opts.Received.IsZero() {
+		date := opts.Received.UTC().Format(time.RFC3339)
+		patch["singleValueExtendedProperties"] = []map[string]string{
+			{"id": "SystemTime 0x0E06", "value": date}, // PR_MESSAGE_DELIVERY_TIME
+			{"id": "SystemTime 0x0039", "value": date}, // PR_CLIENT_SUBMIT_TIME
+		}
+	}
+	body, _ := json.Marshal(patch)
+
+	if _, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", created.ID), body); err != nil {
+		return created.ID, fmt.Errorf("imported as %s but failed to set date and read state: %w", created.ID, err)
+	}
+	return created.ID, nil
+}
@@ -0,0 +1,162 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// mimeEntity is a leaf part with content or a multipart of child entities
+type mimeEntity struct {
+	header   textproto.MIMEHeader
+	content  []byte
+	boundary string
+	children []mimeEntity
+}
+
+// buildMIME renders a message as RFC 822 with the plain-text alternative
+// next to the HTML body. Inline images go into a multipart/related part
+// with the HTML; other attachments are only included if withAttachments
+// is set.
+func buildMIME(opts SendOptions, withAttachments bool) ([]byte, error) {
+	var related, files []FileAttachment
+	for _, att := range opts.Attachments {
+		if !withAttachments {
+			break
+		}
+		if att.ContentID != "" {
+			related = append(related, att)
+		} else {
+			files = append(files, att)
+		}
+	}
+
+	html := textEntity("text/html", opts.Body)
+	if len(related) > 0 {
+		parts := []mimeEntity{html}
+		for _, att := range related {
+			parts = append(parts, fileEntity(att))
+		}
+		html = multipartEntity("related", parts...)
+	}
+	root := multipartEntity("alternative", textEntity("text/plain", opts.TextBody), html)
+	if len(files) > 0 {
+		parts := []mimeEntity{root}
+		for _, att := range files {
+			parts = append(parts, fileEntity(att))
+		}
+		root = multipartEntity("mixed", parts...)
+	}
+
+	var b bytes.Buffer
+	writeHeader := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+		}
+	}
+	writeHeader("To", formatMIMEAddresses(opts.To))
+	writeHeader("Cc", formatMIMEAddresses(opts.Cc))
+	writeHeader("Bcc", formatMIMEAddresses(opts.Bcc))
+	writeHeader("Reply-To", formatMIMEAddresses(opts.ReplyTo))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", opts.Subject))
+	if opts.Importance != "" && opts.Importance != "normal" {
+		writeHeader("Importance", opts.Importance)
+	}
+	writeHeader("Sensitivity", mimeSensitivity[opts.Sensitivity])
+	writeHeader("MIME-Version", "1.0")
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		writeHeader(key, root.header.Get(key))
+	}
+	b.WriteString("\r\n")
+
+	if err := root.writeBody(&b); err != nil {
+		return nil, fmt.Errorf("failed to build MIME message: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func (e mimeEntity) writeBody(w io.Writer) error {
+	if e.boundary == "" {
+		_, err := w.Write(e.content)
+		return err
+	}
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(e.boundary); err != nil {
+		return err
+	}
+	for _, child := range e.children {
+		pw, err := mw.CreatePart(child.header)
+		if err != nil {
+			return err
+		}
+		if err := child.writeBody(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func multipartEntity(subtype string, children ...mimeEntity) mimeEntity {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}))
+	return mimeEntity{header: header, boundary: boundary, children: children}
+}
+
+// textEntity encodes text as quoted-printable UTF-8 with CRLF line endings
+func textEntity(mediaType, text string) mimeEntity {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+
+	var b bytes.Buffer
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(text))
+	qp.Close()
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mediaType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimeEntity{header: header, content: b.Bytes()}
+}
+
+// fileEntity encodes an attachment as base64, inline when it has a content ID
+func fileEntity(att FileAttachment) mimeEntity {
+	disposition := "attachment"
+	header := textproto.MIMEHeader{}
+	if att.ContentID != "" {
+		disposition = "inline"
+		header.Set("Content-ID", "<"+att.ContentID+">")
+	}
+	contentType := att.ContentType
+	if mediaType, params, err := mime.ParseMediaType(contentType); err == nil {
+		params["name"] = att.Name
+		contentType = mime.FormatMediaType(mediaType, params)
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": att.Name}))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	encoded := base64.StdEncoding.EncodeToString(att.Content)
+	var b bytes.Buffer
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return mimeEntity{header: header, content: b.Bytes()}
+}
+
+func formatMIMEAddresses(addrs []string) string {
+	formatted := make([]string, len(addrs))
+	for i, addr := range addrs {
+		formatted[i] = (&netmail.Address{Address: ParseEmail(addr)}).String()
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,74 @@
+package mail_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+var logo = mail.FileAttachment{Name: "logo.png", ContentType: "image/png", Content: []byte("\x89PNG"), ContentID: "logo@test"}
+
+func TestSend_TextAlternativeAsMIME(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	err := srv.Client().Send(ctx, mail.SendOptions{
+		To:       []string{"a@example.com"},
+		Bcc:      []string{"hidden@example.com"},
+		Subject:  "Grüße",
+		Body:     `<p>Hello</p><img src="cid:logo@test">`,
+		TextBody: "Hello",
+		HTML:     true,
+		Attachments: []mail.FileAttachment{
+			logo,
+			{Name: "r.pdf", ContentType: "application/pdf", Content: []byte("%PDF")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 {
+		t.Fatalf("sent %d message(s), want 1", len(sent))
+	}
+	m := sent[0]
+	if m.Subject != "Grüße" || m.BodyType != "html" || !strings.Contains(m.Body, "cid:logo@test") {
+		t.Errorf("message = %q %s %q", m.Subject, m.BodyType, m.Body)
+	}
+	if len(m.Bcc) != 1 || m.Bcc[0] != "hidden@example.com" {
+		t.Errorf("bcc = %v", m.Bcc)
+	}
+	raw := string(m.MIME)
+	for _, want := range []string{"multipart/mixed", "multipart/alternative", "multipart/related", "text/plain"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("MIME message has no %s part", want)
+		}
+	}
+	if len(m.Attachment) != 2 || !m.Attachment[0].Inline || m.Attachment[0].ContentID != "logo@test" || m.Attachment[1].Inline {
+		t.Errorf("attachments = %+v", m.Attachment)
+	}
+}
+
+func TestSaveDraft_InlineImage(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	id, err := srv.Client().SaveDraft(ctx, mail.SendOptions{
+		To:          []string{"a@example.com"},
+		Subject:     "Logo",
+		Body:        `<img src="cid:logo@test">`,
+		HTML:        true,
+		Attachments: []mail.FileAttachment{logo},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	draft, _ := srv.Message(id)
+	if len(draft.Attachment) != 1 || !draft.Attachment[0].Inline || draft.Attachment[0].ContentID != "logo@test" {
+		t.Errorf("attachments = %+v", draft.Attachment)
+	}
+}
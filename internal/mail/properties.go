@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Importance values of a message
+var Importances = []string{"low", "normal", "high"}
+
+// Sensitivity values of a message, in the order of PidTagSensitivity
+var Sensitivities = []string{"normal", "personal", "private", "confidential"}
+
+// sensitivityProperty is PidTagSensitivity. Graph v1.0 has no sensitivity
+// property on messages, so it is set as an extended property.
+const sensitivityProperty = "Integer 0x0036"
+
+// mimeSensitivity are the RFC 2156 Sensitivity header values
+var mimeSensitivity = map[string]string{
+	"personal":     "Personal",
+	"private":      "Private",
+	"confidential": "Company-Confidential",
+}
+
+// ParseImportance validates an importance name (case-insensitive)
+func ParseImportance(s string) (string, error) {
+	return parseChoice("importance", s, Importances)
+}
+
+// ParseSensitivity validates a sensitivity name (case-insensitive)
+func ParseSensitivity(s string) (string, error) {
+	return parseChoice("sensitivity", s, Sensitivities)
+}
+
+func parseChoice(kind, s string, choices []string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	for _, choice := range choices {
+		if value == choice {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q (use %s)", kind, s, strings.Join(choices, ", "))
+}
+
+// hasReceipts reports whether read or delivery receipts are requested
+func (opts SendOptions) hasReceipts() bool {
+	return opts.ReadReceipt || opts.DeliveryReceipt
+}
+
+// setMessageProperties adds importance, sensitivity, receipt requests and
+// reply-to addresses of opts to a Graph message payload
+func setMessageProperties(message map[string]interface{}, opts SendOptions) {
+	if opts.Importance != "" {
+		message["importance"] = opts.Importance
+	}
+	if props := extendedProperties(opts); len(props) > 0 {
+		message["singleValueExtendedProperties"] = props
+	}
+	if opts.ReadReceipt {
+		message["isReadReceiptRequested"] = true
+	}
+	if opts.DeliveryReceipt {
+		message["isDeliveryReceiptRequested"] = true
+	}
+	if len(opts.ReplyTo) > 0 {
+		message["replyTo"] = recipients(opts.ReplyTo)
+	}
+}
+
+// extendedProperties returns the MAPI properties of opts that Graph has
+// no message property for
+func extendedProperties(opts SendOptions) []map[string]string {
+	var props []map[string]string
+	for i, s := range Sensitivities {
+		if opts.Sensitivity != "" && s == opts.Sensitivity {
+			props = append(props, map[string]string{"id": sensitivityProperty, "value": strconv.Itoa(i)})
+		}
+	}
+	if !opts.SendAt.IsZero() {
+		props = append(props, deferredSendProperty(opts.SendAt))
+	}
+	return props
+}
+
+// mimeDraftPatch returns the properties of opts a MIME message cannot carry
+// (receipt requests and the deferred send time) as a PATCH body for the
+// draft, or nil if there are none
+func mimeDraftPatch(opts SendOptions) map[string]interface{} {
+	if !opts.hasReceipts() && opts.SendAt.IsZero() {
+		return nil
+	}
+	patch := map[string]interface{}{}
+	if opts.ReadReceipt {
+		patch["isReadReceiptRequested"] = true
+	}
+	if opts.DeliveryReceipt {
+		patch["isDeliveryReceiptRequested"] = true
+	}
+	if !opts.SendAt.IsZero() {
+		patch["singleValueExtendedProperties"] = []map[string]string{deferredSendProperty(opts.SendAt)}
+	}
+	return patch
+}
+
+func recipients(addrs []string) []GraphEmailAddressWrapper {
+	result := make([]GraphEmailAddressWrapper, len(addrs))
+	for i, addr := range addrs {
+		result[i] = GraphEmailAddressWrapper{
+			EmailAddress: GraphEmailAddress{Address: ParseEmail(addr)},
+		}
+	}
+	return result
+}
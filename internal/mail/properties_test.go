@@ -0,0 +1,83 @@
+package mail_test
+
+import (
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestSend_MessageProperties(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	opts := mail.SendOptions{
+		To:              []string{"a@example.com"},
+		Subject:         "Outage",
+		Body:            "<p>The VPN is down</p>",
+		HTML:            true,
+		Importance:      "high",
+		Sensitivity:     "confidential",
+		ReadReceipt:     true,
+		DeliveryReceipt: true,
+		ReplyTo:         []string{"Ops <ops@example.com>"},
+	}
+	if err := srv.Client().Send(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	// With a text alternative the message is sent as MIME
+	opts.TextBody = "The VPN is down"
+	if err := srv.Client().Send(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Newest first: sent[0] is the MIME message
+	sent := srv.Messages("sentitems")
+	if len(sent) != 2 {
+		t.Fatalf("sent %d message(s), want 2", len(sent))
+	}
+	for i, m := range sent {
+		if m.Importance != "high" || m.Sensitivity != "confidential" {
+			t.Errorf("message %d: importance %q, sensitivity %q", i, m.Importance, m.Sensitivity)
+		}
+		if !m.ReadReceipt || !m.DeliveryReceipt {
+			t.Errorf("message %d: receipts = %v/%v", i, m.ReadReceipt, m.DeliveryReceipt)
+		}
+		if len(m.ReplyTo) != 1 || m.ReplyTo[0] != "ops@example.com" {
+			t.Errorf("message %d: reply-to = %v", i, m.ReplyTo)
+		}
+	}
+	if len(sent[0].MIME) == 0 {
+		t.Error("message with text alternative was not sent as MIME")
+	}
+	if len(srv.Messages("drafts")) != 0 {
+		t.Error("draft left behind")
+	}
+}
+
+func TestListEmailsFiltered_Importance(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	srv.AddMessage(graphtest.Message{Subject: "urgent", Importance: "high"})
+	srv.AddMessage(graphtest.Message{Subject: "routine"})
+
+	emails, err := srv.Client().ListEmailsFiltered(ctx, "inbox", 10, mail.ListFilter{Importance: "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emails) != 1 || emails[0].Subject != "urgent" || emails[0].Importance != "high" {
+		t.Errorf("emails = %+v", emails)
+	}
+}
+
+func TestParseSensitivity(t *testing.T) {
+	if s, err := mail.ParseSensitivity(" Confidential "); err != nil || s != "confidential" {
+		t.Errorf("ParseSensitivity = %q, %v", s, err)
+	}
+	if _, err := mail.ParseSensitivity("secret"); err == nil {
+		t.Error("expected error for unknown sensitivity")
+	}
+	if _, err := mail.ParseImportance("urgent"); err == nil {
+		t.Error("expected error for unknown importance")
+	}
+}
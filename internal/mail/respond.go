@@ -0,0 +1,269 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	netmail "net/mail"
+	"regexp"
+	"strings"
+)
+
+// ResponseOptions describes a reply or forward. Microsoft Graph quotes the
+// original message below Body.
+type ResponseOptions struct {
+	// Body is added above the quoted original
+	Body string
+	// HTML marks Body as HTML
+	HTML bool
+	// To, Cc and Bcc are added to the recipients Graph derives from the
+	// original. The recipients of a forward are To.
+	To  []string
+	Cc  []string
+	Bcc []string
+	// Remove are addresses removed from the derived recipients
+	Remove      []string
+	Attachments []FileAttachment
+}
+
+// Reply sends a reply (or reply-all) to a message. Simple replies are sent in
+// a single request. An HTML body, changed recipients or large attachments
+// need a reply draft that is completed and then sent.
+func (c *GraphClient) Reply(ctx context.Context, messageID string, opts ResponseOptions, replyAll bool) error {
+	action := "reply"
+	if replyAll {
+		action = "replyAll"
+	}
+	// Recipients in the message parameter replace the ones Graph derives,
+	// so only kinds the reply has none of can be set directly
+	direct := len(opts.To) == 0 && len(opts.Remove) == 0 && (!replyAll || len(opts.Cc) == 0)
+	return c.respond(ctx, messageID, action, opts, direct)
+}
+
+// Forward forwards a message to opts.To, with the original quoted below the body
+func (c *GraphClient) Forward(ctx context.Context, messageID string, opts ResponseOptions) error {
+	return c.respond(ctx, messageID, "forward", opts, len(opts.Remove) == 0)
+}
+
+// ForwardAsAttachment sends a new message to opts.To with the original
+// attached as an .eml file instead of quoted
+func (c *GraphClient) ForwardAsAttachment(ctx context.Context, messageID string, opts ResponseOptions) error {
+	content, err := c.GetMIME(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	subject := mimeSubject(content)
+
+	original := FileAttachment{Name: emlAttachmentName(subject), ContentType: "message/rfc822", Content: content}
+	return c.Send(ctx, SendOptions{
+		To:          opts.To,
+		Cc:          opts.Cc,
+		Bcc:         opts.Bcc,
+		Subject:     forwardSubject(subject),
+		Body:        opts.Body,
+		HTML:        opts.HTML,
+		Attachments: append([]FileAttachment{original}, opts.Attachments...),
+	})
+}
+
+// forwardSubject prefixes subject with "FW: " unless it already starts with
+// "FW:" or "Fwd:"
+func forwardSubject(subject string) string {
+	lower := strings.ToLower(subject)
+	if strings.HasPrefix(lower, "fw:") || strings.HasPrefix(lower, "fwd:") {
+		return subject
+	}
+	return "FW: " + subject
+}
+
+func (c *GraphClient) respond(ctx context.Context, messageID, action string, opts ResponseOptions, direct bool) error {
+	if err := checkAttachmentsSize(attachmentsSize(opts.Attachments)); err != nil {
+		return err
+	}
+
+	if !direct || opts.HTML || !canInlineAttachments(opts.Attachments) {
+		draftID, err := c.createResponse(ctx, messageID, "create"+strings.ToUpper(action[:1])+action[1:], opts)
+		if err != nil {
+			return err
+		}
+		if err := c.SendDraft(ctx, draftID); err != nil {
+			return fmt.Errorf("response saved as draft %s but not sent: %w", draftID, err)
+		}
+		return nil
+	}
+
+	message := map[string]interface{}{}
+	if len(opts.To) > 0 {
+		message["toRecipients"] = recipients(opts.To)
+	}
+	if len(opts.Cc) > 0 {
+		message["ccRecipients"] = recipients(opts.Cc)
+	}
+	if len(opts.Bcc) > 0 {
+		message["bccRecipients"] = recipients(opts.Bcc)
+	}
+	if len(opts.Attachments) > 0 {
+		attachments := make([]map[string]interface{}, len(opts.Attachments))
+		for i, att := range opts.Attachments {
+			attachments[i] = fileAttachmentJSON(att)
+		}
+		message["attachments"] = attachments
+	}
+
+	body := map[string]interface{}{}
+	if opts.Body != "" {
+		body["comment"] = opts.Body
+	}
+	if len(message) > 0 {
+		body["message"] = message
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	_, err := c.doRequest(ctx, "POST", c.baseURL+messagePath("", messageID)+"/"+action, jsonBody)
+	return err
+}
+
+// createResponse creates a reply or forward draft with createReply,
+// createReplyAll or createForward and applies opts to it. The draft is
+// deleted again if it cannot be completed.
+func (c *GraphClient) createResponse(ctx context.Context, messageID, action string, opts ResponseOptions) (string, error) {
+	if err := checkAttachmentsSize(attachmentsSize(opts.Attachments)); err != nil {
+		return "", err
+	}
+
+	// A comment is always added as text; HTML goes into the body afterwards
+	req := map[string]interface{}{}
+	if opts.Body != "" && !opts.HTML {
+		req["comment"] = opts.Body
+	}
+	jsonBody, _ := json.Marshal(req)
+	resp, err := c.doRequest(ctx, "POST", c.baseURL+messagePath("", messageID)+"/"+action, jsonBody)
+	if err != nil {
+		return "", err
+	}
+
+	var draft GraphMessageResponse
+	if err := json.Unmarshal(resp, &draft); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if err := c.completeResponse(ctx, draft, opts); err != nil {
+		c.DeleteDraft(context.WithoutCancel(ctx), draft.ID)
+		return "", err
+	}
+	return draft.ID, nil
+}
+
+func (c *GraphClient) completeResponse(ctx context.Context, draft GraphMessageResponse, opts ResponseOptions) error {
+	for _, addr := range opts.Remove {
+		if !hasRecipient(draft.ToRecipients, addr) && !hasRecipient(draft.CcRecipients, addr) && !hasRecipient(draft.BccRecipients, addr) {
+			return fmt.Errorf("recipient '%s' %w", addr, ErrNotFound)
+		}
+	}
+
+	patch := map[string]interface{}{}
+	if to, changed := adjustRecipients(draft.ToRecipients, opts.To, opts.Remove); changed {
+		patch["toRecipients"] = to
+	}
+	if cc, changed := adjustRecipients(draft.CcRecipients, opts.Cc, opts.Remove); changed {
+		patch["ccRecipients"] = cc
+	}
+	if bcc, changed := adjustRecipients(draft.BccRecipients, opts.Bcc, opts.Remove); changed {
+		patch["bccRecipients"] = bcc
+	}
+	if opts.HTML && opts.Body != "" {
+		patch["body"] = GraphBody{ContentType: "HTML", Content: prependHTML(opts.Body, draft.Body)}
+	}
+	if len(patch) > 0 {
+		jsonBody, _ := json.Marshal(patch)
+		if _, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", draft.ID), jsonBody); err != nil {
+			return err
+		}
+	}
+
+	return c.addAttachments(ctx, draft.ID, opts.Attachments)
+}
+
+// adjustRecipients removes the remove addresses from current and appends
+// the add addresses not yet present. changed reports whether anything differs.
+func adjustRecipients(current []GraphEmailAddressWrapper, add, remove []string) (result []GraphEmailAddressWrapper, changed bool) {
+	result = []GraphEmailAddressWrapper{}
+	for _, r := range current {
+		if containsAddress(remove, r.EmailAddress.Address) {
+			changed = true
+			continue
+		}
+		result = append(result, r)
+	}
+	for _, addr := range add {
+		if hasRecipient(result, addr) {
+			continue
+		}
+		result = append(result, recipients([]string{addr})...)
+		changed = true
+	}
+	return result, changed
+}
+
+func hasRecipient(list []GraphEmailAddressWrapper, addr string) bool {
+	for _, r := range list {
+		if strings.EqualFold(r.EmailAddress.Address, ParseEmail(addr)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddress(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if strings.EqualFold(ParseEmail(a), addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var bodyTagPattern = regexp.MustCompile(`(?i)<body[^>]*>`)
+
+// prependHTML inserts comment above the quoted original in a response
+// draft body, converting a plain text body to HTML
+func prependHTML(comment string, body GraphBodyResponse) string {
+	quoted := body.Content
+	if !strings.EqualFold(body.ContentType, "html") {
+		quoted = `<div style="white-space: pre-wrap">` + html.EscapeString(quoted) + "</div>"
+	}
+	if loc := bodyTagPattern.FindStringIndex(quoted); loc != nil {
+		return quoted[:loc[1]] + comment + quoted[loc[1]:]
+	}
+	return comment + quoted
+}
+
+// mimeSubject returns the decoded subject of an RFC 822 message
+func mimeSubject(content []byte) string {
+	msg, err := netmail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	subject := msg.Header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	return subject
+}
+
+// emlAttachmentName derives the file name of a forwarded message from its subject
+func emlAttachmentName(subject string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(subject))
+	if name == "" {
+		name = "message"
+	}
+	return name + ".eml"
+}
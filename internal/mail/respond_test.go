@@ -0,0 +1,123 @@
+package mail_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestReply_MessageParameter(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	id := srv.AddMessage(graphtest.Message{Subject: "Question", From: "a@example.com"})
+
+	err := srv.Client().Reply(ctx, id, mail.ResponseOptions{
+		Body:        "Answer",
+		Bcc:         []string{"archive@example.com"},
+		Attachments: []mail.FileAttachment{{Name: "answer.txt", ContentType: "text/plain", Content: []byte("42")}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := srv.Actions()
+	if len(actions) != 1 || actions[0].Kind != "reply" || actions[0].Body["comment"] != "Answer" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	message := fmt.Sprint(actions[0].Body["message"])
+	for _, want := range []string{"archive@example.com", "answer.txt"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message parameter %s does not contain %q", message, want)
+		}
+	}
+}
+
+func TestReply_ThroughDraft(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+	id := srv.AddMessage(graphtest.Message{
+		Subject:  "Question",
+		From:     "a@example.com",
+		To:       []string{"me@example.com"},
+		Cc:       []string{"b@example.com"},
+		Body:     "<html><body><p>Original</p></body></html>",
+		BodyType: "html",
+	})
+
+	err := c.Reply(ctx, id, mail.ResponseOptions{
+		Body:        "<p>Answer</p>",
+		HTML:        true,
+		Cc:          []string{"c@example.com"},
+		Remove:      []string{"B@example.com", "me@example.com"},
+		Attachments: []mail.FileAttachment{{Name: "answer.txt", ContentType: "text/plain", Content: []byte("42")}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || len(srv.Actions()) != 0 || len(srv.Messages("drafts")) != 0 {
+		t.Fatalf("sent = %+v, actions = %+v", sent, srv.Actions())
+	}
+	m := sent[0]
+	if len(m.To) != 1 || m.To[0] != "a@example.com" || len(m.Cc) != 1 || m.Cc[0] != "c@example.com" {
+		t.Errorf("recipients = %v / %v", m.To, m.Cc)
+	}
+	if m.BodyType != "html" || !strings.Contains(m.Body, "<body><p>Answer</p>") || !strings.Contains(m.Body, "Original") {
+		t.Errorf("body = %s %q", m.BodyType, m.Body)
+	}
+	if len(m.Attachment) != 1 || m.Attachment[0].Name != "answer.txt" {
+		t.Errorf("attachments = %+v", m.Attachment)
+	}
+
+	err = c.Reply(ctx, id, mail.ResponseOptions{Body: "x", Remove: []string{"nobody@example.com"}}, false)
+	if !errors.Is(err, mail.ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if n := len(srv.Messages("drafts")); n != 0 {
+		t.Errorf("%d draft(s) left behind", n)
+	}
+}
+
+func TestForwardAsAttachment(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	id := srv.AddMessage(graphtest.Message{Subject: "Invoice 2/24", From: "billing@example.com", Body: "Please pay"})
+
+	err := srv.Client().ForwardAsAttachment(ctx, id, mail.ResponseOptions{To: []string{"abuse@example.com"}, Body: "Phishing?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := srv.Messages("sentitems")
+	if len(sent) != 1 || sent[0].Subject != "FW: Invoice 2/24" || sent[0].Body != "Phishing?" {
+		t.Fatalf("sent = %+v", sent)
+	}
+	att := sent[0].Attachment
+	if len(att) != 1 || att[0].Name != "Invoice 2_24.eml" || att[0].ContentType != "message/rfc822" {
+		t.Fatalf("attachments = %+v", att)
+	}
+	if !strings.Contains(string(att[0].Content), "From: billing@example.com") {
+		t.Errorf("attached message = %q", att[0].Content)
+	}
+}
+
+func TestForwardAsAttachment_AlreadyForwarded(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+
+	for _, subject := range []string{"FW: Invoice", "fwd: Invoice", "Fw:Invoice"} {
+		id := srv.AddMessage(graphtest.Message{Subject: subject, From: "billing@example.com"})
+		if err := srv.Client().ForwardAsAttachment(ctx, id, mail.ResponseOptions{To: []string{"abuse@example.com"}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := srv.Messages("sentitems")[0].Subject; got != subject {
+			t.Errorf("subject %q forwarded as %q", subject, got)
+		}
+	}
+}
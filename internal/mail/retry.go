@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how throttled or unavailable Graph requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one (1 = no retries)
+	MaxAttempts int
+	// BaseDelay is the initial backoff, doubled after every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff. A Retry-After header from the server is always honored.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   1 * time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// shouldRetry reports whether a response status may be retried for the given method.
+// Throttling (429) means Graph rejected the request without processing it, so it is
+// safe to retry any method. 503/504 may occur after the request was processed, so
+// only idempotent methods are retried to avoid e.g. sending a mail twice.
+func shouldRetry(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+// isIdempotent reports whether repeating a request has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry (1-based).
+// A valid Retry-After header takes precedence over the exponential backoff.
+func (p RetryPolicy) backoff(retry int, retryAfter string) time.Duration {
+	if d, ok := parseRetryAfter(retryAfter); ok {
+		return d
+	}
+
+	delay := p.BaseDelay << uint(retry-1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+
+	// Up to 10% jitter so parallel clients don't retry in lockstep
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/10 + 1))
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
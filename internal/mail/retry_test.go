@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	if d, ok := parseRetryAfter("5"); !ok || d != 5*time.Second {
+		t.Errorf("seconds: got %v, %v", d, ok)
+	}
+
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(future); !ok || d <= 0 || d > 10*time.Second {
+		t.Errorf("http date: got %v, %v", d, ok)
+	}
+
+	for _, v := range []string{"", "soon", "-1"} {
+		if _, ok := parseRetryAfter(v); ok {
+			t.Errorf("%q should not parse", v)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	if d := p.backoff(1, ""); d < 100*time.Millisecond || d > 110*time.Millisecond {
+		t.Errorf("first retry = %v", d)
+	}
+	if d := p.backoff(2, ""); d < 200*time.Millisecond || d > 220*time.Millisecond {
+		t.Errorf("second retry = %v", d)
+	}
+	if d := p.backoff(10, ""); d < 300*time.Millisecond || d > 330*time.Millisecond {
+		t.Errorf("capped retry = %v", d)
+	}
+	if d := p.backoff(1, "2"); d != 2*time.Second {
+		t.Errorf("Retry-After not honored: %v", d)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		method string
+		status int
+		want   bool
+	}{
+		{"GET", 429, true},
+		{"POST", 429, true},
+		{"GET", 503, true},
+		{"PATCH", 504, true},
+		{"POST", 503, false},
+		{"POST", 504, false},
+		{"GET", 500, false},
+		{"GET", 404, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.method, tt.status); got != tt.want {
+			t.Errorf("shouldRetry(%s, %d) = %v, want %v", tt.method, tt.status, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package mail
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -80,10 +81,10 @@ type GraphRulesResponse struct {
 }
 
 // ListRules lists all inbox message rules
-func (c *GraphClient) ListRules() ([]MessageRule, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules", GraphAPIBaseURL)
+func (c *GraphClient) ListRules(ctx context.Context) ([]MessageRule, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules", c.baseURL)
 
-	resp, err := c.doRequest("GET", endpoint, nil)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -97,10 +98,10 @@ func (c *GraphClient) ListRules() ([]MessageRule, error) {
 }
 
 // GetRule gets a specific inbox message rule
-func (c *GraphClient) GetRule(ruleID string) (*MessageRule, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", GraphAPIBaseURL, ruleID)
+func (c *GraphClient) GetRule(ctx context.Context, ruleID string) (*MessageRule, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", c.baseURL, ruleID)
 
-	resp, err := c.doRequest("GET", endpoint, nil)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -114,15 +115,15 @@ func (c *GraphClient) GetRule(ruleID string) (*MessageRule, error) {
 }
 
 // CreateRule creates a new inbox message rule
-func (c *GraphClient) CreateRule(rule *MessageRule) (*MessageRule, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules", GraphAPIBaseURL)
+func (c *GraphClient) CreateRule(ctx context.Context, rule *MessageRule) (*MessageRule, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules", c.baseURL)
 
 	jsonBody, err := json.Marshal(rule)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal rule: %w", err)
 	}
 
-	resp, err := c.doRequest("POST", endpoint, jsonBody)
+	resp, err := c.doRequest(ctx, "POST", endpoint, jsonBody)
 	if err != nil {
 		return nil, err
 	}
@@ -136,15 +137,15 @@ func (c *GraphClient) CreateRule(rule *MessageRule) (*MessageRule, error) {
 }
 
 // UpdateRule updates an existing inbox message rule
-func (c *GraphClient) UpdateRule(ruleID string, updates *MessageRule) (*MessageRule, error) {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", GraphAPIBaseURL, ruleID)
+func (c *GraphClient) UpdateRule(ctx context.Context, ruleID string, updates *MessageRule) (*MessageRule, error) {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", c.baseURL, ruleID)
 
 	jsonBody, err := json.Marshal(updates)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal updates: %w", err)
 	}
 
-	resp, err := c.doRequest("PATCH", endpoint, jsonBody)
+	resp, err := c.doRequest(ctx, "PATCH", endpoint, jsonBody)
 	if err != nil {
 		return nil, err
 	}
@@ -158,21 +159,21 @@ func (c *GraphClient) UpdateRule(ruleID string, updates *MessageRule) (*MessageR
 }
 
 // DeleteRule deletes an inbox message rule
-func (c *GraphClient) DeleteRule(ruleID string) error {
-	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", GraphAPIBaseURL, ruleID)
+func (c *GraphClient) DeleteRule(ctx context.Context, ruleID string) error {
+	endpoint := fmt.Sprintf("%s/me/mailFolders/inbox/messageRules/%s", c.baseURL, ruleID)
 
-	_, err := c.doRequest("DELETE", endpoint, nil)
+	_, err := c.doRequest(ctx, "DELETE", endpoint, nil)
 	return err
 }
 
 // EnableRule enables an inbox message rule
-func (c *GraphClient) EnableRule(ruleID string) (*MessageRule, error) {
-	return c.UpdateRule(ruleID, &MessageRule{IsEnabled: true})
+func (c *GraphClient) EnableRule(ctx context.Context, ruleID string) (*MessageRule, error) {
+	return c.UpdateRule(ctx, ruleID, &MessageRule{IsEnabled: true})
 }
 
 // DisableRule disables an inbox message rule
-func (c *GraphClient) DisableRule(ruleID string) (*MessageRule, error) {
-	return c.UpdateRule(ruleID, &MessageRule{IsEnabled: false})
+func (c *GraphClient) DisableRule(ctx context.Context, ruleID string) (*MessageRule, error) {
+	return c.UpdateRule(ctx, ruleID, &MessageRule{IsEnabled: false})
 }
 
 // Helper function to create email address wrapper from string
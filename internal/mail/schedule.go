@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// deferredSendTimeProperty is PidTagDeferredSendTime. Exchange keeps a sent
+// message with this property in the Outbox until the given time.
+const deferredSendTimeProperty = "SystemTime 0x3FEF"
+
+func deferredSendProperty(at time.Time) map[string]string {
+	return map[string]string{"id": deferredSendTimeProperty, "value": at.UTC().Format(time.RFC3339)}
+}
+
+// ScheduledEmail is a sent message waiting in the Outbox for its deferred send time
+type ScheduledEmail struct {
+	Email
+	SendAt time.Time `json:"send_at"`
+}
+
+// SendDraftAt sends a draft, deferring its delivery until at
+func (c *GraphClient) SendDraftAt(ctx context.Context, messageID string, at time.Time) error {
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"singleValueExtendedProperties": []map[string]string{deferredSendProperty(at)},
+	})
+	if _, err := c.doRequest(ctx, "PATCH", c.baseURL+messagePath("", messageID), jsonBody); err != nil {
+		return err
+	}
+	return c.SendDraft(ctx, messageID)
+}
+
+// ListScheduled lists the messages waiting for their deferred send time,
+// the next one first
+func (c *GraphClient) ListScheduled(ctx context.Context) ([]ScheduledEmail, error) {
+	params := url.Values{}
+	params.Set("$top", "100")
+	params.Set("$select", "id,subject,bodyPreview,receivedDateTime,isRead,from,toRecipients,ccRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
+	params.Set("$expand", fmt.Sprintf("singleValueExtendedProperties($filter=id eq '%s')", deferredSendTimeProperty))
+
+	var scheduled []ScheduledEmail
+	currentEndpoint := c.baseURL + "/me/mailFolders/outbox/messages?" + params.Encode()
+
+	for currentEndpoint != "" {
+		resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Value []struct {
+				GraphMessageResponse
+				SingleValueExtendedProperties []struct {
+					ID    string `json:"id"`
+					Value string `json:"value"`
+				} `json:"singleValueExtendedProperties"`
+			} `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		for _, msg := range result.Value {
+			for _, prop := range msg.SingleValueExtendedProperties {
+				if !strings.EqualFold(prop.ID, deferredSendTimeProperty) {
+					continue
+				}
+				at, err := time.Parse(time.RFC3339, prop.Value)
+				if err != nil {
+					c.logf("Ignoring invalid deferred send time %q of %s", prop.Value, msg.ID)
+					continue
+				}
+				scheduled = append(scheduled, ScheduledEmail{Email: graphMessageToEmail(msg.GraphMessageResponse), SendAt: at})
+			}
+		}
+
+		currentEndpoint = result.NextLink
+	}
+
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].SendAt.Before(scheduled[j].SendAt)
+	})
+	return scheduled, nil
+}
+
+// CancelScheduled stops the delivery of a scheduled message by moving it
+// back to the Drafts folder and returns its new ID
+func (c *GraphClient) CancelScheduled(ctx context.Context, messageID string) (string, error) {
+	return c.MoveMessage(ctx, messageID, "drafts")
+}
@@ -0,0 +1,50 @@
+package mail_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"github.com/yourname/o365-mail-cli/internal/mail/graphtest"
+)
+
+func TestSend_Deferred(t *testing.T) {
+	srv := graphtest.NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	later := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	err := c.Send(ctx, mail.SendOptions{To: []string{"a@example.com"}, Subject: "Later", Body: "text", SendAt: later.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	draftID, err := c.SaveDraft(ctx, mail.SendOptions{To: []string{"b@example.com"}, Subject: "Sooner", Body: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendDraftAt(ctx, draftID, later); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.Messages("sentitems")); n != 0 {
+		t.Errorf("%d message(s) sent immediately", n)
+	}
+
+	scheduled, err := c.ListScheduled(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scheduled) != 2 || scheduled[0].Subject != "Sooner" || !scheduled[0].SendAt.Equal(later) {
+		t.Fatalf("scheduled = %+v", scheduled)
+	}
+
+	id, err := c.CancelScheduled(ctx, scheduled[0].MessageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := srv.Message(id); m.FolderID != "drafts" || !m.IsDraft {
+		t.Errorf("cancelled message in %s (draft %v), want a draft", m.FolderID, m.IsDraft)
+	}
+	if scheduled, _ := c.ListScheduled(ctx); len(scheduled) != 1 {
+		t.Errorf("%d scheduled after cancelling, want 1", len(scheduled))
+	}
+}
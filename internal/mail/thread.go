@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ThreadFolders are the folders searched for the messages of a conversation
+var ThreadFolders = []string{"inbox", "sentitems", "archive"}
+
+// Thread is a conversation: the messages sharing a conversation ID
+type Thread struct {
+	ConversationID string    `json:"conversation_id"`
+	Subject        string    `json:"subject"`
+	Latest         time.Time `json:"latest"`
+	UnreadCount    int       `json:"unread_count"`
+	Messages       []Email   `json:"messages"`
+}
+
+// GetConversation fetches the messages of a conversation with their bodies
+// from the given folders, oldest first. Folders that don't exist (such as
+// an archive that was never set up) are skipped.
+func (c *GraphClient) GetConversation(ctx context.Context, conversationID string, folderIDs []string) ([]Email, error) {
+	params := url.Values{}
+	params.Set("$top", "100")
+	params.Set("$filter", fmt.Sprintf("conversationId eq '%s'", strings.ReplaceAll(conversationID, "'", "''")))
+	params.Set("$select", "id,subject,body,bodyPreview,receivedDateTime,isRead,from,toRecipients,ccRecipients,hasAttachments,internetMessageId,conversationId,categories,flag,importance")
+
+	seen := make(map[string]bool)
+	var emails []Email
+	for _, folderID := range folderIDs {
+		currentEndpoint := fmt.Sprintf("%s/me/mailFolders/%s/messages?%s", c.baseURL, url.PathEscape(folderID), params.Encode())
+
+		for currentEndpoint != "" {
+			resp, err := c.doRequest(ctx, "GET", currentEndpoint, nil)
+			if errors.Is(err, ErrNotFound) {
+				c.logf("Folder %s not found, skipping", folderID)
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var result GraphMessagesResponse
+			if err := json.Unmarshal(resp, &result); err != nil {
+				return nil, fmt.Errorf("failed to parse response: %w", err)
+			}
+
+			for _, msg := range result.Value {
+				if seen[msg.ID] {
+					continue
+				}
+				seen[msg.ID] = true
+				email := graphMessageToEmail(msg)
+				email.Body = msg.Body.Content
+				email.BodyType = msg.Body.ContentType
+				emails = append(emails, email)
+			}
+
+			currentEndpoint = result.NextLink
+		}
+	}
+
+	sort.SliceStable(emails, func(i, j int) bool {
+		return emails[i].Date.Before(emails[j].Date)
+	})
+	return emails, nil
+}
+
+// GroupThreads groups emails by conversation. Threads are ordered by their
+// first email in the list and keep the order of their emails, so a list
+// sorted newest first gives threads with the most recent activity first.
+// Emails without a conversation ID form a thread of their own.
+func GroupThreads(emails []Email) []Thread {
+	var threads []Thread
+	index := make(map[string]int)
+	for _, email := range emails {
+		key := email.ConversationID
+		if key == "" {
+			key = "message:" + email.MessageID
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(threads)
+			index[key] = i
+			threads = append(threads, Thread{ConversationID: email.ConversationID, Subject: email.Subject})
+		}
+
+		t := &threads[i]
+		t.Messages = append(t.Messages, email)
+		if email.Date.After(t.Latest) {
+			t.Latest = email.Date
+		}
+		if email.Unread {
+			t.UnreadCount++
+		}
+	}
+	return threads
+}
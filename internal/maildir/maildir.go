@@ -0,0 +1,168 @@
+// Package maildir reads and writes Maildir directories: messages are files
+// in new/ (not yet seen by a mail client) and cur/, with flags encoded in the
+// file name after ":2," (e.g. "1700000000.M1P2.host:2,FS"). New messages are
+// written to tmp/ first and then renamed into place.
+package maildir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Message is a message file in a Maildir
+type Message struct {
+	Path string
+	// Key is the unique file name without flags
+	Key string
+	// Flags are the Maildir flags, e.g. "FS" for flagged and seen
+	Flags string
+}
+
+// Seen reports whether the message has been read
+func (m Message) Seen() bool {
+	return strings.ContainsRune(m.Flags, 'S')
+}
+
+// Dir returns the Maildir containing the message
+func (m Message) Dir() string {
+	return filepath.Dir(filepath.Dir(m.Path))
+}
+
+// IsMaildir reports whether dir has the cur/ and new/ subdirectories of a Maildir
+func IsMaildir(dir string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		info, err := os.Stat(filepath.Join(dir, sub))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the messages in new/ and cur/, each sorted by file name
+func List(dir string) ([]Message, error) {
+	var messages []Message
+	for _, sub := range []string{"new", "cur"} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read maildir: %w", err)
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			key, flags := ParseName(name)
+			messages = append(messages, Message{Path: filepath.Join(dir, sub, name), Key: key, Flags: flags})
+		}
+	}
+	return messages, nil
+}
+
+// ParseName splits a Maildir file name into its unique key and flags
+func ParseName(name string) (key, flags string) {
+	if i := strings.LastIndex(name, ":2,"); i >= 0 {
+		return name[:i], name[i+3:]
+	}
+	return name, ""
+}
+
+// Create makes dir a Maildir, creating cur/, new/ and tmp/ as needed
+func Create(dir string) error {
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+	return nil
+}
+
+var deliveries atomic.Int64
+
+// NewKey returns a unique name for a new message
+func NewKey() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	host = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(host)
+	now := time.Now()
+	return fmt.Sprintf("%d.M%dP%dQ%d.%s", now.Unix(), now.Nanosecond()/1000, os.Getpid(), deliveries.Add(1), host)
+}
+
+// Deliver writes content as a new message with the given key. Messages
+// without flags go to new/, others to cur/.
+func Deliver(dir, key, flags string, content []byte) (Message, error) {
+	tmp := filepath.Join(dir, "tmp", key)
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return Message{}, fmt.Errorf("failed to write message: %w", err)
+	}
+
+	msg := Message{Path: messagePath(dir, key, normalizeFlags(flags)), Key: key, Flags: normalizeFlags(flags)}
+	if err := os.Rename(tmp, msg.Path); err != nil {
+		os.Remove(tmp)
+		return Message{}, fmt.Errorf("failed to deliver message: %w", err)
+	}
+	return msg, nil
+}
+
+// SetFlags replaces the flags of a message by renaming it into cur/
+func SetFlags(m Message, flags string) (Message, error) {
+	return rename(m, m.Dir(), flags)
+}
+
+// Move moves a message into another Maildir, keeping key and flags
+func Move(m Message, dir string) (Message, error) {
+	return rename(m, dir, m.Flags)
+}
+
+// Remove deletes a message
+func Remove(m Message) error {
+	if err := os.Remove(m.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+	return nil
+}
+
+func rename(m Message, dir, flags string) (Message, error) {
+	flags = normalizeFlags(flags)
+	moved := Message{Path: filepath.Join(dir, "cur", m.Key+":2,"+flags), Key: m.Key, Flags: flags}
+	if moved.Path == m.Path {
+		return m, nil
+	}
+	if err := os.Rename(m.Path, moved.Path); err != nil {
+		return m, fmt.Errorf("failed to update message: %w", err)
+	}
+	return moved, nil
+}
+
+func messagePath(dir, key, flags string) string {
+	if flags == "" {
+		return filepath.Join(dir, "new", key)
+	}
+	return filepath.Join(dir, "cur", key+":2,"+flags)
+}
+
+// normalizeFlags sorts flags and drops duplicates, as the Maildir spec requires
+func normalizeFlags(flags string) string {
+	seen := map[rune]bool{}
+	var result []rune
+	for _, r := range flags {
+		if !seen[r] {
+			seen[r] = true
+			result = append(result, r)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return string(result)
+}
@@ -0,0 +1,94 @@
+package maildir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"new/2.host":      "Subject: new\n\n",
+		"cur/1.host:2,S":  "Subject: seen\n\n",
+		"cur/0.host:2,FR": "Subject: flagged\n\n",
+		"cur/.hidden":     "",
+		"tmp/3.host":      "Subject: in delivery\n\n",
+	}
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		os.MkdirAll(filepath.Join(dir, sub), 0700)
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+	}
+
+	if !IsMaildir(dir) || IsMaildir(filepath.Join(dir, "cur")) {
+		t.Fatal("IsMaildir mismatch")
+	}
+
+	messages, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	want := []struct {
+		key  string
+		seen bool
+	}{{"2.host", false}, {"0.host", false}, {"1.host", true}}
+	for i, w := range want {
+		if messages[i].Key != w.key || messages[i].Seen() != w.seen {
+			t.Errorf("message %d = %+v, want key %s seen %t", i, messages[i], w.key, w.seen)
+		}
+	}
+}
+
+func TestDeliverAndUpdate(t *testing.T) {
+	root := t.TempDir()
+	inbox, archive := filepath.Join(root, "Inbox"), filepath.Join(root, "Archive")
+	for _, dir := range []string{inbox, archive} {
+		if err := Create(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key := NewKey()
+	if key == NewKey() {
+		t.Fatal("NewKey returned the same key twice")
+	}
+	msg, err := Deliver(inbox, key, "", []byte("Subject: x\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Path != filepath.Join(inbox, "new", key) {
+		t.Errorf("unflagged message delivered to %s, want new/", msg.Path)
+	}
+
+	msg, err = SetFlags(msg, "SFS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Path != filepath.Join(inbox, "cur", key+":2,FS") || !msg.Seen() {
+		t.Errorf("after SetFlags: %+v", msg)
+	}
+
+	msg, err = Move(msg, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Dir() != archive {
+		t.Errorf("moved message is in %s, want %s", msg.Dir(), archive)
+	}
+	listed, _ := List(archive)
+	if len(listed) != 1 || listed[0] != msg {
+		t.Errorf("List(archive) = %+v, want %+v", listed, msg)
+	}
+
+	if err := Remove(msg); err != nil {
+		t.Fatal(err)
+	}
+	if listed, _ := List(archive); len(listed) != 0 {
+		t.Errorf("message still listed after Remove: %+v", listed)
+	}
+}
@@ -0,0 +1,51 @@
+// Package mbox reads and writes mbox files in the mboxrd flavour, where body
+// lines starting with "From " (after any number of '>') are quoted with one more '>'.
+package mbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+)
+
+// fromLine matches lines that must be quoted in mboxrd
+var fromLine = regexp.MustCompile(`(?m)^(>*From )`)
+
+// Writer appends messages to an mbox stream
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer appending to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteMessage appends one RFC 822 message. sender and date form the "From " separator
+// line; an empty sender is written as MAILER-DAEMON. The message is written with a
+// single Write call so an interrupted export never leaves half a message behind.
+func (w *Writer) WriteMessage(sender string, date time.Time, msg []byte) error {
+	if sender == "" {
+		sender = "MAILER-DAEMON"
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From %s %s\n", sender, date.UTC().Format(time.ANSIC))
+
+	msg = bytes.ReplaceAll(msg, []byte("\r\n"), []byte("\n"))
+	b.Write(fromLine.ReplaceAll(msg, []byte(">$1")))
+	if !bytes.HasSuffix(msg, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	if _, err := w.w.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write mbox message: %w", err)
+	}
+	return nil
+}
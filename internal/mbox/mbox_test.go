@@ -0,0 +1,90 @@
+package mbox
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	date := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	msg := "Subject: Hi\r\n\r\nFrom here on\r\n>From quoted\r\nnot From start"
+	if err := w.WriteMessage("a@example.com", date, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteMessage("", date, []byte("Subject: Two\n\nBody\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "From a@example.com Tue Jan  2 15:04:05 2024\n" +
+		"Subject: Hi\n\n>From here on\n>>From quoted\nnot From start\n\n" +
+		"From MAILER-DAEMON Tue Jan  2 15:04:05 2024\n" +
+		"Subject: Two\n\nBody\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("mbox =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestReader(t *testing.T) {
+	input := "\nFrom a@example.com Tue Jan  2 15:04:05 2024\n" +
+		"Subject: Hi\n\n>From here on\n>>From quoted\n\n" +
+		"From MAILER-DAEMON Wed Jan  3 10:00:00 2024\n" +
+		"Subject: Two\n\nBody"
+
+	r := NewReader(strings.NewReader(input))
+	first, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Sender != "a@example.com" || !first.Date.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)) {
+		t.Errorf("first separator = %q %v", first.Sender, first.Date)
+	}
+	if got, want := string(first.Content), "Subject: Hi\n\nFrom here on\n>From quoted\n"; got != want {
+		t.Errorf("first content = %q, want %q", got, want)
+	}
+
+	second, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(second.Content), "Subject: Two\n\nBody"; got != want {
+		t.Errorf("second content = %q, want %q", got, want)
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	msgs := []string{"Subject: A\n\nFrom me\n", "Subject: B\n\n>From you\n\n"}
+	for _, m := range msgs {
+		if err := w.WriteMessage("x@example.com", time.Now(), []byte(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewReader(&buf)
+	for i, want := range msgs {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg.Content) != want {
+			t.Errorf("message %d = %q, want %q", i, msg.Content, want)
+		}
+	}
+}
+
+func TestReader_NotMbox(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("Subject: x\n\nbody\n")).Next(); err == nil {
+		t.Error("expected an error for a non-mbox file")
+	}
+}
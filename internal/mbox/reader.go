@@ -0,0 +1,116 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Message is a message read from an mbox stream
+type Message struct {
+	// Sender and Date come from the "From " separator line and may be empty
+	Sender  string
+	Date    time.Time
+	Content []byte
+}
+
+// Reader reads messages from an mbox stream
+type Reader struct {
+	r    *bufio.Reader
+	next string
+	err  error
+}
+
+// NewReader returns a Reader for r. Data before the first "From " line is an error.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next message, or io.EOF after the last one
+func (r *Reader) Next() (*Message, error) {
+	if r.next == "" {
+		line, err := r.readFromLine()
+		if err != nil {
+			return nil, err
+		}
+		r.next = line
+	}
+
+	msg := parseFromLine(r.next)
+	r.next = ""
+
+	var content bytes.Buffer
+	for {
+		line, err := r.r.ReadString('\n')
+		if strings.HasPrefix(line, "From ") {
+			r.next = line
+			break
+		}
+		content.WriteString(unquote(line))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mbox: %w", err)
+		}
+	}
+
+	// The blank line before the next separator belongs to the mbox format
+	data := content.Bytes()
+	if bytes.HasSuffix(data, []byte("\n\n")) {
+		data = data[:len(data)-1]
+	}
+	msg.Content = data
+	return msg, nil
+}
+
+// readFromLine skips leading blank lines and returns the first separator line
+func (r *Reader) readFromLine() (string, error) {
+	for {
+		line, err := r.r.ReadString('\n')
+		if strings.TrimSpace(line) == "" {
+			if err == io.EOF {
+				return "", io.EOF
+			}
+			if err != nil {
+				return "", fmt.Errorf("failed to read mbox: %w", err)
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "From ") {
+			return "", fmt.Errorf("not an mbox file: expected a \"From \" line")
+		}
+		return line, nil
+	}
+}
+
+// unquote reverses the mboxrd quoting of a ">From " line
+func unquote(line string) string {
+	trimmed := strings.TrimLeft(line, ">")
+	if len(trimmed) < len(line) && strings.HasPrefix(trimmed, "From ") {
+		return line[1:]
+	}
+	return line
+}
+
+// parseFromLine reads sender and date from a "From sender date" line
+func parseFromLine(line string) *Message {
+	fields := strings.Fields(strings.TrimPrefix(line, "From "))
+	msg := &Message{}
+	if len(fields) == 0 {
+		return msg
+	}
+	msg.Sender = fields[0]
+
+	date := strings.Join(fields[1:], " ")
+	for _, layout := range []string{time.ANSIC, "Mon Jan 2 15:04:05 2006", "Mon Jan 2 15:04:05 -0700 2006", time.UnixDate} {
+		if t, err := time.Parse(layout, date); err == nil {
+			msg.Date = t
+			break
+		}
+	}
+	return msg
+}
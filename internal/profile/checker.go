@@ -9,6 +9,10 @@ import (
 // AnnotationKey is the cobra command annotation key for required permissions.
 const AnnotationKey = "required_permission"
 
+// DraftAnnotationKey is the annotation key for the permission required
+// instead when the command's --draft flag is set.
+const DraftAnnotationKey = "required_permission_draft"
+
 // PermissionDeniedError is returned when a command is blocked by a profile.
 type PermissionDeniedError struct {
 	Command    string
@@ -28,6 +32,11 @@ func CheckCommand(p *Profile, cmd *cobra.Command) error {
 	}
 
 	permission, ok := cmd.Annotations[AnnotationKey]
+	if draftPermission, has := cmd.Annotations[DraftAnnotationKey]; has {
+		if f := cmd.Flags().Lookup("draft"); f != nil && f.Value.String() == "true" {
+			permission, ok = draftPermission, true
+		}
+	}
 	if !ok {
 		return nil
 	}
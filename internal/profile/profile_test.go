@@ -99,6 +99,27 @@ func TestCheckCommand_Denied(t *testing.T) {
 	}
 }
 
+func TestCheckCommand_Draft(t *testing.T) {
+	p := &Profile{Name: "assistant", Allow: []string{"drafts.create"}}
+	var draft bool
+	cmd := &cobra.Command{
+		Use: "reply",
+		Annotations: map[string]string{
+			AnnotationKey:      "mail.send",
+			DraftAnnotationKey: "drafts.create",
+		},
+	}
+	cmd.Flags().BoolVar(&draft, "draft", false, "")
+
+	if err := CheckCommand(p, cmd); err == nil {
+		t.Error("should deny without --draft")
+	}
+	cmd.Flags().Set("draft", "true")
+	if err := CheckCommand(p, cmd); err != nil {
+		t.Errorf("should allow with --draft: %v", err)
+	}
+}
+
 func TestPermissionDeniedError_Message(t *testing.T) {
 	err := &PermissionDeniedError{
 		Command:    "mail send",
@@ -0,0 +1,97 @@
+package syncstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MaildirStateFile is the state file kept in the root of a synced Maildir
+const MaildirStateFile = ".o365-mail-cli-sync.json"
+
+// MaildirState is the state of a Maildir tree synced with a mailbox
+type MaildirState struct {
+	Account string `json:"account"`
+	// Folders are keyed by Graph folder ID
+	Folders map[string]*MaildirFolder `json:"folders"`
+	// Messages are keyed by their Maildir key, which stays stable while
+	// Graph assigns new IDs on moves
+	Messages map[string]*MaildirMessage `json:"messages"`
+
+	root string
+}
+
+// MaildirFolder is a mail folder mirrored as a Maildir
+type MaildirFolder struct {
+	// Path is the folder path below the Maildir root, e.g. "Inbox/Projects"
+	Path      string `json:"path"`
+	DeltaLink string `json:"delta_link"`
+}
+
+// MaildirMessage is the last synced state of a message
+type MaildirMessage struct {
+	ID                string `json:"id"`
+	FolderID          string `json:"folder_id"`
+	InternetMessageID string `json:"internet_message_id,omitempty"`
+	Subject           string `json:"subject,omitempty"`
+	// Flags are the Maildir flags both sides agreed on at the last sync
+	Flags string `json:"flags"`
+}
+
+// LoadMaildir reads the state of the Maildir tree at root.
+// A missing state file yields an empty state, not an error.
+func LoadMaildir(root string) (*MaildirState, error) {
+	state := &MaildirState{
+		Folders:  map[string]*MaildirFolder{},
+		Messages: map[string]*MaildirMessage{},
+		root:     root,
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, MaildirStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maildir sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse maildir sync state: %w", err)
+	}
+	if state.Folders == nil {
+		state.Folders = map[string]*MaildirFolder{}
+	}
+	if state.Messages == nil {
+		state.Messages = map[string]*MaildirMessage{}
+	}
+	return state, nil
+}
+
+// Save writes the state atomically
+func (s *MaildirState) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode maildir sync state: %w", err)
+	}
+
+	path := filepath.Join(s.root, MaildirStateFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write maildir sync state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write maildir sync state: %w", err)
+	}
+	return nil
+}
+
+// ByID returns the Maildir keys of the messages indexed by Graph message ID
+func (s *MaildirState) ByID() map[string]string {
+	keys := make(map[string]string, len(s.Messages))
+	for key, msg := range s.Messages {
+		keys[msg.ID] = key
+	}
+	return keys
+}
@@ -0,0 +1,246 @@
+package syncstate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+// StoreDirName is the directory below the cache dir holding synced messages
+const StoreDirName = "store"
+
+// ErrNotStored is returned when a message is not in the local store
+var ErrNotStored = errors.New("message not in local store")
+
+// StoredMessage is a message in the local store
+type StoredMessage struct {
+	mail.MessageDetail
+	// Folder is the folder name the message was synced with (e.g. "inbox")
+	Folder   string    `json:"folder"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Store keeps synced messages of one account as one JSON file per message
+type Store struct {
+	dir string
+}
+
+// SearchOptions filters messages in the local store. Empty fields match everything.
+type SearchOptions struct {
+	// Text must occur word by word in subject, sender, recipients, body or attachment names
+	Text    string
+	From    string
+	Subject string
+	// Folder matches the folder name or ID; empty or "all" searches all folders
+	Folder     string
+	Since      time.Time
+	UnreadOnly bool
+	Flagged    bool
+	// Category is a category name the messages must have
+	Category string
+	// Importance is the importance the messages must have
+	Importance string
+	// Limit caps the results (0 = no limit)
+	Limit int
+}
+
+// OpenStore returns the message store of an account
+func OpenStore(cacheDir, account string) *Store {
+	if account == "" {
+		account = "default"
+	}
+	return &Store{dir: filepath.Join(cacheDir, StoreDirName, sanitize(account))}
+}
+
+// Dir returns the directory holding the stored messages
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(messageID string) string {
+	sum := sha256.Sum256([]byte(messageID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Put stores or replaces a message
+func (s *Store) Put(msg *StoredMessage) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	path := s.path(msg.MessageID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// Get returns a stored message or ErrNotStored
+func (s *Store) Get(messageID string) (*StoredMessage, error) {
+	data, err := os.ReadFile(s.path(messageID))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", messageID, ErrNotStored)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	var msg StoredMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse stored message: %w", err)
+	}
+	return &msg, nil
+}
+
+// Remove deletes a message if it is stored for the given folder ID.
+// Messages moved to another synced folder are kept.
+func (s *Store) Remove(messageID, folderID string) error {
+	msg, err := s.Get(messageID)
+	if errors.Is(err, ErrNotStored) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.FolderID != folderID {
+		return nil
+	}
+
+	if err := os.Remove(s.path(messageID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove message: %w", err)
+	}
+	return nil
+}
+
+// Search returns the stored messages matching opts, newest first
+func (s *Store) Search(opts SearchOptions) ([]*StoredMessage, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+
+	words := strings.Fields(strings.ToLower(opts.Text))
+	var result []*StoredMessage
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read store: %w", err)
+		}
+		var msg StoredMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			// Skip damaged files instead of failing every search
+			continue
+		}
+
+		if matches(&msg, &opts, words) {
+			result = append(result, &msg)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Date.After(result[j].Date)
+	})
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
+	}
+	return result, nil
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func matches(msg *StoredMessage, opts *SearchOptions, words []string) bool {
+	if opts.Folder != "" && !strings.EqualFold(opts.Folder, "all") &&
+		!strings.EqualFold(opts.Folder, msg.Folder) && opts.Folder != msg.FolderID {
+		return false
+	}
+	if opts.UnreadOnly && !msg.Unread {
+		return false
+	}
+	if opts.Flagged && !msg.Flagged {
+		return false
+	}
+	if opts.Category != "" && !hasCategory(msg.Categories, opts.Category) {
+		return false
+	}
+	if opts.Importance != "" && !strings.EqualFold(opts.Importance, importanceOf(msg)) {
+		return false
+	}
+	if !opts.Since.IsZero() && msg.Date.Before(opts.Since) {
+		return false
+	}
+	if opts.From != "" && !containsFold(msg.From, opts.From) {
+		return false
+	}
+	if opts.Subject != "" && !containsFold(msg.Subject, opts.Subject) {
+		return false
+	}
+	if len(words) == 0 {
+		return true
+	}
+
+	body := msg.Body
+	if strings.EqualFold(msg.BodyType, "html") {
+		body = htmlTag.ReplaceAllString(body, " ")
+	}
+	fields := []string{msg.Subject, msg.From, strings.Join(msg.To, " "), strings.Join(msg.Cc, " "), body}
+	for _, att := range msg.Attachments {
+		fields = append(fields, att.Name)
+	}
+	text := strings.ToLower(strings.Join(fields, "\n"))
+
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// importanceOf returns the importance of a message; messages synced
+// before importance was stored count as normal
+func importanceOf(msg *StoredMessage) string {
+	if msg.Importance == "" {
+		return "normal"
+	}
+	return msg.Importance
+}
+
+func hasCategory(categories []string, name string) bool {
+	for _, c := range categories {
+		if strings.EqualFold(c, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,88 @@
+package syncstate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+func storedMessage(id, folderID, subject, body string, date time.Time) *StoredMessage {
+	msg := &StoredMessage{Folder: folderID}
+	msg.MessageID = id
+	msg.FolderID = folderID
+	msg.Subject = subject
+	msg.Body = body
+	msg.Date = date
+	return msg
+}
+
+func TestStoreSearch(t *testing.T) {
+	store := OpenStore(t.TempDir(), "user@example.com")
+	now := time.Now()
+	for _, msg := range []*StoredMessage{
+		storedMessage("1", "inbox", "Budget", "numbers for 2024", now.Add(-time.Hour)),
+		storedMessage("2", "inbox", "Lunch", "pizza", now),
+		storedMessage("3", "archive", "Old budget", "numbers for 2023", now.Add(-90*24*time.Hour)),
+	} {
+		if err := store.Put(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		opts SearchOptions
+		want []string
+	}{
+		{SearchOptions{Text: "numbers"}, []string{"1", "3"}},
+		{SearchOptions{Text: "budget 2024"}, []string{"1"}},
+		{SearchOptions{Folder: "Inbox"}, []string{"2", "1"}},
+		{SearchOptions{Text: "numbers", Since: now.Add(-24 * time.Hour)}, []string{"1"}},
+		{SearchOptions{Limit: 1}, []string{"2"}},
+	}
+	for _, tt := range tests {
+		got, err := store.Search(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, m := range got {
+			ids = append(ids, m.MessageID)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.opts, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("%+v: got %v, want %v", tt.opts, ids, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestStoreRemoveKeepsMovedMessage(t *testing.T) {
+	store := OpenStore(t.TempDir(), "")
+	msg := storedMessage("1", "archive", "Moved", "", time.Now())
+	msg.Attachments = []mail.AttachmentInfo{{Name: "a.pdf"}}
+	if err := store.Put(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removal reported by the inbox sync must not drop the archived copy
+	if err := store.Remove("1", "inbox"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("1"); err != nil {
+		t.Fatalf("message removed from wrong folder: %v", err)
+	}
+
+	if err := store.Remove("1", "archive"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("1"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("err = %v, want ErrNotStored", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package syncstate persists the delta sync state of mail folders and
+// the messages synced from them, per account under the cache directory.
+package syncstate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName is the directory below the cache dir holding sync state
+const DirName = "sync"
+
+// FolderState is the sync state of one folder of one account
+type FolderState struct {
+	Account   string    `json:"account"`
+	FolderID  string    `json:"folder_id"`
+	Folder    string    `json:"folder"`
+	DeltaLink string    `json:"delta_link"`
+	SyncedAt  time.Time `json:"synced_at"`
+	// MessageIDs are the messages known to be in the folder after the last sync
+	MessageIDs []string `json:"message_ids"`
+}
+
+// Path returns the state file for an account and folder
+func Path(cacheDir, account, folderID string) string {
+	if account == "" {
+		account = "default"
+	}
+	// Folder IDs are long and may contain '/', so the file is named by their hash
+	sum := sha256.Sum256([]byte(folderID))
+	return filepath.Join(cacheDir, DirName, sanitize(account), hex.EncodeToString(sum[:])+".json")
+}
+
+// Load reads the state for an account and folder.
+// A missing state file yields an empty state, not an error.
+func Load(cacheDir, account, folderID string) (*FolderState, error) {
+	state := &FolderState{Account: account, FolderID: folderID}
+
+	data, err := os.ReadFile(Path(cacheDir, account, folderID))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return state, nil
+}
+
+// Save writes the state atomically so an interrupted sync never leaves a broken file
+func (s *FolderState) Save(cacheDir string) error {
+	path := Path(cacheDir, s.Account, s.FolderID)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create sync state directory: %w", err)
+	}
+
+	sort.Strings(s.MessageIDs)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the state for an account and folder
+func Remove(cacheDir, account, folderID string) error {
+	err := os.Remove(Path(cacheDir, account, folderID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sync state: %w", err)
+	}
+	return nil
+}
+
+// Known returns the message IDs of the last sync as a set
+func (s *FolderState) Known() map[string]bool {
+	known := make(map[string]bool, len(s.MessageIDs))
+	for _, id := range s.MessageIDs {
+		known[id] = true
+	}
+	return known
+}
+
+// SetKnown replaces the known message IDs
+func (s *FolderState) SetKnown(known map[string]bool) {
+	s.MessageIDs = s.MessageIDs[:0]
+	for id := range known {
+		s.MessageIDs = append(s.MessageIDs, id)
+	}
+}
+
+// sanitize makes an account name safe to use as a directory name
+func sanitize(name string) string {
+	if strings.Trim(name, ".") == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '.', r == '-', r == '_', r == '@', r == '=':
+			return r
+		}
+		return '_'
+	}, name)
+}
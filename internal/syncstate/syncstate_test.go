@@ -0,0 +1,50 @@
+package syncstate
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMissing(t *testing.T) {
+	state, err := Load(t.TempDir(), "user@example.com", "inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.DeltaLink != "" || len(state.MessageIDs) != 0 {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	state := &FolderState{Account: "user@example.com", FolderID: "AAMk/abc+=", DeltaLink: "https://graph/delta"}
+	state.SetKnown(map[string]bool{"b": true, "a": true})
+
+	if err := state.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir, "user@example.com", "AAMk/abc+=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.DeltaLink != state.DeltaLink || strings.Join(loaded.MessageIDs, ",") != "a,b" {
+		t.Errorf("loaded = %+v", loaded)
+	}
+
+	if err := Remove(dir, "user@example.com", "AAMk/abc+="); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ := Load(dir, "user@example.com", "AAMk/abc+="); loaded.DeltaLink != "" {
+		t.Error("state not removed")
+	}
+}
+
+func TestPathStaysInCacheDir(t *testing.T) {
+	for _, account := range []string{"..", "../../etc", ""} {
+		path := Path("/cache", account, "inbox")
+		if !strings.HasPrefix(filepath.Clean(path), filepath.Join("/cache", DirName)+"/") {
+			t.Errorf("account %q: path %q escapes the sync dir", account, path)
+		}
+	}
+}
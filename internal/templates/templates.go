@@ -0,0 +1,232 @@
+package templates
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/config"
+	"github.com/yourname/o365-mail-cli/internal/mail"
+	"gopkg.in/yaml.v3"
+)
+
+const templatesDirName = "templates"
+
+// Formats are the body formats a template can have
+var Formats = []string{"text", "html", "markdown"}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Template is a stored message template. Subject and Body are Go
+// text/template sources; the body of an HTML template is an html/template,
+// so inserted values are escaped.
+type Template struct {
+	Name        string   `yaml:"-" json:"name"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Subject     string   `yaml:"subject,omitempty" json:"subject,omitempty"`
+	Format      string   `yaml:"format,omitempty" json:"format,omitempty"`
+	To          []string `yaml:"to,omitempty" json:"to,omitempty"`
+	Cc          []string `yaml:"cc,omitempty" json:"cc,omitempty"`
+	Bcc         []string `yaml:"bcc,omitempty" json:"bcc,omitempty"`
+	Body        string   `yaml:"body" json:"body"`
+}
+
+// Original holds the fields of the message a template replies to,
+// available as {{.Original.SenderName}} etc.
+type Original struct {
+	SenderName      string
+	SenderFirstName string
+	SenderEmail     string
+	Subject         string
+	Date            time.Time
+}
+
+// NewOriginal extracts the template fields of a message. Without a display
+// name the sender name is the address.
+func NewOriginal(email *mail.Email) *Original {
+	o := &Original{Subject: email.Subject, Date: email.Date, SenderEmail: mail.ParseEmail(email.From)}
+	if addr, err := netmail.ParseAddress(email.From); err == nil {
+		o.SenderName, o.SenderEmail = addr.Name, addr.Address
+	}
+	if o.SenderName == "" {
+		o.SenderName = o.SenderEmail
+	}
+	if fields := strings.Fields(o.SenderName); len(fields) > 0 {
+		o.SenderFirstName = fields[0]
+	}
+	return o
+}
+
+// Dir returns the path to ~/.o365-mail-cli/templates/
+func Dir() string {
+	return filepath.Join(config.GetConfigDir(), templatesDirName)
+}
+
+// Validate checks the name, format and template syntax
+func (t *Template) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid template name %q: use letters, digits, '.', '_' and '-'", t.Name)
+	}
+	switch t.Format {
+	case "", "text", "html", "markdown":
+	default:
+		return fmt.Errorf("invalid format %q (valid: %s)", t.Format, strings.Join(Formats, ", "))
+	}
+	if _, err := parse("subject", t.Subject, false); err != nil {
+		return err
+	}
+	if _, err := parse("body", t.Body, t.Format == "html"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render executes subject and body with the given variables and, for
+// replies, the original message. Variables that are used but not given
+// are an error.
+func (t *Template) Render(vars map[string]string, original *Original) (subject, body string, err error) {
+	data := make(map[string]interface{}, len(vars)+1)
+	for k, v := range vars {
+		data[k] = v
+	}
+	if original != nil {
+		data["Original"] = original
+	}
+
+	if subject, err = execute("subject", t.Subject, false, data); err != nil {
+		return "", "", fmt.Errorf("template %q: %w", t.Name, err)
+	}
+	if body, err = execute("body", t.Body, t.Format == "html", data); err != nil {
+		return "", "", fmt.Errorf("template %q: %w", t.Name, err)
+	}
+	return subject, body, nil
+}
+
+// executor is implemented by both text/template and html/template
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parse parses src as html/template if html is set, else as text/template
+func parse(name, src string, html bool) (executor, error) {
+	var tmpl executor
+	var err error
+	if html {
+		tmpl, err = htmltemplate.New(name).Option("missingkey=error").Parse(src)
+	} else {
+		tmpl, err = template.New(name).Option("missingkey=error").Parse(src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func execute(name, src string, html bool, data map[string]interface{}) (string, error) {
+	tmpl, err := parse(name, src, html)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Load loads a template by name from the templates directory
+func Load(name string) (*Template, error) {
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("template '%s' %w", name, mail.ErrNotFound)
+	}
+	return loadFromPath(filepath.Join(Dir(), name+".yaml"), name)
+}
+
+// List loads all templates, sorted by name
+func List() ([]*Template, error) {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+
+	var list []*Template
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yaml" {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".yaml")
+		t, err := loadFromPath(filepath.Join(Dir(), entry.Name()), name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Exists reports whether a template with this name is stored
+func Exists(name string) bool {
+	_, err := os.Stat(filepath.Join(Dir(), name+".yaml"))
+	return err == nil
+}
+
+// Save validates and stores a template, replacing one with the same name
+func Save(t *Template) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template: %w", err)
+	}
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(Dir(), t.Name+".yaml"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a stored template
+func Delete(name string) error {
+	if !namePattern.MatchString(name) || !Exists(name) {
+		return fmt.Errorf("template '%s' %w", name, mail.ErrNotFound)
+	}
+	if err := os.Remove(filepath.Join(Dir(), name+".yaml")); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func loadFromPath(path, name string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("template '%s' %w", name, mail.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to read template %q: %w", name, err)
+	}
+
+	var t Template
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse template %q: %w", name, err)
+	}
+	t.Name = name
+
+	return &t, nil
+}
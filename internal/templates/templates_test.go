@@ -0,0 +1,116 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourname/o365-mail-cli/internal/mail"
+)
+
+func TestSaveLoadListDelete(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if list, err := List(); err != nil || len(list) != 0 {
+		t.Fatalf("List() = %v, %v, want empty", list, err)
+	}
+
+	for _, name := range []string{"thanks", "escalate"} {
+		tmpl := &Template{Name: name, Subject: "Re: {{.Original.Subject}}", Body: "Hi", Format: "markdown", Cc: []string{"lead@example.com"}}
+		if err := Save(tmpl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := Load("thanks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "thanks" || loaded.Format != "markdown" || len(loaded.Cc) != 1 || loaded.Body != "Hi" {
+		t.Errorf("Load() = %+v", loaded)
+	}
+
+	list, err := List()
+	if err != nil || len(list) != 2 || list[0].Name != "escalate" {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+
+	if err := Delete("thanks"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load("thanks"); !errors.Is(err, mail.ErrNotFound) {
+		t.Errorf("Load() after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := Delete("../config"); !errors.Is(err, mail.ErrNotFound) {
+		t.Errorf("Delete(../config): err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tmpl := range []*Template{
+		{Name: "../evil", Body: "x"},
+		{Name: "bad-format", Body: "x", Format: "rtf"},
+		{Name: "bad-syntax", Body: "Hi {{.name"},
+	} {
+		if err := tmpl.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", tmpl)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tmpl := &Template{
+		Name:    "thanks",
+		Subject: "Ticket {{.ticket}}",
+		Body:    "Hi {{.Original.SenderFirstName}}, about \"{{.Original.Subject}}\" from {{.Original.Date.Format \"2 Jan\"}}: {{.ticket}}",
+	}
+	original := NewOriginal(&mail.Email{
+		From:    "Anna Smith <anna@example.com>",
+		Subject: "Login broken",
+		Date:    time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC),
+	})
+
+	subject, body, err := tmpl.Render(map[string]string{"ticket": "4711"}, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Ticket 4711" || body != `Hi Anna, about "Login broken" from 5 Mar: 4711` {
+		t.Errorf("Render() = %q, %q", subject, body)
+	}
+
+	if _, _, err := tmpl.Render(nil, original); err == nil || !strings.Contains(err.Error(), "ticket") {
+		t.Errorf("err = %v, want error naming the missing variable", err)
+	}
+	if _, _, err := tmpl.Render(map[string]string{"ticket": "1"}, nil); err == nil {
+		t.Error("expected error for original fields without original")
+	}
+}
+
+func TestRender_HTMLEscapesValues(t *testing.T) {
+	original := NewOriginal(&mail.Email{From: `"<script>alert(1)</script>" <evil@example.com>`, Subject: "a & b"})
+
+	tmpl := &Template{Name: "thanks", Format: "html", Subject: "Re: {{.Original.Subject}}", Body: "<p>Hi {{.Original.SenderName}}</p>"}
+	subject, body, err := tmpl.Render(nil, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Re: a & b" {
+		t.Errorf("subject = %q, want it unescaped", subject)
+	}
+	if body != "<p>Hi &lt;script&gt;alert(1)&lt;/script&gt;</p>" {
+		t.Errorf("body = %q, want the sender name escaped", body)
+	}
+
+	tmpl.Format = "markdown"
+	if _, body, _ := tmpl.Render(nil, original); !strings.Contains(body, "<script>") {
+		t.Errorf("markdown body = %q, want text/template output", body)
+	}
+}
+
+func TestNewOriginal_AddressOnly(t *testing.T) {
+	o := NewOriginal(&mail.Email{From: "noreply@example.com"})
+	if o.SenderName != "noreply@example.com" || o.SenderEmail != "noreply@example.com" || o.SenderFirstName != "noreply@example.com" {
+		t.Errorf("NewOriginal() = %+v", o)
+	}
+}